@@ -0,0 +1,77 @@
+package gohtmock
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"path"
+)
+
+type allowed struct {
+	method  string
+	pattern string
+}
+
+// Allow turns on allowlist mode: from then on only requests with method to
+// a path matching pattern, as for Forbid, are acceptable. Any other request
+// is answered with 403 Forbidden and reported with a dump of the request,
+// even if a mock would answer it. Violations are logged and reported by
+// AssertNotForbidden. Use For(t).Allow to fail t immediately.
+func (m *Mock) Allow(method, pattern string) {
+	m.allow(method, pattern, nil)
+}
+
+// Allow is Mock.Allow, failing the owner of o as soon as a request outside
+// the allowlist arrives.
+func (o *Owned) Allow(method, pattern string) {
+	o.mock.allow(method, pattern, o.owner)
+}
+
+func (m *Mock) allow(method, pattern string, o *owner) {
+	if _, err := path.Match(pattern, "/"); err != nil {
+		panic(fmt.Sprintf("gohtmock: invalid allowed path %q: %s", pattern, err))
+	}
+	m.Lock()
+	m.allowed = append(m.allowed, allowed{method: method, pattern: pattern})
+	if o != nil {
+		m.allowOwner = o
+	}
+	m.Unlock()
+}
+
+// serveDisallowed answers r with 403 if allowlist mode is on and r is not
+// allowed, and reports if it did.
+func (m *Mock) serveDisallowed(w http.ResponseWriter, r *http.Request) bool {
+	m.Lock()
+	if len(m.allowed) == 0 {
+		m.Unlock()
+		return false
+	}
+	for _, a := range m.allowed {
+		if a.method != r.Method && a.method != "ANY" {
+			continue
+		}
+		if ok, _ := path.Match(a.pattern, r.URL.Path); ok {
+			m.Unlock()
+			return false
+		}
+	}
+	o := m.allowOwner
+	m.Unlock()
+
+	dump, err := httputil.DumpRequest(r, true)
+	if err != nil {
+		dump = []byte(fmt.Sprintf("dumping request: %s", err))
+	}
+	msg := fmt.Sprintf("request %s %s is not allowed:\n%s", r.Method, r.URL.RequestURI(), dump)
+	m.Lock()
+	m.disallowed = append(m.disallowed, msg)
+	m.Unlock()
+	if o == nil || !o.fail("%s", msg) {
+		log.Print("gohtmock: ", msg)
+	}
+	w.WriteHeader(http.StatusForbidden)
+	fmt.Fprintf(w, "gohtmock: request %s %s is not allowed", r.Method, r.URL.RequestURI())
+	return true
+}
@@ -0,0 +1,39 @@
+package gohtmock
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllow(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/users/*", "{}").SetMethod("ANY")
+	rt := newRecordingT("TestAllow")
+	mock.For(rt).Allow("GET", "/users/*")
+	mock.Allow("ANY", "/health")
+
+	do := func(method, path string) int {
+		req, _ := http.NewRequest(method, mock.URL()+path, strings.NewReader("payload"))
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, do("GET", "/users/1"))
+	assert.Equal(t, http.StatusNotFound, do("POST", "/health"))
+	assert.Empty(t, rt.Errors())
+
+	assert.Equal(t, http.StatusForbidden, do("DELETE", "/users/1"))
+	assert.Len(t, rt.Errors(), 1)
+	assert.True(t, strings.HasPrefix(rt.Errors()[0], "request DELETE /users/1 is not allowed:\nDELETE /users/1 HTTP/1.1\r\n"))
+	assert.True(t, strings.HasSuffix(rt.Errors()[0], "\r\n\r\npayload"))
+
+	rt = newRecordingT("TestAllow")
+	mock.AssertNotForbidden(rt)
+	assert.Len(t, rt.Errors(), 1)
+}
@@ -0,0 +1,83 @@
+package gohtmock
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const packagePath = "github.com/fortnoxab/gohtmock"
+
+// DetectAmbiguousFilters makes tb fail when a request passes the filters of
+// more than one mock of the same Priority on the same method and path.
+// Matchers such as MatchHeader and MatchBody count as filters. The first
+// registered mock still answers; the failure names where all the matching
+// mocks were registered.
+func (m *Mock) DetectAmbiguousFilters(tb testing.TB) {
+	m.Lock()
+	m.onAmbiguous = func(msg string) { tb.Errorf("%s", msg) }
+	m.Unlock()
+}
+
+// LogAmbiguousFilters is like DetectAmbiguousFilters but only logs.
+func (m *Mock) LogAmbiguousFilters() {
+	m.Lock()
+	m.onAmbiguous = func(msg string) { log.Print("gohtmock: ", msg) }
+	m.Unlock()
+}
+
+// checkAmbiguous reports if any filtered mock after served in candidates also
+// matches the request. m must be locked.
+func (m *Mock) checkAmbiguous(served *mockResponse, candidates []*mockResponse, method, path string, r *http.Request) {
+	if m.onAmbiguous == nil || !served.filtered() {
+		return
+	}
+	sites := []string{served.registeredAt + " (served)"}
+	after := false
+	for _, v := range candidates {
+		if v == served {
+			after = true
+			continue
+		}
+		if !after || !v.filtered() || v.priority != served.priority || !v.matches(method, path) || v.depleted() {
+			continue
+		}
+		rewind(r)
+		if v.checkFilter(r) {
+			sites = append(sites, v.registeredAt)
+		}
+	}
+	if len(sites) > 1 {
+		m.onAmbiguous(fmt.Sprintf("ambiguous filters: %s %s matches mocks registered at %s", method, path, strings.Join(sites, ", ")))
+	}
+}
+
+// filtered reports if mr has a filter or matchers narrowing the requests it
+// answers.
+func (mr *mockResponse) filtered() bool {
+	mr.Lock()
+	defer mr.Unlock()
+	return mr.filter != nil || len(mr.query) > 0 || len(mr.rawHeaderMatchers) > 0 || len(mr.messageMatchers) > 0 ||
+		len(mr.bodyMatchers) > 0 || len(mr.requestMatchers) > 0
+}
+
+// callerOutsidePackage returns file:line of the first caller that is not
+// part of gohtmock itself, tests excluded.
+func callerOutsidePackage() string {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packagePath) || strings.HasSuffix(frame.File, "_test.go") {
+			return fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectAmbiguousFilters(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	rt := newRecordingT("TestAmbiguous")
+	mock.DetectAmbiguousFilters(rt)
+
+	admin := func(r *http.Request) bool { return r.URL.Query().Get("role") == "admin" }
+	anyRole := func(r *http.Request) bool { return r.URL.Query().Get("role") != "" }
+	mock.Mock("/users", "admin").Filter(admin)
+	mock.Mock("/users", "any").Filter(anyRole)
+	mock.Mock("/users", "unfiltered")
+
+	_, err := http.Get(mock.URL() + "/users?role=user")
+	assert.NoError(t, err)
+	assert.Empty(t, rt.Errors())
+
+	_, err = http.Get(mock.URL() + "/users?role=admin")
+	assert.NoError(t, err)
+	errs := rt.Errors()
+	if assert.Len(t, errs, 1) {
+		assert.True(t, strings.HasPrefix(errs[0], "ambiguous filters: GET /users matches mocks registered at ambiguous_test.go:"), errs[0])
+		assert.Equal(t, 2, strings.Count(errs[0], "ambiguous_test.go:"))
+		assert.Contains(t, errs[0], "(served)")
+	}
+}
+
+func TestDetectAmbiguousMatchers(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	rt := newRecordingT("TestAmbiguousMatchers")
+	mock.DetectAmbiguousFilters(rt)
+
+	mock.Mock("POST /users", "json").MatchHeader("Content-Type", "application/json")
+	mock.Mock("POST /users", "alice").MatchBodyString(`{"name":"alice"}`)
+
+	resp, err := http.Post(mock.URL()+"/users", "text/plain", strings.NewReader(`{"name":"alice"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, rt.Errors())
+
+	resp, err = http.Post(mock.URL()+"/users", "application/json", strings.NewReader(`{"name":"alice"}`))
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "json", string(body))
+	assert.Len(t, rt.Errors(), 1)
+}
@@ -0,0 +1,51 @@
+package gohtmock
+
+import (
+	"context"
+	"sync"
+)
+
+// barrier parks requests until n have arrived and then releases them together.
+type barrier struct {
+	n       int
+	arrived int
+	release chan struct{}
+	sync.Mutex
+}
+
+// HoldUntil makes mr park incoming requests until n of them have arrived and
+// then answer them simultaneously. Later requests form new groups of n.
+// Parked requests are dropped if the client goes away, for example when the
+// Mock is closed.
+func (mr *mockResponse) HoldUntil(n int) *mockResponse {
+	mr.Lock()
+	mr.barrier = &barrier{n: n, release: make(chan struct{})}
+	mr.Unlock()
+	return mr
+}
+
+// wait blocks until the group of the calling request is complete. It
+// returns false if ctx is done first.
+func (b *barrier) wait(ctx context.Context) bool {
+	b.Lock()
+	release := b.release
+	b.arrived++
+	if b.arrived >= b.n {
+		close(b.release)
+		b.arrived = 0
+		b.release = make(chan struct{})
+	}
+	b.Unlock()
+
+	select {
+	case <-release:
+		return true
+	case <-ctx.Done():
+		b.Lock()
+		if b.release == release {
+			b.arrived--
+		}
+		b.Unlock()
+		return false
+	}
+}
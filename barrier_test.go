@@ -0,0 +1,50 @@
+package gohtmock
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHoldUntil(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/herd", "{}").HoldUntil(3)
+
+	var wg sync.WaitGroup
+	done := make(chan time.Time, 6)
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			time.Sleep(time.Duration(i/3) * 50 * time.Millisecond)
+			resp, err := http.Get(mock.URL() + "/herd")
+			assert.NoError(t, err)
+			resp.Body.Close()
+			done <- time.Now()
+		}(i)
+	}
+	wg.Wait()
+	close(done)
+	var times []time.Time
+	for tm := range done {
+		times = append(times, tm)
+	}
+	assert.Len(t, times, 6)
+	assert.WithinDuration(t, times[0], times[2], 20*time.Millisecond)
+	assert.WithinDuration(t, times[3], times[5], 20*time.Millisecond)
+	mock.AssertCallCount(t, "GET", "/herd", 6)
+}
+
+func TestHoldUntilClientGone(t *testing.T) {
+	mock := New()
+	mock.Mock("/herd", "{}").HoldUntil(2)
+
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	_, err := client.Get(mock.URL() + "/herd")
+	assert.Error(t, err)
+	mock.Close()
+}
@@ -0,0 +1,37 @@
+package gohtmock
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Response bodies starting with one of these prefixes are not sent as is but
+// resolved when the mock is called:
+//
+//	@file:testdata/users.json  the content of the file
+//	@env:FIXTURE_USERS         the value of the environment variable
+//
+// A body that really should start with @ can be escaped as @@.
+const (
+	bodyFilePrefix = "@file:"
+	bodyEnvPrefix  = "@env:"
+)
+
+func resolveBody(resp string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(resp, bodyFilePrefix):
+		return ioutil.ReadFile(strings.TrimPrefix(resp, bodyFilePrefix))
+	case strings.HasPrefix(resp, bodyEnvPrefix):
+		name := strings.TrimPrefix(resp, bodyEnvPrefix)
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s is not set", name)
+		}
+		return []byte(v), nil
+	case strings.HasPrefix(resp, "@@"):
+		return []byte(resp[1:]), nil
+	}
+	return []byte(resp), nil
+}
@@ -0,0 +1,42 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndirectBody(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/file", "@file:testdata/wiremock/__files/user.json")
+	mock.Mock("/env", "@env:GOHTMOCK_TEST_FIXTURE")
+	mock.Mock("/escaped", "@@env:literal")
+
+	// resolved when called, not when mocked
+	t.Setenv("GOHTMOCK_TEST_FIXTURE", `{"users":[]}`)
+
+	for path, expected := range map[string]string{
+		"/file":    "{\"id\":1,\"name\":\"foo\"}\n",
+		"/env":     `{"users":[]}`,
+		"/escaped": "@env:literal",
+	} {
+		resp, err := http.Get(mock.URL() + path)
+		assert.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, string(body), path)
+	}
+}
+
+func TestIndirectBodyMissing(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/env", "@env:GOHTMOCK_TEST_MISSING")
+
+	resp, err := http.Get(mock.URL() + "/env")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
@@ -0,0 +1,133 @@
+package gohtmock
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// CacheStats counts how clients fetched a mock using CacheFor.
+type CacheStats struct {
+	// Fetches are unconditional requests
+	Fetches int
+	// Revalidations are requests with If-None-Match or If-Modified-Since
+	Revalidations int
+	// NotModified are revalidations answered with 304 Not Modified
+	NotModified int
+}
+
+type cacheState struct {
+	maxAge       time.Duration
+	version      int
+	lastModified time.Time
+	stats        CacheStats
+}
+
+// CacheFor makes mr a cacheable resource fresh for maxAge. Responses carry
+// Cache-Control, Expires, ETag and Last-Modified, and conditional requests
+// with current validators are answered with 304 Not Modified. Use
+// UpdateResource to change the resource and its validators.
+func (mr *mockResponse) CacheFor(maxAge time.Duration) *mockResponse {
+	mr.Lock()
+	mr.cache = &cacheState{maxAge: maxAge, version: 1, lastModified: time.Now().UTC().Truncate(time.Second)}
+	mr.Unlock()
+	return mr
+}
+
+// WithAge makes mr report that its response has been cached for age, as a
+// shared cache in front of the server would.
+func (mr *mockResponse) WithAge(age time.Duration) *mockResponse {
+	return mr.SetHeader("Age", fmt.Sprint(int(age.Seconds())))
+}
+
+// UpdateResource replaces the body of mr and, with CacheFor, bumps its
+// ETag and Last-Modified so that revalidating clients refetch it.
+func (mr *mockResponse) UpdateResource(body string) *mockResponse {
+	mr.Lock()
+	defer mr.Unlock()
+	mr.resp = body
+	if mr.cache != nil {
+		mr.cache.version++
+		lastModified := time.Now().UTC().Truncate(time.Second)
+		if !lastModified.After(mr.cache.lastModified) {
+			lastModified = mr.cache.lastModified.Add(time.Second)
+		}
+		mr.cache.lastModified = lastModified
+	}
+	return mr
+}
+
+// CacheStats returns how mr has been fetched since CacheFor.
+func (mr *mockResponse) CacheStats() CacheStats {
+	mr.Lock()
+	defer mr.Unlock()
+	if mr.cache == nil {
+		return CacheStats{}
+	}
+	return mr.cache.stats
+}
+
+// AssertRevalidations asserts that clients revalidated mr n times.
+func (mr *mockResponse) AssertRevalidations(tb testing.TB, n int) {
+	if mr.httpMock.assertionsDisabled(tb) {
+		return
+	}
+	if got := mr.CacheStats().Revalidations; got != n {
+		tb.Errorf("%s %s revalidated %d times, expected %d", mr.method, mr.path, got, n)
+	}
+}
+
+// AssertFetches asserts that clients fetched mr unconditionally n times.
+func (mr *mockResponse) AssertFetches(tb testing.TB, n int) {
+	if mr.httpMock.assertionsDisabled(tb) {
+		return
+	}
+	if got := mr.CacheStats().Fetches; got != n {
+		tb.Errorf("%s %s fetched %d times, expected %d", mr.method, mr.path, got, n)
+	}
+}
+
+// serveCached adds the cache headers of mr and answers r with 304 if its
+// validators are current. It reports if it answered r.
+func (mr *mockResponse) serveCached(w http.ResponseWriter, r *http.Request) bool {
+	mr.Lock()
+	defer mr.Unlock()
+	c := mr.cache
+	if c == nil {
+		return false
+	}
+	etag := fmt.Sprintf(`"v%d"`, c.version)
+	h := w.Header()
+	h.Set("Cache-Control", fmt.Sprintf("max-age=%d", int(c.maxAge.Seconds())))
+	h.Set("Expires", time.Now().Add(c.maxAge).UTC().Format(http.TimeFormat))
+	h.Set("ETag", etag)
+	h.Set("Last-Modified", c.lastModified.Format(http.TimeFormat))
+
+	inm := r.Header.Get("If-None-Match")
+	ims := r.Header.Get("If-Modified-Since")
+	if inm == "" && ims == "" {
+		c.stats.Fetches++
+		return false
+	}
+	c.stats.Revalidations++
+	notModified := false
+	if inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == etag || tag == "*" {
+				notModified = true
+			}
+		}
+	} else if t, err := http.ParseTime(ims); err == nil && !c.lastModified.After(t) {
+		notModified = true
+	}
+	if !notModified {
+		return false
+	}
+	c.stats.NotModified++
+	h.Del("Content-Type")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
@@ -0,0 +1,58 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheFor(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mr := mock.Mock("/config", `{"v":1}`).CacheFor(time.Minute).WithAge(10 * time.Second)
+
+	get := func(header, value string) (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodGet, mock.URL()+"/config", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	resp, body := get("", "")
+	assert.Equal(t, `{"v":1}`, body)
+	assert.Equal(t, "max-age=60", resp.Header.Get("Cache-Control"))
+	assert.Equal(t, "10", resp.Header.Get("Age"))
+	assert.NotEmpty(t, resp.Header.Get("Expires"))
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	assert.Equal(t, `"v1"`, etag)
+
+	resp, body = get("If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	assert.Equal(t, "", body)
+	resp, _ = get("If-Modified-Since", lastModified)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	mr.UpdateResource(`{"v":2}`)
+	resp, body = get("If-None-Match", etag)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"v":2}`, body)
+	assert.Equal(t, `"v2"`, resp.Header.Get("ETag"))
+	resp, _ = get("If-Modified-Since", lastModified)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, CacheStats{Fetches: 1, Revalidations: 4, NotModified: 2}, mr.CacheStats())
+	mr.AssertFetches(t, 1)
+	mr.AssertRevalidations(t, 4)
+	rt := newRecordingT("TestCacheFor")
+	mr.AssertFetches(rt, 2)
+	assert.Equal(t, []string{"GET /config fetched 1 times, expected 2"}, rt.Errors())
+}
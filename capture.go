@@ -0,0 +1,75 @@
+package gohtmock
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// responseCapture passes a response through while keeping its status,
+// headers and up to maxBody bytes of its body, no limit if maxBody < 0.
+type responseCapture struct {
+	http.ResponseWriter
+	status    int
+	body      []byte
+	size      int64
+	maxBody   int
+	truncated bool
+}
+
+func newResponseCapture(w http.ResponseWriter, maxBody int) *responseCapture {
+	return &responseCapture{ResponseWriter: w, maxBody: maxBody}
+}
+
+func (c *responseCapture) WriteHeader(status int) {
+	if c.status == 0 && !isInformational(status) {
+		c.status = status
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *responseCapture) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	keep := len(b)
+	if c.maxBody >= 0 && len(c.body)+keep > c.maxBody {
+		keep = c.maxBody - len(c.body)
+		c.truncated = true
+	}
+	c.body = append(c.body, b[:keep]...)
+	n, err := c.ResponseWriter.Write(b)
+	c.size += int64(n)
+	return n, err
+}
+
+func (c *responseCapture) noteStatus(status int, reason string) {
+	c.status = status
+}
+
+func (c *responseCapture) Flush() {
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (c *responseCapture) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := c.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("gohtmock: %T can not be hijacked", c.ResponseWriter)
+	}
+	return h.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (c *responseCapture) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+func (c *responseCapture) statusCode() int {
+	if c.status == 0 {
+		return http.StatusOK
+	}
+	return c.status
+}
@@ -0,0 +1,20 @@
+// Package cbor provides a gohtmock.Codec for CBOR bodies.
+package cbor
+
+import (
+	"github.com/fortnoxab/gohtmock"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// ContentType is the content type of CBOR bodies.
+const ContentType = "application/cbor"
+
+// Codec encodes and decodes CBOR, for use with MockEncoded,
+// MatchBodyEncoded and HandleEncoded.
+var Codec gohtmock.Codec = codec{}
+
+type codec struct{}
+
+func (codec) ContentType() string                { return ContentType }
+func (codec) Marshal(v any) ([]byte, error)      { return cbor.Marshal(v) }
+func (codec) Unmarshal(data []byte, v any) error { return cbor.Unmarshal(data, v) }
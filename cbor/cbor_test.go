@@ -0,0 +1,56 @@
+package cbor
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/fortnoxab/gohtmock"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type reading struct {
+	Sensor string  `cbor:"sensor"`
+	Value  float64 `cbor:"value"`
+}
+
+func TestCodec(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	mock.MockEncoded("/config", Codec, map[string]any{"interval": 30}).
+		SetMethod("POST").
+		MatchBodyEncoded(Codec, reading{Sensor: "t1", Value: 21.5})
+
+	body, _ := cbor.Marshal(map[string]any{"sensor": "t1", "value": 21.5})
+	resp, err := http.Post(mock.URL()+"/config", ContentType, bytes.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, ContentType, resp.Header.Get("Content-Type"))
+	var config struct {
+		Interval int `cbor:"interval"`
+	}
+	assert.NoError(t, cbor.Unmarshal(b, &config))
+	assert.Equal(t, 30, config.Interval)
+}
+
+func TestHandleEncoded(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	gohtmock.HandleEncoded(mock, Codec, "POST", "/readings", func(in reading) (reading, int, error) {
+		in.Value *= 2
+		return in, http.StatusCreated, nil
+	})
+
+	body, _ := cbor.Marshal(reading{Sensor: "t1", Value: 2})
+	resp, err := http.Post(mock.URL()+"/readings", ContentType, bytes.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var out reading
+	assert.NoError(t, cbor.Unmarshal(b, &out))
+	assert.Equal(t, reading{Sensor: "t1", Value: 4}, out)
+}
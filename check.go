@@ -0,0 +1,149 @@
+package gohtmock
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Checker is a testing.TB that collects failures instead of failing a test,
+// so that the assertions and For can be used outside tests, e.g. in local
+// development stubs. Only the reporting methods of testing.TB are
+// implemented, the others panic.
+type Checker struct {
+	testing.TB
+	name     string
+	failures []string
+	cleanups []func()
+	// report, set by AsTB, receives every failure as it is recorded
+	report Reporter
+	sync.Mutex
+}
+
+// NewChecker returns a Checker named name, the name For reports as owner.
+func NewChecker(name string) *Checker {
+	return &Checker{name: name}
+}
+
+func (c *Checker) Name() string {
+	return c.name
+}
+
+func (c *Checker) Helper() {}
+
+func (c *Checker) Log(args ...any) {}
+
+func (c *Checker) Logf(format string, args ...any) {}
+
+func (c *Checker) Error(args ...any) {
+	c.fail(fmt.Sprint(args...))
+}
+
+func (c *Checker) Errorf(format string, args ...any) {
+	c.fail(fmt.Sprintf(format, args...))
+}
+
+// Fatal records a failure like Error. Unlike testing.T it does not stop the
+// calling goroutine.
+func (c *Checker) Fatal(args ...any) {
+	c.fail(fmt.Sprint(args...))
+}
+
+// Fatalf records a failure like Errorf, see Fatal.
+func (c *Checker) Fatalf(format string, args ...any) {
+	c.fail(fmt.Sprintf(format, args...))
+}
+
+func (c *Checker) Fail() {
+	c.fail("failed")
+}
+
+func (c *Checker) FailNow() {
+	c.fail("failed")
+}
+
+func (c *Checker) Failed() bool {
+	if f, ok := c.report.(interface{ Failed() bool }); ok {
+		return f.Failed()
+	}
+	c.Lock()
+	defer c.Unlock()
+	return len(c.failures) > 0
+}
+
+// Cleanup registers f to be called by Close, or by the Reporter of AsTB if
+// it has a Cleanup method.
+func (c *Checker) Cleanup(f func()) {
+	if cl, ok := c.report.(interface{ Cleanup(func()) }); ok {
+		cl.Cleanup(f)
+		return
+	}
+	c.Lock()
+	c.cleanups = append(c.cleanups, f)
+	c.Unlock()
+}
+
+// Close calls the functions registered with Cleanup in reverse order.
+func (c *Checker) Close() {
+	c.Lock()
+	cleanups := c.cleanups
+	c.cleanups = nil
+	c.Unlock()
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		cleanups[i]()
+	}
+}
+
+func (c *Checker) fail(msg string) {
+	c.Lock()
+	c.failures = append(c.failures, msg)
+	c.Unlock()
+	if c.report != nil {
+		c.report.Errorf("%s", msg)
+	}
+}
+
+// Err returns the failures recorded so far as one error, nil if none, and
+// forgets them.
+func (c *Checker) Err() error {
+	c.Lock()
+	defer c.Unlock()
+	if len(c.failures) == 0 {
+		return nil
+	}
+	err := errors.New(strings.Join(c.failures, "\n"))
+	c.failures = nil
+	return err
+}
+
+// Check runs assertion, such as a method value of an Assert method, and
+// returns its failures as an error instead of failing a test.
+func Check(assertion func(testing.TB)) error {
+	c := NewChecker("Check")
+	assertion(c)
+	return c.Err()
+}
+
+// CheckAllCalled is AssertMocksCalled returning an error.
+func (m *Mock) CheckAllCalled() error {
+	return Check(m.AssertMocksCalled)
+}
+
+// CheckNoMissingMocks is AssertNoMissingMocks returning an error.
+func (m *Mock) CheckNoMissingMocks() error {
+	return Check(m.AssertNoMissingMocks)
+}
+
+// CheckCallCount is AssertCallCount returning an error.
+func (m *Mock) CheckCallCount(method, path string, expected int) error {
+	return Check(func(tb testing.TB) {
+		m.AssertCallCount(tb, method, path, expected)
+	})
+}
+
+// CheckNotForbidden is AssertNotForbidden returning an error.
+func (m *Mock) CheckNotForbidden() error {
+	return Check(m.AssertNotForbidden)
+}
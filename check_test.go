@@ -0,0 +1,49 @@
+package gohtmock
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/called", "{}")
+	mock.Mock("/uncalled", "{}")
+
+	resp, err := http.Get(mock.URL() + "/called")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	resp, err = http.Get(mock.URL() + "/missing")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.EqualError(t, mock.CheckAllCalled(), "GET /uncalled mocked but never called.")
+	if err := mock.CheckNoMissingMocks(); assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "url: GET/missing is called but not mocked. It was called 1 times")
+	}
+	assert.NoError(t, mock.CheckCallCount("GET", "/called", 1))
+	assert.Error(t, mock.CheckCallCount("GET", "/called", 2))
+	assert.NoError(t, mock.CheckNotForbidden())
+	assert.EqualError(t, Check(func(tb testing.TB) { mock.AssertRedirectFollowed(tb, "/a", "/b") }),
+		"no redirect from /a to /b, no redirects were sent")
+}
+
+func TestCheckerAsOwner(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	c := NewChecker("dev-stub")
+	mock.For(c).Mock("/once", "{}", func(*http.Request) int { return 0 })
+
+	for i := 0; i < 2; i++ {
+		resp, err := http.Get(mock.URL() + "/once")
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.True(t, c.Failed())
+	assert.EqualError(t, c.Err(), "GET /once called 2 times but only 1 callbacks given (mocked by dev-stub)")
+	assert.NoError(t, c.Err())
+	c.Close()
+}
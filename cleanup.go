@@ -0,0 +1,25 @@
+package gohtmock
+
+import "testing"
+
+// WithCleanupAssertions makes a Mock created by NewT run AssertNoMissingMocks
+// and AssertMocksCalled when its test finishes. New ignores it.
+func WithCleanupAssertions() Option {
+	return func(m *Mock) {
+		m.cleanupAssertions = true
+	}
+}
+
+// NewT is New with the Mock closed when tb finishes, and asserted on if
+// created WithCleanupAssertions.
+func NewT(tb testing.TB, opts ...Option) *Mock {
+	m := New(opts...)
+	tb.Cleanup(func() {
+		m.Close()
+		if m.cleanupAssertions {
+			m.AssertNoMissingMocks(tb)
+			m.AssertMocksCalled(tb)
+		}
+	})
+	return m
+}
@@ -0,0 +1,32 @@
+package gohtmock
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewT(t *testing.T) {
+	c := NewChecker("TestNewT")
+	mock := NewT(c, WithCleanupAssertions())
+	mock.Mock("/called", "{}")
+	mock.Mock("/uncalled", "{}")
+	for _, path := range []string{"/called", "/missing"} {
+		resp, err := http.Get(mock.URL() + path)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.NoError(t, c.Err())
+
+	c.Close()
+	_, err := http.Get(mock.URL() + "/called")
+	assert.Error(t, err)
+	if err := c.Err(); assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "url: GET/missing is called but not mocked")
+		assert.Contains(t, err.Error(), "GET /uncalled mocked but never called.")
+	}
+
+	mock = NewT(t)
+	mock.Mock("/unasserted", "{}")
+}
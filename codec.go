@@ -0,0 +1,118 @@
+package gohtmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+)
+
+// Codec encodes and decodes bodies of one content type. JSON is built in,
+// the msgpack, cbor and proto packages provide compact binary formats.
+type Codec interface {
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// EqualCodec is a Codec comparing bodies itself, for formats such as
+// protobuf that only decode into the type of the expected value.
+// MatchBodyEncoded uses it instead of comparing generic decoded values.
+type EqualCodec interface {
+	Codec
+	// Equal reports if body decodes to a value equal to expected.
+	Equal(expected any, body []byte) bool
+	// Describe renders expected and body for a Diff.
+	Describe(expected any, body []byte) (string, string)
+}
+
+// JSON is the Codec of application/json.
+var JSON Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string                { return "application/json" }
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// MockEncoded answers with v encoded by codec. It panics if v can not be
+// encoded.
+func (m *Mock) MockEncoded(path string, codec Codec, v any) *mockResponse {
+	body, err := codec.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("gohtmock: MockEncoded: %s", err))
+	}
+	var mr *mockResponse
+	mr = m.MockFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		mr.Lock()
+		status := mr.status
+		mr.Unlock()
+		if err := mr.writeBody(w, status, body); err != nil {
+			log.Print("gohtmock: writing response for ", path, ": ", err)
+		}
+	}).SetHeader("Content-Type", codec.ContentType())
+	return mr
+}
+
+// MatchBodyEncoded makes mr only answer requests whose body, decoded by
+// codec, equals expected. Both sides are compared in their generic decoded
+// form, so expected may be a struct, a map or any other value codec encodes.
+// It panics if expected can not be encoded.
+func (mr *mockResponse) MatchBodyEncoded(codec Codec, expected any) *mockResponse {
+	if ec, ok := codec.(EqualCodec); ok {
+		if _, err := codec.Marshal(expected); err != nil {
+			panic(fmt.Sprintf("gohtmock: MatchBodyEncoded: %s", err))
+		}
+		return mr.matchBodyDescribed(func(body []byte) bool {
+			return ec.Equal(expected, body)
+		}, func(body []byte) (string, string) {
+			return ec.Describe(expected, body)
+		})
+	}
+	want, err := normalize(codec, expected)
+	if err != nil {
+		panic(fmt.Sprintf("gohtmock: MatchBodyEncoded: %s", err))
+	}
+	return mr.matchBodyDescribed(func(body []byte) bool {
+		var got any
+		if err := codec.Unmarshal(body, &got); err != nil {
+			return false
+		}
+		return reflect.DeepEqual(want, got)
+	}, func(body []byte) (string, string) {
+		var got any
+		if err := codec.Unmarshal(body, &got); err != nil {
+			return describeValue(want), fmt.Sprintf("%q", body)
+		}
+		return describeValue(want), describeValue(got)
+	})
+}
+
+// describeValue renders a decoded value for a Diff, as indented JSON if
+// possible.
+func describeValue(v any) string {
+	if b, err := json.MarshalIndent(v, "", "  "); err == nil {
+		return string(b)
+	}
+	return fmt.Sprintf("%#v", v)
+}
+
+// normalize returns v as codec decodes it into an any.
+func normalize(codec Codec, v any) (any, error) {
+	b, err := codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	err = codec.Unmarshal(b, &out)
+	return out, err
+}
+
+// Decode unmarshals the body into v with codec.
+func (rr *RecordedRequest) Decode(codec Codec, v any) error {
+	if err := codec.Unmarshal(rr.Body, v); err != nil {
+		return fmt.Errorf("decoding %s body of %s %s: %w", codec.ContentType(), rr.Method, rr.URL.Path, err)
+	}
+	return nil
+}
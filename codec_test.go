@@ -0,0 +1,35 @@
+package gohtmock
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockEncoded(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mr := mock.MockEncoded("/user", JSON, map[string]string{"name": "john"}).
+		SetMethod("POST").
+		MatchBodyEncoded(JSON, struct {
+			ID int `json:"id"`
+		}{1})
+
+	resp, err := http.Post(mock.URL()+"/user", "application/json", bytes.NewBufferString(`{ "id": 1 }`))
+	assert.NoError(t, err)
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, `{"name":"john"}`, string(b))
+
+	resp, err = http.Post(mock.URL()+"/user", "application/json", bytes.NewBufferString(`{"id":2}`))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	var in struct{ ID int }
+	assert.NoError(t, mr.Requests()[0].Decode(JSON, &in))
+	assert.Equal(t, 1, in.ID)
+}
@@ -0,0 +1,51 @@
+package gohtmock
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+)
+
+// MockCSV answers with a text/csv body of headers followed by rows, quoted
+// as needed. Headers may be nil to send rows only. With Gzip the body is
+// compressed for clients that accept it. It panics if the rows can not be
+// written.
+func (m *Mock) MockCSV(path string, headers []string, rows [][]string) *mockResponse {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if headers != nil {
+		_ = cw.Write(headers)
+	}
+	_ = cw.WriteAll(rows)
+	if err := cw.Error(); err != nil {
+		panic(fmt.Sprintf("gohtmock: MockCSV: %s", err))
+	}
+	body := buf.Bytes()
+
+	var mr *mockResponse
+	mr = m.MockFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		mr.Lock()
+		status := mr.status
+		mr.Unlock()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		if mr.gzipEnabled() {
+			w.Header().Add("Vary", "Accept-Encoding")
+		}
+		if !mr.gzipEnabled() || !acceptsGzip(r) {
+			w.WriteHeader(status)
+			_, _ = w.Write(body)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(status)
+		zw := gzip.NewWriter(w)
+		_, _ = zw.Write(body)
+		_ = zw.Close()
+	})
+	return mr
+}
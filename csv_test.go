@@ -0,0 +1,40 @@
+package gohtmock
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockCSV(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.MockCSV("/export", []string{"id", "name"}, [][]string{{"1", "Smith, John"}, {"2", `say "hi"`}})
+
+	resp, err := http.Get(mock.URL() + "/export")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "id,name\n1,\"Smith, John\"\n2,\"say \"\"hi\"\"\"\n", string(b))
+}
+
+func TestMockCSVGzip(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.MockCSV("/export", nil, [][]string{{"a", "b"}}).Gzip()
+
+	req, _ := http.NewRequest("GET", mock.URL()+"/export", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	zr, err := gzip.NewReader(resp.Body)
+	assert.NoError(t, err)
+	b, _ := ioutil.ReadAll(zr)
+	assert.Equal(t, "a,b\n", string(b))
+}
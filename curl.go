@@ -0,0 +1,69 @@
+package gohtmock
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Curl returns a curl command reproducing the request against the host it
+// was sent to, over https if it was sent over TLS.
+func (rr *RecordedRequest) Curl() string {
+	if rr.TLS {
+		return rr.CurlTo("https://" + rr.Host)
+	}
+	return rr.CurlTo("http://" + rr.Host)
+}
+
+// CurlTo returns a curl command reproducing the request against baseURL,
+// e.g. a dev environment or a standalone mock server. Requests sent over
+// TLS get -k, since the certificate of a mock is self-signed.
+func (rr *RecordedRequest) CurlTo(baseURL string) string {
+	parts := []string{"curl"}
+	if rr.TLS {
+		parts = append(parts, "-k")
+	}
+	if rr.Method != http.MethodGet {
+		parts = append(parts, "-X", rr.Method)
+	}
+	parts = append(parts, shellQuote(strings.TrimSuffix(baseURL, "/")+rr.URL.RequestURI()))
+
+	keys := make([]string, 0, len(rr.Header))
+	for k := range rr.Header {
+		if k == "Content-Length" || k == "Accept-Encoding" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range rr.Header[k] {
+			parts = append(parts, "-H", shellQuote(k+": "+v))
+		}
+	}
+	if len(rr.Body) > 0 {
+		parts = append(parts, "--data-binary", shellQuote(string(rr.Body)))
+	}
+	if rr.Truncated {
+		parts = append(parts, "# body truncated")
+	}
+	return strings.Join(parts, " ")
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// maxReproduced limits the unmocked requests kept per method+path for
+// reproduction in failure messages.
+const maxReproduced = 3
+
+// reproduce formats the curl commands of reqs for failure messages.
+func reproduce(reqs []*RecordedRequest) string {
+	var b strings.Builder
+	for _, rr := range reqs {
+		fmt.Fprintf(&b, "\n\treproduce with: %s", rr.Curl())
+	}
+	return b.String()
+}
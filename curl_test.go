@@ -0,0 +1,58 @@
+package gohtmock
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurl(t *testing.T) {
+	rr := &RecordedRequest{
+		Method: "POST",
+		URL:    mustParseURL("/users?q=a%20b"),
+		Host:   "127.0.0.1:8080",
+		Header: http.Header{
+			"Content-Type":   {"application/json"},
+			"Content-Length": {"16"},
+			"X-Tag":          {"a", "b"},
+		},
+		Body: []byte(`{"name":"o'neil"}`),
+	}
+	assert.Equal(t,
+		`curl -X POST 'http://127.0.0.1:8080/users?q=a%20b' -H 'Content-Type: application/json' -H 'X-Tag: a' -H 'X-Tag: b' --data-binary '{"name":"o'\''neil"}'`,
+		rr.Curl())
+	assert.Equal(t, `curl 'https://dev.example.com/users?q=a%20b'`, (&RecordedRequest{
+		Method: "GET",
+		URL:    mustParseURL("/users?q=a%20b"),
+	}).CurlTo("https://dev.example.com/"))
+}
+
+func TestAssertNoMissingMocksReproduces(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	_, err := http.Post(mock.URL()+"/missing", "text/plain", strings.NewReader("body"))
+	assert.NoError(t, err)
+
+	rt := newRecordingT("TestMissing")
+	mock.AssertNoMissingMocks(rt)
+	if assert.Len(t, rt.Errors(), 1) {
+		assert.Contains(t, rt.Errors()[0], "reproduce with: curl -X POST '"+mock.URL()+"/missing'")
+		assert.Contains(t, rt.Errors()[0], "--data-binary 'body'")
+	}
+}
+
+func TestCurlTLS(t *testing.T) {
+	mock := New(WithTLS())
+	defer mock.Close()
+	mr := mock.Mock("/secure", "ok")
+
+	resp, err := mock.Client().Get(mock.URL() + "/secure")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	reqs := mr.Requests()
+	if assert.Len(t, reqs, 1) {
+		assert.True(t, strings.HasPrefix(reqs[0].Curl(), "curl -k 'https://127.0.0.1:"), reqs[0].Curl())
+	}
+}
@@ -0,0 +1,84 @@
+package gohtmock
+
+import "net/http"
+
+// dedupedResponse is the response generated for the first request with an
+// idempotency key. done is closed once it is complete.
+type dedupedResponse struct {
+	done   chan struct{}
+	status int
+	header http.Header
+	body   []byte
+}
+
+// DedupeBy makes mr answer repeated requests carrying the same value of the
+// header, such as Idempotency-Key, with the response generated for the first
+// one. Repeats are counted by Retries rather than as new calls. Requests
+// without the header are answered as usual.
+func (mr *mockResponse) DedupeBy(header string) *mockResponse {
+	mr.Lock()
+	mr.dedupeHeader = http.CanonicalHeaderKey(header)
+	mr.deduped = make(map[string]*dedupedResponse)
+	mr.Unlock()
+	return mr
+}
+
+// Retries returns the number of repeated requests answered by DedupeBy.
+func (mr *mockResponse) Retries() int {
+	mr.Lock()
+	defer mr.Unlock()
+	return mr.retries
+}
+
+// dedupeKey returns the idempotency key of r, "" if mr does not dedupe it.
+func (mr *mockResponse) dedupeKey(r *http.Request) string {
+	mr.Lock()
+	defer mr.Unlock()
+	if mr.dedupeHeader == "" {
+		return ""
+	}
+	return r.Header.Get(mr.dedupeHeader)
+}
+
+// retry returns the response of an earlier request with key, counting a
+// retry, or nil if there was none.
+func (mr *mockResponse) retry(key string) *dedupedResponse {
+	mr.Lock()
+	defer mr.Unlock()
+	d := mr.deduped[key]
+	if d != nil {
+		mr.retries++
+	}
+	return d
+}
+
+// startDedupe registers the pending response for the first request with key.
+func (mr *mockResponse) startDedupe(key string) *dedupedResponse {
+	d := &dedupedResponse{done: make(chan struct{})}
+	mr.Lock()
+	mr.deduped[key] = d
+	mr.Unlock()
+	return d
+}
+
+// finish stores the captured response and releases waiting retries.
+func (d *dedupedResponse) finish(c *responseCapture) {
+	d.status = c.statusCode()
+	d.header = c.Header().Clone()
+	d.body = c.body
+	close(d.done)
+}
+
+// replay writes the stored response once it is complete.
+func (d *dedupedResponse) replay(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-d.done:
+	case <-r.Context().Done():
+		return
+	}
+	for k, v := range d.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(d.status)
+	_, _ = w.Write(d.body)
+}
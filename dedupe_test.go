@@ -0,0 +1,50 @@
+package gohtmock
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupeBy(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	var n int
+	mr := mock.MockFunc("/payments", func(w http.ResponseWriter, r *http.Request) {
+		n++
+		w.Header().Set("Location", fmt.Sprintf("/payments/%d", n))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":%d}`, n)
+	}).SetMethod("POST").DedupeBy("idempotency-key")
+
+	pay := func(key string) (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodPost, mock.URL()+"/payments", strings.NewReader("{}"))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	resp, body := pay("k1")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, `{"id":1}`, body)
+	resp, body = pay("k1")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/payments/1", resp.Header.Get("Location"))
+	assert.Equal(t, `{"id":1}`, body)
+	_, body = pay("k2")
+	assert.Equal(t, `{"id":2}`, body)
+	_, body = pay("")
+	assert.Equal(t, `{"id":3}`, body)
+
+	assert.Equal(t, 1, mr.Retries())
+	mock.AssertCallCount(t, "POST", "/payments", 3)
+}
@@ -0,0 +1,73 @@
+package gohtmock
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+type defaultResponder struct {
+	mediaType string
+	responder http.Handler
+}
+
+// DefaultFor registers responder as the fallback for requests no mock
+// answers whose Content-Type or Accept header names contentType. Requests
+// answered by a fallback are not reported by AssertNoMissingMocks. Fallbacks
+// are tried in the order they were registered.
+func (m *Mock) DefaultFor(contentType string, responder http.Handler) {
+	m.Lock()
+	m.defaults = append(m.defaults, defaultResponder{mediaType: mediaType(contentType), responder: responder})
+	m.Unlock()
+}
+
+// SetNotFoundHandler makes h answer the requests no mock or DefaultFor
+// fallback answers, instead of a 404. Unlike DefaultFor the requests are
+// still reported by AssertNoMissingMocks. A nil h restores the 404.
+func (m *Mock) SetNotFoundHandler(h http.Handler) {
+	m.Lock()
+	m.notFound = h
+	m.Unlock()
+}
+
+// SetUnmatchedStatus makes requests no mock answers get status and body,
+// e.g. 501 for clients that take a 404 as a valid answer, see
+// SetNotFoundHandler.
+func (m *Mock) SetUnmatchedStatus(status int, body string) {
+	m.SetNotFoundHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+}
+
+// defaultFor returns the fallback for r, nil if there is none. m must be locked.
+func (m *Mock) defaultFor(r *http.Request) http.Handler {
+	if len(m.defaults) == 0 {
+		return nil
+	}
+	types := []string{mediaType(r.Header.Get("Content-Type"))}
+	for _, accept := range r.Header.Values("Accept") {
+		for _, t := range strings.Split(accept, ",") {
+			types = append(types, mediaType(t))
+		}
+	}
+	for _, d := range m.defaults {
+		for _, t := range types {
+			if t != "" && t == d.mediaType {
+				return d.responder
+			}
+		}
+	}
+	return nil
+}
+
+// mediaType returns the lower case media type of a Content-Type or Accept
+// value without parameters.
+func mediaType(v string) string {
+	t, _, err := mime.ParseMediaType(v)
+	if err != nil {
+		t = strings.TrimSpace(strings.Split(v, ";")[0])
+	}
+	return strings.ToLower(t)
+}
@@ -0,0 +1,75 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultFor(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/users", `["u1"]`)
+	mock.DefaultFor("application/xml", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte("<ok/>"))
+	}))
+
+	do := func(path, header, value string) (int, string) {
+		req, _ := http.NewRequest(http.MethodPost, mock.URL()+path, strings.NewReader("<x/>"))
+		req.Header.Set(header, value)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := do("/orders", "Content-Type", "application/XML; charset=utf-8")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "<ok/>", body)
+	_, body = do("/invoices", "Accept", "application/json;q=0.5, application/xml")
+	assert.Equal(t, "<ok/>", body)
+	mock.AssertNoMissingMocks(t)
+
+	status, _ = do("/orders", "Content-Type", "application/json")
+	assert.Equal(t, http.StatusNotFound, status)
+	newT := &testing.T{}
+	mock.AssertNoMissingMocks(newT)
+	assert.True(t, newT.Failed())
+}
+
+func TestUnmatchedResponse(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.SetUnmatchedStatus(http.StatusNotImplemented, "not mocked")
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(mock.URL() + path)
+		if !assert.NoError(t, err) {
+			return 0, ""
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return resp.StatusCode, string(body)
+	}
+	status, body := get("/missing")
+	assert.Equal(t, http.StatusNotImplemented, status)
+	assert.Equal(t, "not mocked", body)
+	assert.Error(t, mock.CheckNoMissingMocks())
+
+	mock.SetNotFoundHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(599)
+		w.Write([]byte(r.URL.Path))
+	}))
+	status, body = get("/other")
+	assert.Equal(t, 599, status)
+	assert.Equal(t, "/other", body)
+
+	mock.SetNotFoundHandler(nil)
+	status, _ = get("/other")
+	assert.Equal(t, http.StatusNotFound, status)
+}
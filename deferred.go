@@ -0,0 +1,108 @@
+package gohtmock
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// deferredFailures collects problems for DeferFailures.
+type deferredFailures struct {
+	// kinds are in order of first occurrence, as are the problems of a kind
+	kinds    []string
+	problems map[string][]string
+	counts   map[string]int
+	sync.Mutex
+}
+
+// DeferFailures collects the problems found while serving requests, such
+// as unmatched requests, requests to mocks depleted by Times, panicking
+// filters and the failures mocks report to their owners, and reports them
+// to tb as one summary when tb finishes instead of one failure each as they
+// happen.
+func (m *Mock) DeferFailures(tb testing.TB) {
+	d := &deferredFailures{problems: make(map[string][]string), counts: make(map[string]int)}
+	m.deferred.Store(d)
+	tb.Cleanup(func() {
+		if s := d.summary(); s != "" {
+			tb.Errorf("%s", s)
+		}
+	})
+}
+
+// deferredFailures returns the collector of DeferFailures, nil if not used.
+func (m *Mock) deferredFailures() *deferredFailures {
+	d, _ := m.deferred.Load().(*deferredFailures)
+	return d
+}
+
+func (d *deferredFailures) add(kind, problem string) {
+	d.Lock()
+	defer d.Unlock()
+	if _, ok := d.problems[kind]; !ok {
+		d.kinds = append(d.kinds, kind)
+	}
+	if d.counts[kind+problem] == 0 {
+		d.problems[kind] = append(d.problems[kind], problem)
+	}
+	d.counts[kind+problem]++
+}
+
+func (d *deferredFailures) summary() string {
+	d.Lock()
+	defer d.Unlock()
+	total := 0
+	var lines []string
+	for _, kind := range d.kinds {
+		lines = append(lines, kind+":")
+		for _, p := range d.problems[kind] {
+			n := d.counts[kind+p]
+			total += n
+			line := "  " + strings.ReplaceAll(p, "\n", "\n    ")
+			if n > 1 {
+				line += fmt.Sprintf(" (%d times)", n)
+			}
+			lines = append(lines, line)
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("gohtmock: %d problems while serving requests:\n%s", total, strings.Join(lines, "\n"))
+}
+
+// noteUnmatched reports a request no mock answered. depleted is a mock that
+// would have answered it but for Times, nil if none.
+func (m *Mock) noteUnmatched(r *http.Request, depleted *mockResponse) {
+	d := m.deferredFailures()
+	if d == nil {
+		return
+	}
+	if depleted != nil {
+		depleted.Lock()
+		times := depleted.times
+		depleted.Unlock()
+		d.add("requests to depleted mocks", fmt.Sprintf("%s %s to %s, limited to %d calls", r.Method, r.URL.RequestURI(), depleted.registeredAt, times))
+		return
+	}
+	d.add("unmatched requests", r.Method+" "+r.URL.RequestURI())
+}
+
+// runFilter calls the filter of mr, treating a panic as a rejection.
+func (mr *mockResponse) runFilter(filter func(*http.Request) bool, r *http.Request) (ok bool) {
+	defer func() {
+		if p := recover(); p != nil {
+			ok = false
+			msg := fmt.Sprintf("%s %s: filter of %s panicked: %v", r.Method, r.URL.RequestURI(), mr.registeredAt, p)
+			if d := mr.httpMock.deferredFailures(); d != nil {
+				d.add("panicking filters", msg)
+			} else if !mr.fail("%s%s", msg, mr.ownedBy()) {
+				log.Print("gohtmock: ", msg)
+			}
+		}
+	}()
+	return filter(r)
+}
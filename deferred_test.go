@@ -0,0 +1,57 @@
+package gohtmock
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeferFailures(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	rt := newRecordingT("TestDeferFailures")
+	mock.DeferFailures(rt)
+	once := mock.Mock("/once", "{}").Once()
+	mock.Mock("/filtered", "{}").Filter(func(r *http.Request) bool {
+		panic("boom")
+	})
+	mock.For(rt).Mock("/callbacks", "{}", func(*http.Request) int { return 0 })
+
+	for _, path := range []string{"/missing", "/missing", "/once", "/once", "/filtered", "/callbacks", "/callbacks"} {
+		resp, err := http.Get(mock.URL() + path)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Empty(t, rt.Errors())
+
+	rt.runCleanups()
+	assert.Equal(t, []string{`gohtmock: 6 problems while serving requests:
+unmatched requests:
+  GET /missing (2 times)
+  GET /filtered
+requests to depleted mocks:
+  GET /once to ` + once.registeredAt + `, limited to 1 calls
+panicking filters:
+  GET /filtered: filter of ` + mock.mockResponses[1].registeredAt + ` panicked: boom
+failures:
+  GET /callbacks called 2 times but only 1 callbacks given (mocked by TestDeferFailures)`}, rt.Errors())
+}
+
+func TestFilterPanicIsReported(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	rt := newRecordingT("TestFilterPanicIsReported")
+	mock.For(rt).Mock("/filtered", "{}").Filter(func(r *http.Request) bool {
+		panic("boom")
+	})
+
+	resp, err := http.Get(mock.URL() + "/filtered")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	if assert.Len(t, rt.Errors(), 1) {
+		assert.Contains(t, rt.Errors()[0], "GET /filtered: filter of ")
+		assert.Contains(t, rt.Errors()[0], "panicked: boom (mocked by TestFilterPanicIsReported)")
+	}
+}
@@ -0,0 +1,164 @@
+package gohtmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Diff is an expected versus actual mismatch, such as a request body that a
+// matcher rejects.
+type Diff struct {
+	// Subject is what differs, for example "body" or "raw headers"
+	Subject  string `json:"subject"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// String returns d as a side by side diff without colors.
+func (d Diff) String() string {
+	return d.Subject + " differs:\n" + sideBySide(d.Expected, d.Actual, false)
+}
+
+// WithColorDiffs makes the diffs in failure messages and Explain use ANSI
+// colors, the expected lines red and the actual ones green.
+func WithColorDiffs() Option {
+	return func(m *Mock) {
+		m.colorDiffs = true
+	}
+}
+
+// WithJSONDiffs makes the diffs in failure messages and Explain a JSON
+// encoded Diff on one line instead of a side by side text, for tools that
+// parse test output.
+func WithJSONDiffs() Option {
+	return func(m *Mock) {
+		m.jsonDiffs = true
+	}
+}
+
+// formatDiff formats d as configured by WithColorDiffs and WithJSONDiffs.
+func (m *Mock) formatDiff(d Diff) string {
+	if m.jsonDiffs {
+		b, _ := json.Marshal(d)
+		return string(b)
+	}
+	return d.Subject + " differs:\n" + sideBySide(d.Expected, d.Actual, m.colorDiffs)
+}
+
+// formatDiffs formats diffs as the continuation of a failure message, each
+// on lines of its own. It is empty if there are no diffs.
+func (m *Mock) formatDiffs(diffs []Diff) string {
+	var b strings.Builder
+	for _, d := range diffs {
+		b.WriteString("\n" + m.formatDiff(d))
+	}
+	return b.String()
+}
+
+const (
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorReset = "\x1b[0m"
+)
+
+// sideBySide lays out the lines of expected and actual next to each other,
+// aligning the lines they share. The column between them is blank for
+// equal lines, | for changed lines, < for lines only expected and > for
+// lines only actual.
+func sideBySide(expected, actual string, color bool) string {
+	left := strings.Split(expected, "\n")
+	right := strings.Split(actual, "\n")
+	width := len("expected")
+	for _, l := range left {
+		if len(l) > width {
+			width = len(l)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-*s   %s", width, "expected", "actual")
+	row := func(l, mark, r string) {
+		pad := strings.Repeat(" ", width-len(l))
+		if color && (mark == "|" || mark == "<") {
+			l = colorRed + l + colorReset
+		}
+		if color && (mark == "|" || mark == ">") {
+			r = colorGreen + r + colorReset
+		}
+		b.WriteString(strings.TrimRight("\n"+l+pad+" "+mark+" "+r, " "))
+	}
+	for _, op := range diffLines(left, right) {
+		switch {
+		case op.left >= 0 && op.right >= 0 && left[op.left] == right[op.right]:
+			row(left[op.left], " ", right[op.right])
+		case op.left >= 0 && op.right >= 0:
+			row(left[op.left], "|", right[op.right])
+		case op.left >= 0:
+			row(left[op.left], "<", "")
+		default:
+			row("", ">", right[op.right])
+		}
+	}
+	return b.String()
+}
+
+// diffOp pairs a line of the left side with one of the right side, -1 for
+// none.
+type diffOp struct {
+	left, right int
+}
+
+// diffLines aligns a and b on their longest common subsequence. Runs of
+// removed and added lines between common lines are paired up as changes.
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	var removed, added []int
+	flush := func() {
+		for len(removed) > 0 || len(added) > 0 {
+			op := diffOp{left: -1, right: -1}
+			if len(removed) > 0 {
+				op.left, removed = removed[0], removed[1:]
+			}
+			if len(added) > 0 {
+				op.right, added = added[0], added[1:]
+			}
+			ops = append(ops, op)
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			flush()
+			ops = append(ops, diffOp{left: i, right: j})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			removed = append(removed, i)
+			i++
+		default:
+			added = append(added, j)
+			j++
+		}
+	}
+	flush()
+	return ops
+}
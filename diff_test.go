@@ -0,0 +1,49 @@
+package gohtmock
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSideBySide(t *testing.T) {
+	assert.Equal(t, `expected    actual
+{           {
+  "a": 1, |   "a": 2,
+  "b": 2  |   "c": 3
+}           }`, sideBySide("{\n  \"a\": 1,\n  \"b\": 2\n}", "{\n  \"a\": 2,\n  \"c\": 3\n}", false))
+	assert.Equal(t, `expected   actual
+a          a
+b        <
+c          c
+         > d`, sideBySide("a\nb\nc", "a\nc\nd", false))
+	assert.Equal(t, "expected   actual\n\x1b[31mx\x1b[0m        | \x1b[32my\x1b[0m", sideBySide("x", "y", true))
+}
+
+func TestExplainBodyDiff(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mr := mock.Mock("/orders", "{}").SetMethod("POST").MatchBodyEncoded(JSON, map[string]any{"id": 1, "qty": 2})
+
+	explanation := mock.Explain(httptest.NewRequest("POST", "/orders", strings.NewReader(`{"id":1,"qty":3}`)))
+	assert.Equal(t, `POST /orders matches no mock, rejected by:
+  POST /orders (`+mr.registeredAt+`): body does not match
+    body differs:
+    expected     actual
+    {            {
+      "id": 1,     "id": 1,
+      "qty": 2 |   "qty": 3
+    }            }`, explanation)
+}
+
+func TestJSONDiffs(t *testing.T) {
+	mock := New(WithJSONDiffs())
+	defer mock.Close()
+	mock.Mock("/orders", "{}").SetMethod("POST").MatchBodyEncoded(JSON, []int{1})
+
+	explanation := mock.Explain(httptest.NewRequest("POST", "/orders", strings.NewReader(`not json`)))
+	assert.True(t, strings.HasSuffix(explanation, `body does not match
+    {"subject":"body","expected":"[\n  1\n]","actual":"\"not json\""}`), explanation)
+}
@@ -0,0 +1,132 @@
+package gohtmock
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RequireDigestAuth makes mr answer only requests authenticated with HTTP
+// Digest authentication, RFC 7616 with MD5 and qop=auth, as one of users,
+// which maps user names to passwords. Other requests get a 401 challenge
+// with a fresh nonce. A nonce count that does not increase is rejected as a
+// replay and an unknown nonce with otherwise valid credentials is answered
+// with a stale challenge, so clients that handle those can be tested.
+// Challenged requests do not count as calls of mr.
+func (mr *mockResponse) RequireDigestAuth(realm string, users map[string]string) *mockResponse {
+	d := &digestAuth{realm: realm, users: users, nonces: make(map[string]uint64)}
+	return mr.Guard(d.check)
+}
+
+type digestAuth struct {
+	realm string
+	users map[string]string
+	// nonces maps the issued nonces to the last nonce count used
+	nonces map[string]uint64
+	sync.Mutex
+}
+
+func (d *digestAuth) check(w http.ResponseWriter, r *http.Request) bool {
+	params, ok := parseDigest(r.Header.Get("Authorization"))
+	if !ok {
+		d.challenge(w, false)
+		return false
+	}
+	password, ok := d.users[params["username"]]
+	if !ok || params["realm"] != d.realm || params["uri"] != r.URL.RequestURI() || params["qop"] != "auth" {
+		d.challenge(w, false)
+		return false
+	}
+	if alg := params["algorithm"]; alg != "" && !strings.EqualFold(alg, "MD5") {
+		d.challenge(w, false)
+		return false
+	}
+	nc, err := strconv.ParseUint(params["nc"], 16, 64)
+	if err != nil {
+		d.challenge(w, false)
+		return false
+	}
+
+	ha1 := md5Hex(params["username"] + ":" + d.realm + ":" + password)
+	ha2 := md5Hex(r.Method + ":" + params["uri"])
+	want := md5Hex(strings.Join([]string{ha1, params["nonce"], params["nc"], params["cnonce"], "auth", ha2}, ":"))
+	if params["response"] != want {
+		d.challenge(w, false)
+		return false
+	}
+
+	d.Lock()
+	last, known := d.nonces[params["nonce"]]
+	if known && nc > last {
+		d.nonces[params["nonce"]] = nc
+	}
+	d.Unlock()
+	if !known {
+		d.challenge(w, true)
+		return false
+	}
+	if nc <= last {
+		d.challenge(w, false)
+		return false
+	}
+	return true
+}
+
+func (d *digestAuth) challenge(w http.ResponseWriter, stale bool) {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	nonce := hex.EncodeToString(b)
+	d.Lock()
+	d.nonces[nonce] = 0
+	d.Unlock()
+
+	value := fmt.Sprintf(`Digest realm="%s", qop="auth", algorithm=MD5, nonce="%s"`, d.realm, nonce)
+	if stale {
+		value += ", stale=true"
+	}
+	w.Header().Set("WWW-Authenticate", value)
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+// parseDigest parses the parameters of a Digest Authorization header.
+func parseDigest(header string) (map[string]string, bool) {
+	if !strings.HasPrefix(header, "Digest ") {
+		return nil, false
+	}
+	rest := strings.TrimPrefix(header, "Digest ")
+	params := make(map[string]string)
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			return nil, false
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if strings.HasPrefix(value, `"`) {
+			end := strings.IndexByte(value[1:], '"')
+			if end < 0 {
+				return nil, false
+			}
+			params[key] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			end := strings.IndexByte(value, ',')
+			if end < 0 {
+				end = len(value)
+			}
+			params[key] = strings.TrimSpace(value[:end])
+			rest = value[end:]
+		}
+		rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), ","))
+	}
+	return params, true
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,79 @@
+package gohtmock
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireDigestAuth(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/dir/index.html", "secret").RequireDigestAuth("testrealm@host.com", map[string]string{"Mufasa": "Circle Of Life"})
+
+	do := func(auth string) *http.Response {
+		req, _ := http.NewRequest("GET", mock.URL()+"/dir/index.html", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+	authorization := func(nonce, nc, password string) string {
+		ha1 := md5Hex("Mufasa:testrealm@host.com:" + password)
+		ha2 := md5Hex("GET:/dir/index.html")
+		response := md5Hex(ha1 + ":" + nonce + ":" + nc + ":0a4f113b:auth:" + ha2)
+		return fmt.Sprintf(`Digest username="Mufasa", realm="testrealm@host.com", nonce="%s", uri="/dir/index.html", qop=auth, nc=%s, cnonce="0a4f113b", response="%s"`, nonce, nc, response)
+	}
+
+	resp := do("")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	params, ok := parseDigest(resp.Header.Get("WWW-Authenticate"))
+	assert.True(t, ok)
+	assert.Equal(t, "testrealm@host.com", params["realm"])
+	assert.Equal(t, "auth", params["qop"])
+	nonce := params["nonce"]
+
+	assert.Equal(t, http.StatusOK, do(authorization(nonce, "00000001", "Circle Of Life")).StatusCode)
+	assert.Equal(t, http.StatusOK, do(authorization(nonce, "00000002", "Circle Of Life")).StatusCode)
+	// replayed nonce count
+	assert.Equal(t, http.StatusUnauthorized, do(authorization(nonce, "00000002", "Circle Of Life")).StatusCode)
+	// wrong password
+	assert.Equal(t, http.StatusUnauthorized, do(authorization(nonce, "00000003", "Hakuna Matata")).StatusCode)
+
+	resp = do(authorization("unknown", "00000001", "Circle Of Life"))
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	params, _ = parseDigest(resp.Header.Get("WWW-Authenticate"))
+	assert.Equal(t, "true", params["stale"])
+}
+
+func TestDigestChallengeDoesNotCount(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/private", "secret").RequireDigestAuth("realm", map[string]string{"user": "pass"}).Once()
+
+	var nonce string
+	for i := 0; i < 2; i++ {
+		resp, err := http.Get(mock.URL() + "/private")
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		params, _ := parseDigest(resp.Header.Get("WWW-Authenticate"))
+		nonce = params["nonce"]
+	}
+
+	ha1 := md5Hex("user:realm:pass")
+	response := md5Hex(ha1 + ":" + nonce + ":00000001:c:auth:" + md5Hex("GET:/private"))
+	req, _ := http.NewRequest("GET", mock.URL()+"/private", nil)
+	req.Header.Set("Authorization", fmt.Sprintf(`Digest username="user", realm="realm", nonce="%s", uri="/private", qop=auth, nc=00000001, cnonce="c", response="%s"`, nonce, response))
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mock.AssertCallCount(t, "GET", "/private", 1)
+	mock.AssertStatusCount(t, "GET", "/private", http.StatusUnauthorized, 0)
+}
@@ -0,0 +1,25 @@
+package gohtmock
+
+// Disable switches mr off until Enable is called. Requests fall through to
+// other mocks as if mr was not registered, but its calls and recorded
+// requests are kept.
+func (mr *mockResponse) Disable() *mockResponse {
+	mr.Lock()
+	mr.disabled = true
+	mr.Unlock()
+	return mr
+}
+
+// Enable switches mr back on after Disable.
+func (mr *mockResponse) Enable() *mockResponse {
+	mr.Lock()
+	mr.disabled = false
+	mr.Unlock()
+	return mr
+}
+
+func (mr *mockResponse) isDisabled() bool {
+	mr.Lock()
+	defer mr.Unlock()
+	return mr.disabled
+}
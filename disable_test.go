@@ -0,0 +1,35 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisable(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	primary := mock.Mock("/config", `"primary"`)
+	mock.Mock("/config", `"fallback"`)
+
+	get := func() string {
+		resp, err := http.Get(mock.URL() + "/config")
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		return string(b)
+	}
+
+	assert.Equal(t, `"primary"`, get())
+	primary.Disable()
+	assert.Equal(t, `"fallback"`, get())
+	assert.Contains(t, mock.Explain(httptest.NewRequest("GET", "/config", nil)), "): disabled")
+	primary.Enable()
+	assert.Equal(t, `"primary"`, get())
+
+	assert.Len(t, primary.Requests(), 2)
+}
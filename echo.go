@@ -0,0 +1,70 @@
+package gohtmock
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// EchoHeader makes mr copy the header name, such as a correlation ID, from
+// the request to its response. The first echoed header is available to
+// templates as RequestID.
+func (mr *mockResponse) EchoHeader(name string) *mockResponse {
+	mr.Lock()
+	mr.echoHeaders = append(mr.echoHeaders, http.CanonicalHeaderKey(name))
+	mr.Unlock()
+	return mr
+}
+
+// echo copies the echoed headers of r to w. mr must be locked.
+func (mr *mockResponse) echo(w http.ResponseWriter, r *http.Request) {
+	for _, name := range mr.echoHeaders {
+		if v := r.Header.Values(name); len(v) > 0 {
+			w.Header()[name] = append([]string(nil), v...)
+		}
+	}
+}
+
+// AssertAllRequestsHaveHeader asserts that every request recorded by mr
+// carried the header name.
+func (mr *mockResponse) AssertAllRequestsHaveHeader(tb testing.TB, name string) {
+	if mr.httpMock.assertionsDisabled(tb) {
+		return
+	}
+	for i, r := range mr.Requests() {
+		if r.Header.Get(name) == "" {
+			tb.Errorf("%s %s: request %d has no %s header", mr.method, mr.path, i+1, http.CanonicalHeaderKey(name))
+		}
+	}
+}
+
+// EchoedRequest is the response body of MockEcho.
+type EchoedRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  url.Values  `json:"query"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// MockEcho registers a mock answering all methods on path with the request
+// it got as a JSON EchoedRequest, so a test can check what a client sends
+// through the response it receives.
+func (m *Mock) MockEcho(path string) *mockResponse {
+	return m.MockFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(EchoedRequest{
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Query:  r.URL.Query(),
+			Header: r.Header,
+			Body:   string(body),
+		})
+	}).SetMethod("ANY")
+}
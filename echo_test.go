@@ -0,0 +1,72 @@
+package gohtmock
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEchoHeader(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mr := mock.Mock("/orders", `{"trace":"{{ .RequestID }}","method":"{{ .Method }}","body":{{ .Body }}}`).
+		SetMethod("POST").EchoHeader("x-request-id").Template()
+
+	req, _ := http.NewRequest(http.MethodPost, mock.URL()+"/orders", strings.NewReader(`{"n":1}`))
+	req.Header.Set("X-Request-ID", "abc-123")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "abc-123", resp.Header.Get("X-Request-Id"))
+	assert.Equal(t, `{"trace":"abc-123","method":"POST","body":{"n":1}}`, string(body))
+	mr.AssertAllRequestsHaveHeader(t, "X-Request-ID")
+
+	resp, err = http.Post(mock.URL()+"/orders", "application/json", strings.NewReader(`{}`))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "", resp.Header.Get("X-Request-Id"))
+
+	rt := newRecordingT("TestEchoHeader")
+	mr.AssertAllRequestsHaveHeader(rt, "x-request-id")
+	assert.Equal(t, []string{"POST /orders: request 2 has no X-Request-Id header"}, rt.Errors())
+}
+
+func TestTemplateError(t *testing.T) {
+	rt := newRecordingT("TestTemplateError")
+	mock := New()
+	defer mock.Close()
+	mock.For(rt).Mock("/broken", "{{ .Missing").Template()
+
+	resp, err := http.Get(mock.URL() + "/broken")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Len(t, rt.Errors(), 1)
+}
+
+func TestMockEcho(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.MockEcho("/echo")
+
+	req, err := http.NewRequest("PUT", mock.URL()+"/echo?a=1&a=2", strings.NewReader(`{"name":"x"}`))
+	assert.NoError(t, err)
+	req.Header.Set("X-Request-Id", "abc")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var echoed EchoedRequest
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&echoed))
+	assert.Equal(t, "PUT", echoed.Method)
+	assert.Equal(t, "/echo", echoed.Path)
+	assert.Equal(t, []string{"1", "2"}, echoed.Query["a"])
+	assert.Equal(t, "abc", echoed.Header.Get("X-Request-Id"))
+	assert.Equal(t, `{"name":"x"}`, echoed.Body)
+}
@@ -0,0 +1,138 @@
+package gohtmock
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Verbose makes the mock log an Explain of every request no mock answers.
+func (m *Mock) Verbose(enabled bool) {
+	m.Lock()
+	m.verbose = enabled
+	m.Unlock()
+}
+
+// Explain describes how the mocks would treat r: which mock answers it and
+// why every other mock rejects it, in the order the mocks are tried. It does not count as a call. Filters are
+// evaluated as they would be when serving r.
+func (m *Mock) Explain(r *http.Request) string {
+	r = m.applyRequestMiddleware(r)
+	recorded, err := record(r, 0)
+	if err != nil {
+		return fmt.Sprintf("reading body of %s %s: %s", r.Method, r.URL.Path, err)
+	}
+	m.Lock()
+	defer m.Unlock()
+	return m.explain(r, recorded.Body, nil)
+}
+
+// explain is Explain with the body already read. If simulated is not nil it
+// replaces the real call counts of the mocks. m must be locked.
+func (m *Mock) explain(r *http.Request, body []byte, simulated map[*mockResponse]int) string {
+	method := r.Method
+	path := r.URL.Path
+	partition, _ := m.partitionFor(r)
+
+	// mocks are explained in the order they are tried, those of other
+	// partitions last
+	mocks := append([]*mockResponse(nil), m.candidates(partition)...)
+	for _, mr := range m.mockResponses {
+		if mr.partition != "" && mr.partition != partition {
+			mocks = append(mocks, mr)
+		}
+	}
+
+	var matched *mockResponse
+	var lines []string
+	for _, mr := range mocks {
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		mr.Lock()
+		calls := mr.calls
+		mr.Unlock()
+		if simulated != nil {
+			calls = simulated[mr]
+		}
+		reason := mr.rejection(method, path, partition, r, calls)
+		if reason == "" && matched == nil {
+			matched = mr
+			continue
+		}
+		if reason == "" {
+			reason = fmt.Sprintf("shadowed by %s", matched.registeredAt)
+		}
+		reason = strings.ReplaceAll(reason, "\n", "\n    ")
+		lines = append(lines, fmt.Sprintf("  %s %s (%s): %s", mr.method, mr.path, mr.registeredAt, reason))
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var head string
+	if matched == nil {
+		head = fmt.Sprintf("%s %s matches no mock", method, r.URL.RequestURI())
+	} else {
+		head = fmt.Sprintf("%s %s is answered by %s %s (%s)", method, r.URL.RequestURI(), matched.method, matched.path, matched.registeredAt)
+	}
+	if len(lines) == 0 {
+		return head
+	}
+	return head + ", rejected by:\n" + strings.Join(lines, "\n")
+}
+
+// rejection returns why mr does not answer r in partition, or "" if it does.
+func (mr *mockResponse) rejection(method, path, partition string, r *http.Request, calls int) string {
+	if reason := mr.rejectionBeforeFilter(method, path, partition, r, calls); reason != "" {
+		return reason
+	}
+	if !mr.checkFilter(r) {
+		return "filter returned false"
+	}
+	return ""
+}
+
+// rejectionBeforeFilter is rejection without calling the filter of mr.
+func (mr *mockResponse) rejectionBeforeFilter(method, path, partition string, r *http.Request, calls int) string {
+	mr.Lock()
+	pathMatches := mr.path == path
+	if mr.pathPattern != nil {
+		pathMatches = mr.pathPattern.MatchString(path)
+	}
+	methodMatches := mr.method == method || mr.method == "ANY"
+	otherPartition := mr.partition != "" && mr.partition != partition
+	times := mr.times
+	mr.Unlock()
+
+	switch {
+	case otherPartition:
+		return fmt.Sprintf("belongs to partition %s", mr.partition)
+	case !pathMatches:
+		return fmt.Sprintf("path %s does not match", path)
+	case !methodMatches:
+		return fmt.Sprintf("method %s does not match", method)
+	case times > 0 && calls >= times:
+		return fmt.Sprintf("depleted after %d calls", calls)
+	case mr.isDisabled():
+		return "disabled"
+	case !mr.inState():
+		return fmt.Sprintf("scenario %s is in state %s, not %s", mr.scenario.name, mr.scenario.State(), mr.givenState)
+	case !mr.matchesURL(r):
+		return fmt.Sprintf("host %s or query %s does not match", requestHost(r), r.URL.RawQuery)
+	case !mr.matchesRawHeaders(r):
+		return "raw headers do not match" + mr.httpMock.formatDiffs([]Diff{mr.rawHeaderDiff(r)})
+	case !mr.matchesMessage(r):
+		return "RPC message does not match"
+	case !mr.matchesBody(r):
+		return "body does not match" + mr.httpMock.formatDiffs(mr.bodyDiffs(r))
+	case mr.failedMatcher(r) != "":
+		return mr.failedMatcher(r) + " does not match"
+	}
+	return ""
+}
+
+func (m *Mock) logUnmatched(r *http.Request, recorded *RecordedRequest) {
+	if m.verbose {
+		log.Print("gohtmock: ", m.explain(r, recorded.Body, nil), reproduce([]*RecordedRequest{recorded}))
+	}
+}
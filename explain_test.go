@@ -0,0 +1,79 @@
+package gohtmock
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/users", "ok").SetMethod("POST")
+	mock.Mock("/users", "ok").Once()
+	mock.Mock("/users", "ok").Filter(func(r *http.Request) bool { return r.URL.Query().Get("id") == "1" })
+	mock.Mock("/other", "ok")
+
+	_, err := http.Get(mock.URL() + "/users")
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", mock.URL()+"/users?id=2", nil)
+	lines := strings.Split(mock.Explain(req), "\n")
+	if assert.Len(t, lines, 5) {
+		assert.Equal(t, "GET /users?id=2 matches no mock, rejected by:", lines[0])
+		assert.Regexp(t, `^  GET /users \(explain_test.go:\d+\): filter returned false$`, lines[1])
+		assert.Regexp(t, `^  POST /users \(explain_test.go:\d+\): method GET does not match$`, lines[2])
+		assert.Regexp(t, `^  GET /users \(explain_test.go:\d+\): depleted after 1 calls$`, lines[3])
+		assert.Regexp(t, `^  GET /other \(explain_test.go:\d+\): path /users does not match$`, lines[4])
+	}
+
+	req, _ = http.NewRequest("GET", mock.URL()+"/users?id=1", nil)
+	assert.Contains(t, mock.Explain(req), "GET /users?id=1 is answered by GET /users")
+
+	// explaining is not a call
+	mock.AssertCallCount(t, "GET", "/users", 1)
+}
+
+func TestExplainFilteredFirst(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/users", "default")
+	mock.Mock("/users", "admin").MatchHeader("Authorization", "admin")
+
+	req, _ := http.NewRequest("GET", mock.URL()+"/users", nil)
+	req.Header.Set("Authorization", "admin")
+	lines := strings.Split(mock.Explain(req), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Regexp(t, `^GET /users is answered by GET /users \(explain_test.go:47\), rejected by:$`, lines[0])
+		assert.Regexp(t, `^  GET /users \(explain_test.go:46\): shadowed by explain_test.go:47$`, lines[1])
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "admin", string(body))
+	}
+}
+
+func TestVerboseLogsUnmatched(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	mock := New()
+	defer mock.Close()
+	mock.Verbose(true)
+	mock.Mock("/users", "ok").SetMethod("PUT")
+
+	_, err := http.Get(mock.URL() + "/users")
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "gohtmock: GET /users matches no mock, rejected by:")
+	assert.Contains(t, buf.String(), "method GET does not match")
+}
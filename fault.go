@@ -0,0 +1,82 @@
+package gohtmock
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// How the connection of a response is broken, see DropConnection and
+// ResetConnection.
+const (
+	faultNone = iota
+	faultDrop
+	faultReset
+)
+
+// DropConnection makes mr close the connection in the middle of its
+// response: after the headers, declaring the full Content-Length, and half
+// of the body. Responses of MockFunc and empty bodies are dropped before
+// anything is sent. Clients see an unexpected EOF. Faults need a real
+// connection: requests served without one, such as through httpmock or
+// ServeHTTP with a ResponseRecorder, are answered with a 500 and reported as
+// a failure.
+func (mr *mockResponse) DropConnection() *mockResponse {
+	mr.Lock()
+	mr.fault = faultDrop
+	mr.Unlock()
+	return mr
+}
+
+// ResetConnection is DropConnection with the connection reset by a TCP RST
+// instead of closed, so clients see a connection reset by peer.
+func (mr *mockResponse) ResetConnection() *mockResponse {
+	mr.Lock()
+	mr.fault = faultReset
+	mr.Unlock()
+	return mr
+}
+
+// breakConnection hijacks the connection of w and closes it as fault says.
+// Unless body is empty, status, the headers of w and half of body are sent
+// first.
+func breakConnection(w http.ResponseWriter, fault, status int, reason string, body []byte) error {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return fmt.Errorf("gohtmock: %T can not be hijacked", w)
+	}
+	header := w.Header().Clone()
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return err
+	}
+	if fault == faultReset {
+		if tc := tcpConn(conn); tc != nil {
+			// a zero linger makes Close send RST instead of FIN
+			_ = tc.SetLinger(0)
+		}
+	}
+	defer conn.Close()
+	if len(body) == 0 {
+		return nil
+	}
+	if reason == "" {
+		reason = http.StatusText(status)
+	}
+	header.Set("Content-Length", fmt.Sprint(len(body)))
+	return writeRawResponse(rw.Writer, status, reason, header, body[:len(body)/2])
+}
+
+// tcpConn returns the TCP connection under c, nil if there is none.
+func tcpConn(c net.Conn) *net.TCPConn {
+	for {
+		switch v := c.(type) {
+		case *net.TCPConn:
+			return v
+		case interface{ NetConn() net.Conn }:
+			c = v.NetConn()
+		default:
+			return nil
+		}
+	}
+}
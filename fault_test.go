@@ -0,0 +1,54 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDropConnection(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/dropped", "0123456789").DropConnection()
+	mock.Mock("/reset", "0123456789").ResetConnection()
+	mock.MockFunc("/handler", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("never sent"))
+	}).DropConnection()
+
+	resp, err := http.Get(mock.URL() + "/dropped")
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Error(t, err)
+		assert.Equal(t, "01234", string(body))
+	}
+
+	for _, path := range []string{"/reset", "/handler"} {
+		resp, err = http.Get(mock.URL() + path)
+		if err == nil {
+			_, err = ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+		assert.Error(t, err, path)
+	}
+}
+
+func TestFaultWithoutConnection(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/dropped", "0123456789").DropConnection()
+	mock.MockFunc("/handler", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("never sent"))
+	}).ResetConnection()
+
+	for _, path := range []string{"/dropped", "/handler"} {
+		rec := httptest.NewRecorder()
+		mock.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code, path)
+		assert.Contains(t, rec.Body.String(), "need a connection to break", path)
+	}
+}
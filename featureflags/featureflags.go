@@ -0,0 +1,312 @@
+// Package featureflags emulates a feature flag service on top of a
+// gohtmock.Mock: a snapshot endpoint, a long-poll endpoint and a server-sent
+// events stream that tests push flag changes into with SetFlag.
+package featureflags
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fortnoxab/gohtmock"
+)
+
+const (
+	// SnapshotPath answers with all flags and the current version.
+	SnapshotPath = "/flags"
+	// PollPath answers like SnapshotPath once the version is newer than the
+	// version query parameter, or with 304 Not Modified after the poll
+	// timeout.
+	PollPath = "/flags/poll"
+	// StreamPath is a text/event-stream starting with a put event holding
+	// all flags, followed by patch and delete events for every change. The
+	// id of each event is the version, so a client reconnecting with
+	// Last-Event-ID only gets the changes it missed.
+	StreamPath = "/flags/stream"
+)
+
+func init() {
+	gohtmock.RegisterPreset("feature-flags", func() gohtmock.Preset { return New() })
+}
+
+var _ gohtmock.Preset = (*Service)(nil)
+
+type Option func(*Service)
+
+// WithFlag sets the initial value of a flag.
+func WithFlag(key string, value any) Option {
+	return func(s *Service) {
+		s.flags[key] = value
+	}
+}
+
+// WithPollTimeout sets how long PollPath waits for a change, 30 seconds by
+// default.
+func WithPollTimeout(d time.Duration) Option {
+	return func(s *Service) {
+		s.pollTimeout = d
+	}
+}
+
+// Snapshot is the body of SnapshotPath and PollPath and the data of put
+// events.
+type Snapshot struct {
+	Version int            `json:"version"`
+	Flags   map[string]any `json:"flags"`
+}
+
+// Change is the data of patch and delete events.
+type Change struct {
+	Version int    `json:"version"`
+	Key     string `json:"key"`
+	Value   any    `json:"value,omitempty"`
+	deleted bool
+}
+
+type Service struct {
+	flags       map[string]any
+	version     int
+	log         []Change
+	pollTimeout time.Duration
+	// changed is closed and replaced on every change
+	changed chan struct{}
+	streams int
+	closing chan struct{}
+	// polls are the times of snapshot and poll requests, streaming tells
+	// for each if a stream was open at the time
+	polls     []time.Time
+	streaming []bool
+	sync.Mutex
+}
+
+func New(opts ...Option) *Service {
+	s := &Service{
+		flags:       make(map[string]any),
+		pollTimeout: 30 * time.Second,
+		changed:     make(chan struct{}),
+		closing:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Install registers the snapshot, poll and stream endpoints on m.
+func (s *Service) Install(m *gohtmock.Mock) error {
+	m.MockFunc(SnapshotPath, s.serveSnapshot)
+	m.MockFunc(PollPath, s.servePoll)
+	m.MockFunc(StreamPath, s.serveStream)
+	return nil
+}
+
+// SetFlag sets a flag and pushes the change to open streams and waiting
+// polls.
+func (s *Service) SetFlag(key string, value any) {
+	s.change(Change{Key: key, Value: value})
+}
+
+// DeleteFlag removes a flag and pushes the change to open streams and
+// waiting polls.
+func (s *Service) DeleteFlag(key string) {
+	s.change(Change{Key: key, deleted: true})
+}
+
+// Flags returns a copy of the current flags.
+func (s *Service) Flags() map[string]any {
+	return s.snapshot().Flags
+}
+
+// Streams returns the number of open streams.
+func (s *Service) Streams() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.streams
+}
+
+// Polls returns the number of snapshot and poll requests.
+func (s *Service) Polls() int {
+	s.Lock()
+	defer s.Unlock()
+	return len(s.polls)
+}
+
+// CloseStreams ends all open streams, forcing clients to reconnect.
+func (s *Service) CloseStreams() {
+	s.Lock()
+	close(s.closing)
+	s.closing = make(chan struct{})
+	s.Unlock()
+}
+
+// AssertPollIntervalAtLeast asserts that the client never fetched the
+// snapshot or polled sooner than min after its previous request.
+func (s *Service) AssertPollIntervalAtLeast(tb testing.TB, min time.Duration) {
+	s.Lock()
+	polls := append([]time.Time(nil), s.polls...)
+	s.Unlock()
+	for i := 1; i < len(polls); i++ {
+		if gap := polls[i].Sub(polls[i-1]); gap < min {
+			tb.Errorf("featureflags: poll %d came %s after the previous one, expected at least %s", i+1, gap, min)
+		}
+	}
+}
+
+// AssertNoPollingWhileStreaming asserts that the client did not fetch the
+// snapshot or poll while it had a stream open.
+func (s *Service) AssertNoPollingWhileStreaming(tb testing.TB) {
+	s.Lock()
+	defer s.Unlock()
+	n := 0
+	for _, streaming := range s.streaming {
+		if streaming {
+			n++
+		}
+	}
+	if n > 0 {
+		tb.Errorf("featureflags: %d of %d polls were made while a stream was open", n, len(s.polls))
+	}
+}
+
+func (s *Service) change(c Change) {
+	s.Lock()
+	defer s.Unlock()
+	s.version++
+	c.Version = s.version
+	if c.deleted {
+		delete(s.flags, c.Key)
+	} else {
+		s.flags[c.Key] = c.Value
+	}
+	s.log = append(s.log, c)
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *Service) snapshot() Snapshot {
+	s.Lock()
+	defer s.Unlock()
+	flags := make(map[string]any, len(s.flags))
+	for k, v := range s.flags {
+		flags[k] = v
+	}
+	return Snapshot{Version: s.version, Flags: flags}
+}
+
+func (s *Service) notePoll() {
+	s.Lock()
+	s.polls = append(s.polls, time.Now())
+	s.streaming = append(s.streaming, s.streams > 0)
+	s.Unlock()
+}
+
+func (s *Service) serveSnapshot(w http.ResponseWriter, r *http.Request) {
+	s.notePoll()
+	writeJSON(w, s.snapshot())
+}
+
+func (s *Service) servePoll(w http.ResponseWriter, r *http.Request) {
+	s.notePoll()
+	since, _ := strconv.Atoi(r.URL.Query().Get("version"))
+	timeout := time.NewTimer(s.pollTimeout)
+	defer timeout.Stop()
+	for {
+		s.Lock()
+		version, changed := s.version, s.changed
+		s.Unlock()
+		if version > since {
+			writeJSON(w, s.snapshot())
+			return
+		}
+		select {
+		case <-changed:
+		case <-timeout.C:
+			w.WriteHeader(http.StatusNotModified)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *Service) serveStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	s.Lock()
+	s.streams++
+	closing := s.closing
+	s.Unlock()
+	defer func() {
+		s.Lock()
+		s.streams--
+		s.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	since := -1
+	if id, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil {
+		since = id
+	}
+	if !s.resumable(since) {
+		snap := s.snapshot()
+		writeEvent(w, "put", snap.Version, snap)
+		since = snap.Version
+	}
+	for {
+		s.Lock()
+		changes := s.changesSince(since)
+		changed := s.changed
+		s.Unlock()
+		for _, c := range changes {
+			if c.deleted {
+				writeEvent(w, "delete", c.Version, Change{Version: c.Version, Key: c.Key})
+			} else {
+				writeEvent(w, "patch", c.Version, c)
+			}
+			since = c.Version
+		}
+		flusher.Flush()
+
+		select {
+		case <-changed:
+		case <-closing:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// resumable reports if the changes after version since are all logged.
+func (s *Service) resumable(since int) bool {
+	s.Lock()
+	defer s.Unlock()
+	return since >= 0 && since <= s.version
+}
+
+// changesSince returns the changes after version since. s must be locked.
+func (s *Service) changesSince(since int) []Change {
+	i := sort.Search(len(s.log), func(i int) bool { return s.log[i].Version > since })
+	return append([]Change(nil), s.log[i:]...)
+}
+
+func writeEvent(w http.ResponseWriter, event string, id int, data any) {
+	b, _ := json.Marshal(data)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, b)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,137 @@
+package featureflags
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fortnoxab/gohtmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type event struct {
+	id, name, data string
+}
+
+func readEvent(t *testing.T, scanner *bufio.Scanner) event {
+	var e event
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			return e
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	t.Fatal("stream ended")
+	return e
+}
+
+func openStream(t *testing.T, ctx context.Context, url, lastEventID string) (*http.Response, *bufio.Scanner) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url+StreamPath, nil)
+	assert.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	return resp, bufio.NewScanner(resp.Body)
+}
+
+func TestStream(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	flags := New(WithFlag("new-ui", false))
+	assert.NoError(t, mock.Install(flags))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resp, scanner := openStream(t, ctx, mock.URL(), "")
+	defer resp.Body.Close()
+	assert.Equal(t, event{id: "0", name: "put", data: `{"version":0,"flags":{"new-ui":false}}`}, readEvent(t, scanner))
+	assert.Equal(t, 1, flags.Streams())
+
+	flags.SetFlag("new-ui", true)
+	assert.Equal(t, event{id: "1", name: "patch", data: `{"version":1,"key":"new-ui","value":true}`}, readEvent(t, scanner))
+	flags.DeleteFlag("new-ui")
+	assert.Equal(t, event{id: "2", name: "delete", data: `{"version":2,"key":"new-ui"}`}, readEvent(t, scanner))
+
+	flags.CloseStreams()
+	assert.False(t, scanner.Scan())
+
+	flags.SetFlag("limit", 10)
+	resp, scanner = openStream(t, ctx, mock.URL(), "2")
+	defer resp.Body.Close()
+	assert.Equal(t, event{id: "3", name: "patch", data: `{"version":3,"key":"limit","value":10}`}, readEvent(t, scanner))
+	assert.Equal(t, map[string]any{"limit": 10}, flags.Flags())
+}
+
+func TestPoll(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	flags := New(WithFlag("new-ui", false), WithPollTimeout(50*time.Millisecond))
+	assert.NoError(t, mock.Install(flags))
+
+	get := func(path string) (int, Snapshot) {
+		resp, err := http.Get(mock.URL() + path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		var snap Snapshot
+		if resp.StatusCode == http.StatusOK {
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&snap))
+		}
+		return resp.StatusCode, snap
+	}
+
+	status, snap := get(SnapshotPath)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, Snapshot{Version: 0, Flags: map[string]any{"new-ui": false}}, snap)
+
+	status, _ = get(PollPath + "?version=0")
+	assert.Equal(t, http.StatusNotModified, status)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		flags.SetFlag("new-ui", true)
+	}()
+	status, snap = get(PollPath + "?version=0")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, Snapshot{Version: 1, Flags: map[string]any{"new-ui": true}}, snap)
+
+	assert.Equal(t, 3, flags.Polls())
+	flags.AssertNoPollingWhileStreaming(t)
+	rt := gohtmock.NewChecker("TestPoll")
+	flags.AssertPollIntervalAtLeast(rt, time.Hour)
+	assert.Error(t, rt.Err())
+}
+
+func TestPollingWhileStreaming(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	p, err := mock.InstallPreset("feature-flags")
+	assert.NoError(t, err)
+	flags := p.(*Service)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resp, scanner := openStream(t, ctx, mock.URL(), "")
+	defer resp.Body.Close()
+	readEvent(t, scanner)
+	poll, err := http.Get(mock.URL() + SnapshotPath)
+	assert.NoError(t, err)
+	poll.Body.Close()
+
+	assert.EqualError(t, gohtmock.Check(flags.AssertNoPollingWhileStreaming), "featureflags: 1 of 1 polls were made while a stream was open")
+}
@@ -0,0 +1,167 @@
+package gohtmock
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// MockFile serves the file at filename, opened anew on every call. Range
+// requests are supported and the content is streamed, using sendfile where
+// the platform allows, so files of any size can be served. The content type
+// is detected from the file unless set with SetHeader.
+func (m *Mock) MockFile(path, filename string) *mockResponse {
+	mr := m.newFileResponse(path, filename)
+	m.add(mr)
+	return mr
+}
+
+func (o *Owned) MockFile(path, filename string) *mockResponse {
+	mr := o.mock.newFileResponse(path, filename)
+	mr.owner = o.owner
+	o.mock.add(mr)
+	return mr
+}
+
+func (m *Mock) newFileResponse(path, filename string) *mockResponse {
+	mr := m.newMockResponse(path, "")
+	mr.headers.Del("Content-Type")
+	mr.handler = func(w http.ResponseWriter, r *http.Request) {
+		f, err := os.Open(filename)
+		if err != nil {
+			mr.serveError(w, r, err)
+			return
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			mr.serveError(w, r, err)
+			return
+		}
+		if mr.gzipEnabled() {
+			// the response depends on Accept-Encoding either way
+			w.Header().Add("Vary", "Accept-Encoding")
+			if acceptsGzip(r) {
+				mr.serveGzip(w, r, f, info)
+				return
+			}
+		}
+		http.ServeContent(w, r, filepath.Base(filename), info.ModTime(), f)
+	}
+	return mr
+}
+
+// RespondReader makes mr stream the body from the reader returned by open,
+// which is called for every request. The reader is closed after use if it
+// is an io.Closer.
+func (mr *mockResponse) RespondReader(open func() (io.Reader, error)) *mockResponse {
+	mr.Lock()
+	mr.handler = func(w http.ResponseWriter, r *http.Request) {
+		body, err := open()
+		if err != nil {
+			mr.serveError(w, r, err)
+			return
+		}
+		if c, ok := body.(io.Closer); ok {
+			defer c.Close()
+		}
+		mr.Lock()
+		status := mr.status
+		mr.Unlock()
+		if status != 0 {
+			w.WriteHeader(status)
+		}
+		_, _ = io.Copy(w, body)
+	}
+	mr.Unlock()
+	return mr
+}
+
+// BytesServed returns the number of response body bytes written by mr.
+func (mr *mockResponse) BytesServed() int64 {
+	return atomic.LoadInt64(&mr.bytesServed)
+}
+
+func (mr *mockResponse) serveError(w http.ResponseWriter, r *http.Request, err error) {
+	msg := fmt.Sprintf("%s %s: %s%s", r.Method, r.URL.Path, err, mr.ownedBy())
+	if !mr.fail("%s", msg) {
+		log.Print(msg)
+	}
+	w.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprint(w, "gohtmock: ", msg)
+}
+
+// countingWriter counts the body bytes written through it. It implements
+// io.ReaderFrom so that io.Copy from a file can still use sendfile.
+type countingWriter struct {
+	http.ResponseWriter
+	n      *int64
+	status SentStatus
+}
+
+func (c *countingWriter) WriteHeader(status int) {
+	if c.status.Code == 0 && !isInformational(status) {
+		c.status = SentStatus{Code: status, Reason: http.StatusText(status)}
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *countingWriter) noteStatus(status int, reason string) {
+	c.status = SentStatus{Code: status, Reason: reason}
+}
+
+// sentStatus returns the status written through c, nil if nothing was written.
+func (c *countingWriter) sentStatus() *SentStatus {
+	if c.status.Code == 0 && atomic.LoadInt64(c.n) == 0 {
+		return nil
+	}
+	if c.status.Code == 0 {
+		return &SentStatus{Code: http.StatusOK, Reason: http.StatusText(http.StatusOK)}
+	}
+	return &c.status
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	if c.status.Code == 0 {
+		c.status = SentStatus{Code: http.StatusOK, Reason: http.StatusText(http.StatusOK)}
+	}
+	n, err := c.ResponseWriter.Write(b)
+	atomic.AddInt64(c.n, int64(n))
+	return n, err
+}
+
+func (c *countingWriter) ReadFrom(src io.Reader) (int64, error) {
+	var n int64
+	var err error
+	if rf, ok := c.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(struct{ io.Writer }{c.ResponseWriter}, src)
+	}
+	atomic.AddInt64(c.n, n)
+	return n, err
+}
+
+func (c *countingWriter) Flush() {
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (c *countingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := c.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("gohtmock: %T can not be hijacked", c.ResponseWriter)
+	}
+	return h.Hijack()
+}
+
+func (c *countingWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
@@ -0,0 +1,94 @@
+package gohtmock
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockFile(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 100000)
+	filename := filepath.Join(t.TempDir(), "large.txt")
+	assert.NoError(t, os.WriteFile(filename, content, 0o644))
+
+	mock := New()
+	defer mock.Close()
+	mr := mock.MockFile("/large.txt", filename)
+
+	resp, err := http.Get(mock.URL() + "/large.txt")
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, content, body)
+	assert.Equal(t, int64(len(content)), mr.BytesServed())
+
+	req, _ := http.NewRequest(http.MethodGet, mock.URL()+"/large.txt", nil)
+	req.Header.Set("Range", "bytes=10-19")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	body, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, "0123456789", string(body))
+	assert.Equal(t, int64(len(content)+10), mr.BytesServed())
+}
+
+func TestMockFileMissing(t *testing.T) {
+	rt := newRecordingT("TestMockFileMissing")
+	mock := New()
+	defer mock.Close()
+	mock.For(rt).MockFile("/missing", filepath.Join(t.TempDir(), "missing"))
+
+	resp, err := http.Get(mock.URL() + "/missing")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Len(t, rt.Errors(), 1)
+}
+
+func TestRespondReader(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mr := mock.Mock("/stream", "").WithStatus(http.StatusAccepted).RespondReader(func() (io.Reader, error) {
+		return io.LimitReader(zeroReader{}, 1<<20), nil
+	})
+	mock.Mock("/broken", "").RespondReader(func() (io.Reader, error) {
+		return nil, errors.New("no stream")
+	})
+
+	resp, err := http.Get(mock.URL() + "/stream")
+	assert.NoError(t, err)
+	n, err := io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, int64(1<<20), n)
+	assert.Equal(t, int64(1<<20), mr.BytesServed())
+
+	resp, err = http.Get(mock.URL() + "/broken")
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.True(t, strings.Contains(string(body), "no stream"))
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 0
+	}
+	return len(b), nil
+}
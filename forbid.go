@@ -0,0 +1,89 @@
+package gohtmock
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"testing"
+)
+
+type forbidden struct {
+	method  string
+	pattern string
+	owner   *owner
+	// violations describes the requests that matched
+	violations []string
+}
+
+// Forbid makes requests with method to a path matching pattern fail,
+// regardless of any mock that would answer them. The method ANY forbids all
+// methods and pattern uses the syntax of path.Match, so "/users/*" forbids
+// every user. Violations are answered with 403 Forbidden, logged and
+// reported by AssertNotForbidden. Use For(t).Forbid to fail t immediately.
+func (m *Mock) Forbid(method, pattern string) {
+	m.forbid(method, pattern, nil)
+}
+
+// Forbid is Mock.Forbid, failing the owner of o as soon as a forbidden
+// request arrives.
+func (o *Owned) Forbid(method, pattern string) {
+	o.mock.forbid(method, pattern, o.owner)
+}
+
+func (m *Mock) forbid(method, pattern string, o *owner) {
+	if _, err := path.Match(pattern, "/"); err != nil {
+		panic(fmt.Sprintf("gohtmock: invalid forbidden path %q: %s", pattern, err))
+	}
+	m.Lock()
+	m.forbidden = append(m.forbidden, &forbidden{method: method, pattern: pattern, owner: o})
+	m.Unlock()
+}
+
+// serveForbidden answers r with 403 if it is forbidden and reports if it did.
+func (m *Mock) serveForbidden(w http.ResponseWriter, r *http.Request) bool {
+	m.Lock()
+	var f *forbidden
+	for _, v := range m.forbidden {
+		if v.method != r.Method && v.method != "ANY" {
+			continue
+		}
+		if ok, _ := path.Match(v.pattern, r.URL.Path); ok {
+			f = v
+			break
+		}
+	}
+	if f == nil {
+		m.Unlock()
+		return false
+	}
+	msg := fmt.Sprintf("forbidden request %s %s matches %s %s", r.Method, r.URL.RequestURI(), f.method, f.pattern)
+	f.violations = append(f.violations, msg)
+	o := f.owner
+	m.Unlock()
+
+	if o == nil || !o.fail("%s", msg) {
+		log.Print("gohtmock: ", msg)
+	}
+	w.WriteHeader(http.StatusForbidden)
+	fmt.Fprint(w, "gohtmock: ", msg)
+	return true
+}
+
+// AssertNotForbidden fails tb for every forbidden request that arrived,
+// including those rejected by the allowlist of Allow.
+func (m *Mock) AssertNotForbidden(tb testing.TB) {
+	if m.assertionsDisabled(tb) {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+	for _, f := range m.forbidden {
+		for _, v := range f.violations {
+			tb.Errorf("%s", v)
+		}
+	}
+	for _, v := range m.disallowed {
+		tb.Errorf("%s", v)
+	}
+}
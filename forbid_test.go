@@ -0,0 +1,38 @@
+package gohtmock
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForbid(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/users/1", "").SetMethod("DELETE")
+	mock.Forbid("DELETE", "/users/*")
+	rt := newRecordingT("TestForbid")
+	mock.For(rt).Forbid("ANY", "/admin")
+
+	do := func(method, path string) int {
+		req, _ := http.NewRequest(method, mock.URL()+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusForbidden, do("DELETE", "/users/1"))
+	assert.Equal(t, http.StatusNotFound, do("GET", "/users/1"))
+	assert.Equal(t, http.StatusForbidden, do("POST", "/admin"))
+	assert.Equal(t, []string{"forbidden request POST /admin matches ANY /admin"}, rt.Errors())
+
+	rt = newRecordingT("TestForbid")
+	mock.AssertNotForbidden(rt)
+	assert.Equal(t, []string{
+		"forbidden request DELETE /users/1 matches DELETE /users/*",
+		"forbidden request POST /admin matches ANY /admin",
+	}, rt.Errors())
+	assert.Panics(t, func() { mock.Forbid("GET", "[") })
+}
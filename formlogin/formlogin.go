@@ -0,0 +1,254 @@
+// Package formlogin emulates HTML form based authentication on top of a
+// gohtmock.Mock: a login form protected by a CSRF token, a session cookie
+// on successful login and CSRF tokens for the session that other mocks can
+// require.
+package formlogin
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/fortnoxab/gohtmock"
+)
+
+const (
+	LoginPath  = "/login"
+	LogoutPath = "/logout"
+	// SessionCookie holds the session id, CSRFCookie the CSRF token of the
+	// session for clients that echo it.
+	SessionCookie = "session"
+	CSRFCookie    = "csrf_token"
+	// CSRFField is the form field and CSRFHeader the header that carry the
+	// CSRF token in requests.
+	CSRFField  = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+)
+
+func init() {
+	gohtmock.RegisterPreset("form-login", func() gohtmock.Preset { return New() })
+}
+
+var _ gohtmock.Preset = (*Login)(nil)
+
+type Option func(*Login)
+
+// WithUser adds a user that can log in with password.
+func WithUser(username, password string) Option {
+	return func(l *Login) {
+		l.users[username] = password
+	}
+}
+
+// WithRedirect sets where a successful login redirects to, "/" by default.
+func WithRedirect(path string) Option {
+	return func(l *Login) {
+		l.redirect = path
+	}
+}
+
+type session struct {
+	user string
+	csrf string
+}
+
+type Login struct {
+	users    map[string]string
+	redirect string
+	// formTokens are the unused CSRF tokens of served login forms
+	formTokens map[string]bool
+	sessions   map[string]*session
+	failures   int
+	sync.Mutex
+}
+
+func New(opts ...Option) *Login {
+	l := &Login{
+		users:      make(map[string]string),
+		redirect:   "/",
+		formTokens: make(map[string]bool),
+		sessions:   make(map[string]*session),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Install registers the login and logout endpoints on m.
+func (l *Login) Install(m *gohtmock.Mock) error {
+	m.MockFunc(LoginPath, l.serveLogin).SetMethod("ANY")
+	m.MockFunc(LogoutPath, l.serveLogout).SetMethod(http.MethodPost)
+	return nil
+}
+
+// AddUser adds a user that can log in with password.
+func (l *Login) AddUser(username, password string) {
+	l.Lock()
+	l.users[username] = password
+	l.Unlock()
+}
+
+// User returns the user logged in with the session cookie of r.
+func (l *Login) User(r *http.Request) (string, bool) {
+	s := l.session(r)
+	if s == nil {
+		return "", false
+	}
+	return s.user, true
+}
+
+// Failures returns the number of rejected login attempts.
+func (l *Login) Failures() int {
+	l.Lock()
+	defer l.Unlock()
+	return l.failures
+}
+
+// RequireSession is a guard for mr.Guard that answers requests without a
+// valid session cookie with a redirect to the login form.
+func (l *Login) RequireSession(w http.ResponseWriter, r *http.Request) bool {
+	if l.session(r) != nil {
+		return true
+	}
+	http.Redirect(w, r, LoginPath+"?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+	return false
+}
+
+// RequireCSRF is a guard for mr.Guard that, besides a valid session, needs
+// the CSRF token of the session in the CSRFHeader header or the CSRFField
+// form field. Requests with a missing or wrong token get a 403.
+func (l *Login) RequireCSRF(w http.ResponseWriter, r *http.Request) bool {
+	if !l.RequireSession(w, r) {
+		return false
+	}
+	s := l.session(r)
+	token := r.Header.Get(CSRFHeader)
+	if token == "" {
+		token = formValue(r, CSRFField)
+	}
+	if token == "" || token != s.csrf {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, "formlogin: missing or invalid CSRF token")
+		return false
+	}
+	return true
+}
+
+func (l *Login) session(r *http.Request) *session {
+	c, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return nil
+	}
+	l.Lock()
+	defer l.Unlock()
+	return l.sessions[c.Value]
+}
+
+func (l *Login) serveLogin(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		l.writeForm(w, http.StatusOK, r.URL.Query().Get("next"), "")
+	case http.MethodPost:
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "formlogin: %s", err)
+			return
+		}
+		next := r.PostForm.Get("next")
+		l.Lock()
+		validToken := l.formTokens[r.PostForm.Get(CSRFField)]
+		delete(l.formTokens, r.PostForm.Get(CSRFField))
+		password, known := l.users[r.PostForm.Get("username")]
+		ok := validToken && known && password == r.PostForm.Get("password")
+		if !ok {
+			l.failures++
+		}
+		l.Unlock()
+		if !validToken {
+			l.writeForm(w, http.StatusForbidden, next, "The form has expired, please try again.")
+			return
+		}
+		if !ok {
+			l.writeForm(w, http.StatusUnauthorized, next, "Invalid username or password.")
+			return
+		}
+
+		id, csrf := randomToken(), randomToken()
+		l.Lock()
+		l.sessions[id] = &session{user: r.PostForm.Get("username"), csrf: csrf}
+		l.Unlock()
+		http.SetCookie(w, &http.Cookie{Name: SessionCookie, Value: id, Path: "/", HttpOnly: true})
+		http.SetCookie(w, &http.Cookie{Name: CSRFCookie, Value: csrf, Path: "/"})
+		if next == "" {
+			next = l.redirect
+		}
+		http.Redirect(w, r, next, http.StatusSeeOther)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (l *Login) serveLogout(w http.ResponseWriter, r *http.Request) {
+	if c, err := r.Cookie(SessionCookie); err == nil {
+		l.Lock()
+		delete(l.sessions, c.Value)
+		l.Unlock()
+	}
+	http.SetCookie(w, &http.Cookie{Name: SessionCookie, Path: "/", MaxAge: -1})
+	http.SetCookie(w, &http.Cookie{Name: CSRFCookie, Path: "/", MaxAge: -1})
+	http.Redirect(w, r, LoginPath, http.StatusSeeOther)
+}
+
+func (l *Login) writeForm(w http.ResponseWriter, status int, next, message string) {
+	token := randomToken()
+	l.Lock()
+	l.formTokens[token] = true
+	l.Unlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprint(w, "<!DOCTYPE html>\n<html><body>\n")
+	if message != "" {
+		fmt.Fprintf(w, "<p class=\"error\">%s</p>\n", html.EscapeString(message))
+	}
+	fmt.Fprintf(w, `<form method="post" action="%s">
+<input type="hidden" name="%s" value="%s">
+<input type="hidden" name="next" value="%s">
+<input type="text" name="username">
+<input type="password" name="password">
+<button type="submit">Log in</button>
+</form>
+</body></html>
+`, LoginPath, CSRFField, token, html.EscapeString(next))
+}
+
+// formValue returns a field of a urlencoded form body and leaves the body
+// in place to be read again.
+func formValue(r *http.Request, field string) string {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" || r.Body == nil {
+		return ""
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	values, _ := url.ParseQuery(string(body))
+	return values.Get(field)
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
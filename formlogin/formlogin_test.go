@@ -0,0 +1,90 @@
+package formlogin
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/fortnoxab/gohtmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var tokenField = regexp.MustCompile(`name="csrf_token" value="([0-9a-f]+)"`)
+
+func formToken(t *testing.T, client *http.Client, u string) string {
+	resp, err := client.Get(u + LoginPath)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	m := tokenField.FindStringSubmatch(string(b))
+	if !assert.Len(t, m, 2, string(b)) {
+		t.FailNow()
+	}
+	return m[1]
+}
+
+func TestLogin(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	login := New(WithUser("alice", "s3cret"), WithRedirect("/account"))
+	assert.NoError(t, mock.Install(login))
+	mock.Mock("/account", "welcome").Guard(login.RequireSession)
+	mock.Mock("/transfer", "done").SetMethod("POST").Guard(login.RequireCSRF)
+
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+
+	// the guarded page redirects to the form
+	resp, err := client.Get(mock.URL() + "/account")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "/login", resp.Request.URL.Path)
+
+	resp, err = client.PostForm(mock.URL()+LoginPath, url.Values{"username": {"alice"}, "password": {"wrong"}, CSRFField: {formToken(t, client, mock.URL())}})
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, 1, login.Failures())
+
+	// a token is only valid once
+	token := formToken(t, client, mock.URL())
+	resp, err = client.PostForm(mock.URL()+LoginPath, url.Values{"username": {"alice"}, "password": {"s3cret"}, CSRFField: {token}})
+	assert.NoError(t, err)
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "welcome", string(b))
+	resp, err = client.PostForm(mock.URL()+LoginPath, url.Values{"username": {"alice"}, "password": {"s3cret"}, CSRFField: {token}})
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, err = client.PostForm(mock.URL()+"/transfer", url.Values{"amount": {"10"}})
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	var csrf string
+	u, _ := url.Parse(mock.URL())
+	for _, c := range jar.Cookies(u) {
+		if c.Name == CSRFCookie {
+			csrf = c.Value
+		}
+	}
+	resp, err = client.PostForm(mock.URL()+"/transfer", url.Values{"amount": {"10"}, CSRFField: {csrf}})
+	assert.NoError(t, err)
+	b, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "done", string(b))
+
+	resp, err = client.Post(mock.URL()+LogoutPath, "", strings.NewReader(""))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	resp, err = client.Get(mock.URL() + "/account")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "/login", resp.Request.URL.Path)
+}
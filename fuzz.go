@@ -0,0 +1,377 @@
+package gohtmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FuzzFromSchema makes mr answer every call with a new random JSON value
+// valid against jsonSchema, to find assumptions of clients beyond a single
+// example payload. The same seed gives the same sequence of bodies.
+//
+// The supported subset of JSON Schema is type, including type lists, enum,
+// const, properties, required, items, oneOf, anyOf, allOf, local $ref,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf,
+// minLength, maxLength, minItems, maxItems and the formats date-time, date,
+// uuid, email and uri. Properties not required are left out at random. It
+// panics if jsonSchema is not valid JSON.
+func (mr *mockResponse) FuzzFromSchema(jsonSchema string, seed int64) *mockResponse {
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(jsonSchema), &schema); err != nil {
+		panic(fmt.Sprintf("gohtmock: FuzzFromSchema: %s", err))
+	}
+	f := &schemaFuzzer{root: schema, rand: rand.New(rand.NewSource(seed))}
+	mr.Lock()
+	mr.handler = func(w http.ResponseWriter, r *http.Request) {
+		body, err := f.next()
+		if err != nil {
+			mr.serveError(w, r, err)
+			return
+		}
+		mr.Lock()
+		status := mr.status
+		mr.Unlock()
+		if err := mr.writeBody(w, status, body); err != nil {
+			log.Print("gohtmock: writing response for ", r.URL.Path, ": ", err)
+		}
+	}
+	mr.Unlock()
+	return mr
+}
+
+// schemaFuzzer generates values for a JSON schema.
+type schemaFuzzer struct {
+	root map[string]any
+	rand *rand.Rand
+	sync.Mutex
+}
+
+// maxFuzzDepth stops recursive schemas from generating endless values.
+const maxFuzzDepth = 16
+
+func (f *schemaFuzzer) next() ([]byte, error) {
+	f.Lock()
+	defer f.Unlock()
+	v, err := f.value(f.root, 0)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func (f *schemaFuzzer) value(schema map[string]any, depth int) (any, error) {
+	if depth > maxFuzzDepth {
+		return nil, fmt.Errorf("schema nests deeper than %d levels", maxFuzzDepth)
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, err := f.resolve(ref)
+		if err != nil {
+			return nil, err
+		}
+		return f.value(resolved, depth+1)
+	}
+	if c, ok := schema["const"]; ok {
+		return c, nil
+	}
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		return enum[f.rand.Intn(len(enum))], nil
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if options, ok := schema[key].([]any); ok && len(options) > 0 {
+			option, _ := options[f.rand.Intn(len(options))].(map[string]any)
+			return f.value(option, depth+1)
+		}
+	}
+	if all, ok := schema["allOf"].([]any); ok {
+		return f.value(mergeSchemas(schema, all), depth+1)
+	}
+
+	switch t := f.typeOf(schema); t {
+	case "object":
+		return f.object(schema, depth)
+	case "array":
+		return f.array(schema, depth)
+	case "string":
+		return f.string(schema), nil
+	case "integer":
+		return f.integer(schema), nil
+	case "number":
+		return f.number(schema), nil
+	case "boolean":
+		return f.rand.Intn(2) == 1, nil
+	case "null":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported schema type %q", t)
+	}
+}
+
+// typeOf picks the type of schema, one at random of a type list.
+func (f *schemaFuzzer) typeOf(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		if len(t) > 0 {
+			s, _ := t[f.rand.Intn(len(t))].(string)
+			return s
+		}
+	}
+	switch {
+	case schema["properties"] != nil:
+		return "object"
+	case schema["items"] != nil:
+		return "array"
+	}
+	return "null"
+}
+
+// resolve looks up a local reference such as #/$defs/address.
+func (f *schemaFuzzer) resolve(ref string) (map[string]any, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("only local $ref is supported, got %s", ref)
+	}
+	var node any = f.root
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimPrefix(ref, "#"), "/"), "/") {
+		if part == "" {
+			continue
+		}
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %s", ref)
+		}
+		node = m[part]
+	}
+	resolved, ok := node.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unresolvable $ref %s", ref)
+	}
+	return resolved, nil
+}
+
+// mergeSchemas combines schema, without its allOf, with the schemas of all.
+// Properties and required are merged, other keywords of later schemas win.
+func mergeSchemas(schema map[string]any, all []any) map[string]any {
+	merged := make(map[string]any)
+	properties := make(map[string]any)
+	var required []any
+	for _, s := range append([]any{schema}, all...) {
+		m, _ := s.(map[string]any)
+		for k, v := range m {
+			switch k {
+			case "allOf":
+			case "properties":
+				props, _ := v.(map[string]any)
+				for name, p := range props {
+					properties[name] = p
+				}
+			case "required":
+				r, _ := v.([]any)
+				required = append(required, r...)
+			default:
+				merged[k] = v
+			}
+		}
+	}
+	if len(properties) > 0 {
+		merged["properties"] = properties
+	}
+	if len(required) > 0 {
+		merged["required"] = required
+	}
+	return merged
+}
+
+func (f *schemaFuzzer) object(schema map[string]any, depth int) (any, error) {
+	properties, _ := schema["properties"].(map[string]any)
+	required := make(map[string]bool)
+	if r, ok := schema["required"].([]any); ok {
+		for _, name := range r {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+	// sorted so that a seed always gives the same values
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	obj := make(map[string]any)
+	for _, name := range names {
+		if !required[name] && f.rand.Intn(2) == 0 {
+			continue
+		}
+		p, _ := properties[name].(map[string]any)
+		v, err := f.value(p, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		obj[name] = v
+	}
+	return obj, nil
+}
+
+func (f *schemaFuzzer) array(schema map[string]any, depth int) (any, error) {
+	min, max := intKeyword(schema, "minItems", 0), intKeyword(schema, "maxItems", -1)
+	if max < 0 {
+		max = min + 5
+	}
+	n := min
+	if max > min {
+		n += f.rand.Intn(max - min + 1)
+	}
+	items, _ := schema["items"].(map[string]any)
+	arr := make([]any, 0, n)
+	for i := 0; i < n; i++ {
+		v, err := f.value(items, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		arr = append(arr, v)
+	}
+	return arr, nil
+}
+
+const fuzzLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 åäö-_"
+
+func (f *schemaFuzzer) string(schema map[string]any) string {
+	switch schema["format"] {
+	case "date-time":
+		return f.time().Format(time.RFC3339)
+	case "date":
+		return f.time().Format("2006-01-02")
+	case "uuid":
+		b := make([]byte, 16)
+		f.rand.Read(b)
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+	case "email":
+		return f.word(1, 10) + "@" + f.word(1, 10) + ".example"
+	case "uri":
+		return "https://" + f.word(1, 10) + ".example/" + f.word(0, 10)
+	}
+	min, max := intKeyword(schema, "minLength", 0), intKeyword(schema, "maxLength", -1)
+	if max < 0 {
+		max = min + 20
+	}
+	letters := []rune(fuzzLetters)
+	n := min
+	if max > min {
+		n += f.rand.Intn(max - min + 1)
+	}
+	s := make([]rune, n)
+	for i := range s {
+		s[i] = letters[f.rand.Intn(len(letters))]
+	}
+	return string(s)
+}
+
+// word returns between min and max lower case letters.
+func (f *schemaFuzzer) word(min, max int) string {
+	n := min + f.rand.Intn(max-min+1)
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('a' + f.rand.Intn(26))
+	}
+	return string(b)
+}
+
+func (f *schemaFuzzer) time() time.Time {
+	return time.Unix(f.rand.Int63n(4102444800), 0).UTC()
+}
+
+func (f *schemaFuzzer) integer(schema map[string]any) int64 {
+	min, max := f.bounds(schema, -1000000, 1000000)
+	lo, hi := int64(math.Ceil(min)), int64(math.Floor(max))
+	if m, ok := schema["multipleOf"].(float64); ok && m >= 1 && m == math.Trunc(m) {
+		step := int64(m)
+		lo, hi = ceilDiv(lo, step), floorDiv(hi, step)
+		if hi < lo {
+			return lo * step
+		}
+		return (lo + f.rand.Int63n(hi-lo+1)) * step
+	}
+	if hi < lo {
+		return lo
+	}
+	return lo + f.rand.Int63n(hi-lo+1)
+}
+
+func (f *schemaFuzzer) number(schema map[string]any) float64 {
+	min, max := f.bounds(schema, -1000000, 1000000)
+	if m, ok := schema["multipleOf"].(float64); ok && m > 0 {
+		lo, hi := math.Ceil(min/m), math.Floor(max/m)
+		if hi < lo {
+			return lo * m
+		}
+		return (lo + float64(f.rand.Int63n(int64(hi-lo)+1))) * m
+	}
+	return min + f.rand.Float64()*(max-min)
+}
+
+// bounds returns the inclusive range of numbers schema allows, narrowed to
+// the defaults if unbounded.
+func (f *schemaFuzzer) bounds(schema map[string]any, min, max float64) (float64, float64) {
+	if v, ok := schema["minimum"].(float64); ok {
+		min = v
+		if _, ok := schema["maximum"]; !ok && max < min {
+			max = min + 1000000
+		}
+	}
+	if v, ok := schema["maximum"].(float64); ok {
+		max = v
+		if _, ok := schema["minimum"]; !ok && min > max {
+			min = max - 1000000
+		}
+	}
+	// exclusive bounds of whole numbers are moved by one, others by a
+	// fraction small enough for both
+	if v, ok := schema["exclusiveMinimum"].(float64); ok {
+		min = math.Max(min, math.Nextafter(v, math.Inf(1)))
+		if schema["type"] == "integer" {
+			min = math.Max(min, math.Floor(v)+1)
+		}
+	}
+	if v, ok := schema["exclusiveMaximum"].(float64); ok {
+		max = math.Min(max, math.Nextafter(v, math.Inf(-1)))
+		if schema["type"] == "integer" {
+			max = math.Min(max, math.Ceil(v)-1)
+		}
+	}
+	return min, max
+}
+
+func intKeyword(schema map[string]any, key string, def int) int {
+	if v, ok := schema[key].(float64); ok {
+		return int(v)
+	}
+	return def
+}
+
+func ceilDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && a > 0 {
+		q++
+	}
+	return q
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,110 @@
+package gohtmock
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const orderSchema = `{
+	"type": "object",
+	"required": ["id", "qty", "status", "lines", "customer"],
+	"properties": {
+		"id": {"type": "string", "format": "uuid"},
+		"note": {"type": ["string", "null"], "maxLength": 5},
+		"qty": {"type": "integer", "minimum": 2, "exclusiveMaximum": 10, "multipleOf": 2},
+		"price": {"type": "number", "minimum": 0, "maximum": 1},
+		"status": {"enum": ["open", "paid"]},
+		"created": {"type": "string", "format": "date-time"},
+		"lines": {"type": "array", "minItems": 1, "maxItems": 3, "items": {"$ref": "#/$defs/line"}},
+		"customer": {"oneOf": [{"const": "anonymous"}, {"type": "object", "required": ["email"], "properties": {"email": {"type": "string", "format": "email"}}}]}
+	},
+	"$defs": {
+		"line": {"type": "object", "required": ["sku"], "properties": {"sku": {"type": "string", "minLength": 3, "maxLength": 3}}}
+	}
+}`
+
+type fuzzedOrder struct {
+	ID      string   `json:"id"`
+	Note    *string  `json:"note"`
+	Qty     int      `json:"qty"`
+	Price   *float64 `json:"price"`
+	Status  string   `json:"status"`
+	Created *string  `json:"created"`
+	Lines   []struct {
+		SKU string `json:"sku"`
+	} `json:"lines"`
+	Customer json.RawMessage `json:"customer"`
+}
+
+func TestFuzzFromSchema(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/a", "").FuzzFromSchema(orderSchema, 42)
+	mock.Mock("/b", "").FuzzFromSchema(orderSchema, 42)
+
+	get := func(path string) []byte {
+		resp, err := http.Get(mock.URL() + path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		return body
+	}
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	bodies := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		body := get("/a")
+		assert.Equal(t, string(body), string(get("/b")), "same seed")
+		bodies[string(body)] = true
+
+		var o fuzzedOrder
+		if !assert.NoError(t, json.Unmarshal(body, &o)) {
+			continue
+		}
+		assert.Regexp(t, uuid, o.ID)
+		if o.Note != nil {
+			assert.LessOrEqual(t, len([]rune(*o.Note)), 5)
+		}
+		assert.Contains(t, []int{2, 4, 6, 8}, o.Qty)
+		if o.Price != nil {
+			assert.True(t, *o.Price >= 0 && *o.Price <= 1, *o.Price)
+		}
+		assert.Contains(t, []string{"open", "paid"}, o.Status)
+		if o.Created != nil {
+			_, err := time.Parse(time.RFC3339, *o.Created)
+			assert.NoError(t, err)
+		}
+		assert.True(t, len(o.Lines) >= 1 && len(o.Lines) <= 3)
+		for _, l := range o.Lines {
+			assert.Len(t, []rune(l.SKU), 3)
+		}
+		var email struct {
+			Email string `json:"email"`
+		}
+		if string(o.Customer) != `"anonymous"` && assert.NoError(t, json.Unmarshal(o.Customer, &email)) {
+			assert.Regexp(t, `^[a-z]+@[a-z]+\.example$`, email.Email)
+		}
+	}
+	assert.Greater(t, len(bodies), 40)
+}
+
+func TestFuzzFromSchemaInvalid(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	assert.Panics(t, func() { mock.Mock("/x", "").FuzzFromSchema("{", 1) })
+
+	mock.Mock("/ref", "").FuzzFromSchema(`{"$ref": "https://example.com/schema.json"}`, 1)
+	resp, err := http.Get(mock.URL() + "/ref")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
@@ -0,0 +1,122 @@
+package gohtmock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Gate blocks the requests answered by a mock until the test releases them.
+type Gate struct {
+	open    bool
+	credits int
+	waiters []chan struct{}
+	// changed is closed and replaced whenever waiters changes
+	changed chan struct{}
+	sync.Mutex
+}
+
+// Gate makes mr block every request until it is let through by the returned
+// Gate. Blocked requests are dropped if the client goes away.
+func (mr *mockResponse) Gate() *Gate {
+	g := &Gate{changed: make(chan struct{})}
+	mr.Lock()
+	mr.gate = g
+	mr.Unlock()
+	return g
+}
+
+// Release lets all blocked and future requests through.
+func (g *Gate) Release() {
+	g.Lock()
+	defer g.Unlock()
+	g.open = true
+	for _, w := range g.waiters {
+		close(w)
+	}
+	g.waiters = nil
+	g.notify()
+}
+
+// ReleaseOne lets the longest blocked request through, or the next request
+// if none is blocked.
+func (g *Gate) ReleaseOne() {
+	g.Lock()
+	defer g.Unlock()
+	if len(g.waiters) == 0 {
+		g.credits++
+		return
+	}
+	close(g.waiters[0])
+	g.waiters = g.waiters[1:]
+	g.notify()
+}
+
+// Waiting returns the number of blocked requests.
+func (g *Gate) Waiting() int {
+	g.Lock()
+	defer g.Unlock()
+	return len(g.waiters)
+}
+
+// AwaitWaiting waits until at least n requests are blocked. It returns false
+// if that does not happen within timeout.
+func (g *Gate) AwaitWaiting(n int, timeout time.Duration) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		g.Lock()
+		waiting, changed := len(g.waiters), g.changed
+		g.Unlock()
+		if waiting >= n {
+			return true
+		}
+		select {
+		case <-changed:
+		case <-deadline.C:
+			return false
+		}
+	}
+}
+
+// notify wakes AwaitWaiting. g must be locked.
+func (g *Gate) notify() {
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
+
+// wait blocks until the request is let through. It returns false if ctx is
+// done first.
+func (g *Gate) wait(ctx context.Context) bool {
+	g.Lock()
+	if g.open {
+		g.Unlock()
+		return true
+	}
+	if g.credits > 0 {
+		g.credits--
+		g.Unlock()
+		return true
+	}
+	w := make(chan struct{})
+	g.waiters = append(g.waiters, w)
+	g.notify()
+	g.Unlock()
+
+	select {
+	case <-w:
+		return true
+	case <-ctx.Done():
+		g.Lock()
+		defer g.Unlock()
+		for i, v := range g.waiters {
+			if v == w {
+				g.waiters = append(g.waiters[:i:i], g.waiters[i+1:]...)
+				g.notify()
+				return false
+			}
+		}
+		// released concurrently
+		return true
+	}
+}
@@ -0,0 +1,61 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGate(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	gate := mock.Mock("/slow", "{}").Gate()
+
+	done := make(chan int, 3)
+	get := func() {
+		resp, err := http.Get(mock.URL() + "/slow")
+		assert.NoError(t, err)
+		_, _ = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		done <- resp.StatusCode
+	}
+	go get()
+	go get()
+
+	assert.True(t, gate.AwaitWaiting(2, time.Second))
+	assert.Equal(t, 2, gate.Waiting())
+	select {
+	case <-done:
+		t.Fatal("request passed a closed gate")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	gate.ReleaseOne()
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.Equal(t, 1, gate.Waiting())
+
+	gate.Release()
+	assert.Equal(t, http.StatusOK, <-done)
+	go get()
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.False(t, gate.AwaitWaiting(1, 10*time.Millisecond))
+}
+
+func TestGateCredit(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	gate := mock.Mock("/slow", "{}").Gate()
+	gate.ReleaseOne()
+
+	resp, err := http.Get(mock.URL() + "/slow")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	_, err = client.Get(mock.URL() + "/slow")
+	assert.Error(t, err)
+	assert.Eventually(t, func() bool { return gate.Waiting() == 0 }, time.Second, 5*time.Millisecond)
+}
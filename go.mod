@@ -2,10 +2,17 @@ module github.com/fortnoxab/gohtmock
 
 go 1.18
 
-require github.com/stretchr/testify v1.8.0
+require (
+	github.com/fxamacker/cbor/v2 v2.5.0
+	github.com/stretchr/testify v1.8.0
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	google.golang.org/protobuf v1.33.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
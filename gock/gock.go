@@ -0,0 +1,321 @@
+// Package gock offers a gock style fluent API on top of a gohtmock.Mock to
+// ease migrating test suites off gock. Mocks registered through it are
+// ordinary gohtmock mocks so all gohtmock assertions keep working.
+//
+//	g := gock.Wrap(mock)
+//	g.New("/users").Get().Reply(200).JSON(users)
+package gock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fortnoxab/gohtmock"
+)
+
+type Gock struct {
+	mock     *gohtmock.Mock
+	requests []*Request
+	sync.Mutex
+}
+
+func Wrap(m *gohtmock.Mock) *Gock {
+	return &Gock{mock: m}
+}
+
+// New starts a mock definition. uri can be a path or a full URL; only its
+// path is used for matching.
+func (g *Gock) New(uri string) *Request {
+	path := uri
+	if u, err := url.Parse(uri); err == nil && u.Host != "" {
+		path = u.Path
+	}
+	return &Request{
+		gock:    g,
+		method:  http.MethodGet,
+		path:    path,
+		headers: make(map[string]*regexp.Regexp),
+		params:  make(map[string]*regexp.Regexp),
+		times:   1,
+	}
+}
+
+// IsDone reports whether all non persistent mocks have been matched as many times as expected.
+func (g *Gock) IsDone() bool {
+	return len(g.Pending()) == 0
+}
+
+// Pending returns the requests that still expect to be matched.
+func (g *Gock) Pending() []*Request {
+	g.Lock()
+	defer g.Unlock()
+	var pending []*Request
+	for _, r := range g.requests {
+		if !r.Done() {
+			pending = append(pending, r)
+		}
+	}
+	return pending
+}
+
+type Request struct {
+	gock     *Gock
+	method   string
+	path     string
+	headers  map[string]*regexp.Regexp
+	params   map[string]*regexp.Regexp
+	body     func([]byte) bool
+	times    int
+	persist  bool
+	matched  int
+	response *Response
+	sync.Mutex
+}
+
+func (r *Request) Get(path ...string) *Request    { return r.Method(http.MethodGet, path...) }
+func (r *Request) Post(path ...string) *Request   { return r.Method(http.MethodPost, path...) }
+func (r *Request) Put(path ...string) *Request    { return r.Method(http.MethodPut, path...) }
+func (r *Request) Patch(path ...string) *Request  { return r.Method(http.MethodPatch, path...) }
+func (r *Request) Delete(path ...string) *Request { return r.Method(http.MethodDelete, path...) }
+func (r *Request) Head(path ...string) *Request   { return r.Method(http.MethodHead, path...) }
+
+// Method sets the method and appends the optional path to the one given to New.
+func (r *Request) Method(method string, path ...string) *Request {
+	r.method = method
+	for _, p := range path {
+		r.path = strings.TrimSuffix(r.path, "/") + "/" + strings.TrimPrefix(p, "/")
+	}
+	return r
+}
+
+func (r *Request) Path(path string) *Request {
+	r.path = path
+	return r
+}
+
+// MatchHeader requires header key to match the regular expression value.
+func (r *Request) MatchHeader(key, value string) *Request {
+	r.headers[key] = regexp.MustCompile(value)
+	return r
+}
+
+// MatchParam requires query parameter key to match the regular expression value.
+func (r *Request) MatchParam(key, value string) *Request {
+	r.params[key] = regexp.MustCompile(value)
+	return r
+}
+
+func (r *Request) MatchType(kind string) *Request {
+	return r.MatchHeader("Content-Type", regexp.QuoteMeta(contentType(kind)))
+}
+
+func (r *Request) BodyString(body string) *Request {
+	r.body = func(b []byte) bool { return string(b) == body }
+	return r
+}
+
+// JSON requires the request body to be JSON semantically equal to v.
+func (r *Request) JSON(v any) *Request {
+	expected, err := normalizeJSON(v)
+	if err != nil {
+		panic(fmt.Sprintf("gock: invalid JSON matcher: %s", err))
+	}
+	r.body = func(b []byte) bool {
+		var actual any
+		if json.Unmarshal(b, &actual) != nil {
+			return false
+		}
+		return reflect.DeepEqual(actual, expected)
+	}
+	return r
+}
+
+// Times sets how many requests the mock answers, it defaults to 1.
+func (r *Request) Times(n int) *Request {
+	r.times = n
+	return r
+}
+
+// Persist makes the mock answer any number of requests.
+func (r *Request) Persist() *Request {
+	r.persist = true
+	return r
+}
+
+// Done reports whether the mock has been matched as many times as expected.
+func (r *Request) Done() bool {
+	r.Lock()
+	defer r.Unlock()
+	return !r.persist && r.matched >= r.times
+}
+
+// Reply registers the mock and returns its response for further configuration.
+func (r *Request) Reply(status int) *Response {
+	r.response = &Response{status: status, header: make(http.Header)}
+	mr := r.gock.mock.MockFunc(r.path, r.serve).SetMethod(r.method).Filter(r.match)
+	if !r.persist {
+		// the mock reserves the calls while matching, so concurrent
+		// requests can not exceed them
+		mr.Times(r.times)
+	}
+
+	r.gock.Lock()
+	r.gock.requests = append(r.gock.requests, r)
+	r.gock.Unlock()
+	return r.response
+}
+
+func (r *Request) match(req *http.Request) bool {
+	for key, re := range r.headers {
+		if !re.MatchString(req.Header.Get(key)) {
+			return false
+		}
+	}
+	query := req.URL.Query()
+	for key, re := range r.params {
+		if !re.MatchString(query.Get(key)) {
+			return false
+		}
+	}
+	if r.body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		req.Body = ioutil.NopCloser(bytes.NewReader(b))
+		if err != nil || !r.body(b) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Request) serve(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	r.matched++
+	r.Unlock()
+
+	resp := r.response
+	resp.Lock()
+	delay := resp.delay
+	status := resp.status
+	body := resp.body
+	// gock responses have no content type unless one is set
+	w.Header().Del("Content-Type")
+	for k, v := range resp.header {
+		w.Header()[k] = v
+	}
+	resp.Unlock()
+
+	time.Sleep(delay)
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+type Response struct {
+	status int
+	header http.Header
+	body   []byte
+	delay  time.Duration
+	sync.Mutex
+}
+
+func (r *Response) Status(code int) *Response {
+	r.Lock()
+	r.status = code
+	r.Unlock()
+	return r
+}
+
+func (r *Response) SetHeader(key, value string) *Response {
+	r.Lock()
+	r.header.Set(key, value)
+	r.Unlock()
+	return r
+}
+
+func (r *Response) AddHeader(key, value string) *Response {
+	r.Lock()
+	r.header.Add(key, value)
+	r.Unlock()
+	return r
+}
+
+func (r *Response) Type(kind string) *Response {
+	return r.SetHeader("Content-Type", contentType(kind))
+}
+
+func (r *Response) BodyString(body string) *Response {
+	r.Lock()
+	r.body = []byte(body)
+	r.Unlock()
+	return r
+}
+
+// JSON marshals v as the body, a string or []byte is used as is.
+func (r *Response) JSON(v any) *Response {
+	var body []byte
+	switch v := v.(type) {
+	case string:
+		body = []byte(v)
+	case []byte:
+		body = v
+	default:
+		var err error
+		body, err = json.Marshal(v)
+		if err != nil {
+			panic(fmt.Sprintf("gock: invalid JSON response: %s", err))
+		}
+	}
+	r.Lock()
+	r.body = body
+	r.header.Set("Content-Type", "application/json")
+	r.Unlock()
+	return r
+}
+
+func (r *Response) Delay(d time.Duration) *Response {
+	r.Lock()
+	r.delay = d
+	r.Unlock()
+	return r
+}
+
+func contentType(kind string) string {
+	switch kind {
+	case "json":
+		return "application/json"
+	case "xml":
+		return "application/xml"
+	case "html":
+		return "text/html"
+	case "text":
+		return "text/plain"
+	case "form":
+		return "application/x-www-form-urlencoded"
+	}
+	return kind
+}
+
+func normalizeJSON(v any) (any, error) {
+	var b []byte
+	switch v := v.(type) {
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	default:
+		var err error
+		if b, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	var out any
+	return out, json.Unmarshal(b, &out)
+}
@@ -0,0 +1,95 @@
+package gock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fortnoxab/gohtmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplyJSON(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	g := Wrap(mock)
+	g.New("/users").Get().Reply(200).JSON(map[string]string{"name": "foo"})
+	g.New("http://api.example.com").Post("/users").JSON(`{"name":"bar"}`).Reply(201).BodyString("created")
+
+	resp, err := http.Get(mock.URL() + "/users")
+	assert.NoError(t, err)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, `{"name":"foo"}`, string(body))
+
+	resp, err = http.Post(mock.URL()+"/users", "application/json", strings.NewReader(`{ "name": "bar" }`))
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+
+	assert.True(t, g.IsDone())
+	mock.AssertCallCount(t, "GET", "/users", 1)
+	mock.AssertCallCount(t, "POST", "/users", 1)
+}
+
+func TestTimesAndMatchers(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	g := Wrap(mock)
+	g.New("/items").MatchParam("page", "^[12]$").MatchHeader("Authorization", "^Bearer ").Times(2).Reply(200).BodyString("ok")
+
+	get := func(query string) int {
+		req, _ := http.NewRequest("GET", mock.URL()+"/items"+query, nil)
+		req.Header.Set("Authorization", "Bearer x")
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusNotFound, get("?page=3"))
+	assert.Equal(t, http.StatusOK, get("?page=1"))
+	assert.False(t, g.IsDone())
+	assert.Len(t, g.Pending(), 1)
+	assert.Equal(t, http.StatusOK, get("?page=2"))
+	assert.Equal(t, http.StatusNotFound, get("?page=2"))
+	assert.True(t, g.IsDone())
+}
+
+func TestTimesUnderConcurrency(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	g := Wrap(mock)
+	g.New("/items").Times(3).Reply(200).BodyString("ok")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	statuses := map[int]int{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Get(mock.URL() + "/items")
+			assert.NoError(t, err)
+			resp.Body.Close()
+			mu.Lock()
+			statuses[resp.StatusCode]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, map[int]int{http.StatusOK: 3, http.StatusNotFound: 7}, statuses)
+	assert.True(t, g.IsDone())
+}
+
+func TestNoDefaultContentType(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	g := Wrap(mock)
+	g.New("/plain").Reply(200).BodyString("ok")
+
+	resp, err := http.Get(mock.URL() + "/plain")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+}
@@ -0,0 +1,119 @@
+package gohtmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/fortnoxab/gohtmock/internal/graphql"
+)
+
+// GraphQLPath is where MockGraphQLSchema serves its schema.
+const GraphQLPath = "/graphql"
+
+// GraphQLMock answers GraphQL queries against a schema with generated data,
+// see MockGraphQLSchema.
+type GraphQLMock struct {
+	schema    *graphql.Schema
+	overrides map[string]func(args map[string]any) any
+	mr        *mockResponse
+	sync.Mutex
+}
+
+// MockGraphQLSchema serves a GraphQL backend for the schema sdl at
+// GraphQLPath. Queries, sent as POST with a JSON body or as GET with query
+// parameters, are answered with data generated from the types of the
+// selected fields: IDs and Ints count list elements from 1, Strings are
+// made from the field name, enums use their values in turn and lists have
+// two elements. Override replaces the value of single fields. It panics if
+// sdl is not a valid schema.
+func (m *Mock) MockGraphQLSchema(sdl string) *GraphQLMock {
+	schema, err := graphql.ParseSchema(sdl)
+	if err != nil {
+		panic(fmt.Sprintf("gohtmock: MockGraphQLSchema: %s", err))
+	}
+	g := &GraphQLMock{schema: schema, overrides: make(map[string]func(map[string]any) any)}
+	g.mr = m.MockFunc(GraphQLPath, g.serve).SetMethod("ANY")
+	return g
+}
+
+// Override sets the value of the field coordinate, such as "Query.user" or
+// "User.name". value may be a func(args map[string]any) any computing it
+// from the field arguments. Object values are maps or structs whose
+// missing fields are generated.
+func (g *GraphQLMock) Override(coordinate string, value any) *GraphQLMock {
+	fn, ok := value.(func(map[string]any) any)
+	if !ok {
+		fn = func(map[string]any) any { return value }
+	}
+	g.Lock()
+	g.overrides[coordinate] = fn
+	g.Unlock()
+	return g
+}
+
+// Mock returns the mock serving the schema, for assertions and further
+// configuration.
+func (g *GraphQLMock) Mock() *mockResponse {
+	return g.mr
+}
+
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+func (g *GraphQLMock) serve(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	if r.Method == http.MethodGet {
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if v := r.URL.Query().Get("variables"); v != "" {
+			_ = json.Unmarshal([]byte(v), &req.Variables)
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeGraphQL(w, http.StatusBadRequest, nil, fmt.Errorf("decoding request: %w", err))
+		return
+	}
+	if req.Variables == nil {
+		req.Variables = make(map[string]any)
+	}
+
+	doc, err := graphql.ParseQuery(req.Query, req.Variables)
+	if err != nil {
+		writeGraphQL(w, http.StatusBadRequest, nil, err)
+		return
+	}
+	e := &graphql.Executor{Schema: g.schema, Override: g.override, ListLength: 2}
+	data, err := e.Execute(doc, req.OperationName)
+	if err != nil {
+		writeGraphQL(w, http.StatusOK, nil, err)
+		return
+	}
+	writeGraphQL(w, http.StatusOK, data, nil)
+}
+
+func (g *GraphQLMock) override(typeName, field string, args map[string]any) (any, bool) {
+	g.Lock()
+	fn, ok := g.overrides[typeName+"."+field]
+	g.Unlock()
+	if !ok {
+		return nil, false
+	}
+	return fn(args), true
+}
+
+func writeGraphQL(w http.ResponseWriter, status int, data graphql.Object, err error) {
+	resp := graphql.Object{}
+	if err != nil {
+		resp = append(resp, graphql.Member{Key: "errors", Value: []map[string]string{{"message": err.Error()}}})
+	}
+	if data != nil {
+		resp = append(resp, graphql.Member{Key: "data", Value: data})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
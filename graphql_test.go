@@ -0,0 +1,94 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSchema = `
+"""A shop"""
+schema { query: Query mutation: Mutation }
+
+type Query {
+  user(id: ID!): User
+  users(first: Int = 10): [User!]!
+  search(term: String!): [SearchResult]
+}
+
+type Mutation {
+  createUser(input: NewUser!): User!
+}
+
+input NewUser { name: String! }
+
+interface Node { id: ID! }
+
+type User implements Node {
+  id: ID!
+  name: String
+  role: Role!
+  score: Float
+  active: Boolean
+  friends: [User]
+}
+
+type Product implements Node { id: ID! title: String }
+
+union SearchResult = Product | User
+
+enum Role { ADMIN MEMBER }
+`
+
+func TestMockGraphQLSchema(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	g := mock.MockGraphQLSchema(testSchema).
+		Override("Query.user", func(args map[string]any) any {
+			return map[string]any{"id": args["id"], "name": "Ada"}
+		}).
+		Override("Mutation.createUser", struct {
+			Name string `json:"name"`
+		}{"Grace"})
+
+	post := func(body string) string {
+		resp, err := http.Post(mock.URL()+GraphQLPath, "application/json", strings.NewReader(body))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		return strings.TrimSpace(string(b))
+	}
+
+	assert.Equal(t,
+		`{"data":{"user":{"id":"7","name":"Ada","role":"ADMIN","friends":[{"name":"name 1"},{"name":"name 2"}]}}}`,
+		post(`{"query":"query Get($id: ID!) { user(id: $id) { id name role friends { name } } }","variables":{"id":"7"}}`))
+
+	assert.Equal(t,
+		`{"data":{"people":[{"__typename":"User","id":"1","score":1.5,"active":true},{"__typename":"User","id":"2","score":2.5,"active":true}]}}`,
+		post(`{"query":"{ people: users { __typename ...F } } fragment F on User { id score active }"}`))
+
+	assert.Equal(t,
+		`{"data":{"search":[{"title":"title 1"},{"title":"title 2"}]}}`,
+		post(`{"query":"{ search(term: \"x\") { ... on Product { title } ... on User { name } } }"}`))
+
+	assert.Equal(t,
+		`{"data":{"createUser":{"name":"Grace","role":"ADMIN"}}}`,
+		post(`{"query":"mutation { createUser(input: {name: \"Grace\"}) { name role } }"}`))
+
+	assert.Equal(t,
+		`{"errors":[{"message":"cannot query field \"email\" on type \"User\""}]}`,
+		post(`{"query":"{ users { email } }"}`))
+
+	resp, err := http.Get(mock.URL() + GraphQLPath + "?query=" + url.QueryEscape("{ users @include(if: false) { id } }"))
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, `{"data":{}}`, strings.TrimSpace(string(body)))
+
+	assert.Len(t, g.Mock().Requests(), 6)
+	assert.Panics(t, func() { mock.MockGraphQLSchema("type User { id: ID }") })
+}
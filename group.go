@@ -0,0 +1,86 @@
+package gohtmock
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Group is a named set of mocks that must all be called, see Mock.Group.
+type Group struct {
+	name    string
+	mock    *Mock
+	members []*mockResponse
+	ordered bool
+	sync.Mutex
+}
+
+// Group returns a new expectation group called name.
+func (m *Mock) Group(name string) *Group {
+	return &Group{name: name, mock: m}
+}
+
+// Add makes mocks members of g. With InOrder they are expected to be first
+// called in the order they are added.
+func (g *Group) Add(mocks ...*mockResponse) *Group {
+	g.Lock()
+	g.members = append(g.members, mocks...)
+	g.Unlock()
+	return g
+}
+
+// InOrder makes Assert also require that the members were first called in
+// the order they were added.
+func (g *Group) InOrder() *Group {
+	g.Lock()
+	g.ordered = true
+	g.Unlock()
+	return g
+}
+
+// Assert fails tb once, naming all members that were never called and, for
+// an ordered group, the members called out of order.
+func (g *Group) Assert(tb testing.TB) {
+	if g.mock.assertionsDisabled(tb) {
+		return
+	}
+	g.Lock()
+	members := append([]*mockResponse(nil), g.members...)
+	ordered := g.ordered
+	g.Unlock()
+
+	var missing, outOfOrder []string
+	var previous *mockResponse
+	var previousSeq uint64
+	for _, mr := range members {
+		mr.Lock()
+		seq := mr.firstCallSeq
+		mr.Unlock()
+		if seq == 0 {
+			missing = append(missing, fmt.Sprintf("%s %s (%s)", mr.method, mr.path, mr.registeredAt))
+			continue
+		}
+		if ordered && previous != nil && seq < previousSeq {
+			outOfOrder = append(outOfOrder, fmt.Sprintf("%s %s called before %s %s", mr.method, mr.path, previous.method, previous.path))
+		}
+		previous, previousSeq = mr, seq
+	}
+
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, "never called: "+strings.Join(missing, ", "))
+	}
+	if len(outOfOrder) > 0 {
+		problems = append(problems, "out of order: "+strings.Join(outOfOrder, ", "))
+	}
+	if len(problems) > 0 {
+		tb.Errorf("group %q: %s", g.name, strings.Join(problems, "; "))
+	}
+}
+
+// AssertCallOrder asserts that mocks were all called, and first called in
+// the order given. It is a shorthand for an ordered Group.
+func (m *Mock) AssertCallOrder(tb testing.TB, mocks ...*mockResponse) {
+	m.Group("call order").Add(mocks...).InOrder().Assert(tb)
+}
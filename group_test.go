@@ -0,0 +1,62 @@
+package gohtmock
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	create := mock.Mock("/vm", "{}").SetMethod("POST")
+	disk := mock.Mock("/vm/disk", "{}").SetMethod("POST")
+	start := mock.Mock("/vm/start", "{}").SetMethod("POST")
+	g := mock.Group("provisioning flow").Add(create, disk, start)
+
+	post := func(path string) {
+		resp, err := http.Post(mock.URL()+path, "application/json", nil)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	post("/vm")
+	post("/vm/start")
+
+	rt := newRecordingT("TestGroup")
+	g.Assert(rt)
+	assert.Len(t, rt.Errors(), 1)
+	assert.True(t, strings.HasPrefix(rt.Errors()[0], `group "provisioning flow": never called: POST /vm/disk (group_test.go:`))
+
+	post("/vm/disk")
+	g.Assert(t)
+
+	g.InOrder()
+	rt = newRecordingT("TestGroup")
+	g.Assert(rt)
+	assert.Equal(t, []string{`group "provisioning flow": out of order: POST /vm/start called before POST /vm/disk`}, rt.Errors())
+}
+
+func TestAssertCallOrder(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	token := mock.Mock("POST /oauth/token", `{"access_token":"t"}`)
+	data := mock.Mock("/data", "{}")
+	unused := mock.Mock("/unused", "{}")
+
+	for _, r := range []struct{ method, path string }{{"POST", "/oauth/token"}, {"GET", "/data"}, {"POST", "/oauth/token"}} {
+		req, _ := http.NewRequest(r.method, mock.URL()+r.path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	mock.AssertCallOrder(t, token, data)
+	assert.EqualError(t, Check(func(tb testing.TB) { mock.AssertCallOrder(tb, data, token) }),
+		`group "call order": out of order: POST /oauth/token called before GET /data`)
+	err := Check(func(tb testing.TB) { mock.AssertCallOrder(tb, token, unused) })
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "never called: GET /unused")
+	}
+}
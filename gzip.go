@@ -0,0 +1,101 @@
+package gohtmock
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gzipCache holds the compressed form of a file served by MockFile.
+type gzipCache struct {
+	modTime time.Time
+	size    int64
+	data    []byte
+}
+
+// Gzip makes a MockFile or MockCSV response compress the body for clients
+// that send Accept-Encoding: gzip. The compressed form of a file is cached
+// across calls until the file changes, so fixtures can be stored
+// uncompressed.
+func (mr *mockResponse) Gzip() *mockResponse {
+	mr.Lock()
+	mr.gzip = true
+	mr.Unlock()
+	return mr
+}
+
+func (mr *mockResponse) gzipEnabled() bool {
+	mr.Lock()
+	defer mr.Unlock()
+	return mr.gzip
+}
+
+func (mr *mockResponse) serveGzip(w http.ResponseWriter, r *http.Request, f *os.File, info os.FileInfo) {
+	// the content type is sniffed from the uncompressed file, so before
+	// gzipped reads it
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", contentTypeOf(f))
+	}
+	data, err := mr.gzipped(f, info)
+	if err != nil {
+		mr.serveError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	http.ServeContent(w, r, "", info.ModTime(), bytes.NewReader(data))
+}
+
+func (mr *mockResponse) gzipped(f *os.File, info os.FileInfo) ([]byte, error) {
+	mr.Lock()
+	c := mr.gzipCache
+	mr.Unlock()
+	if c != nil && c.modTime.Equal(info.ModTime()) && c.size == info.Size() {
+		return c.data, nil
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.Copy(zw, f); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	mr.Lock()
+	mr.gzipCache = &gzipCache{modTime: info.ModTime(), size: info.Size(), data: buf.Bytes()}
+	mr.Unlock()
+	return buf.Bytes(), nil
+}
+
+// contentTypeOf detects the content type of f from its extension or, failing
+// that, its first bytes. f is rewound afterwards.
+func contentTypeOf(f *os.File) string {
+	if ctype := mime.TypeByExtension(filepath.Ext(f.Name())); ctype != "" {
+		return ctype
+	}
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	_, _ = f.Seek(0, io.SeekStart)
+	return http.DetectContentType(head[:n])
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(enc, ";")
+		if strings.TrimSpace(parts[0]) != "gzip" {
+			continue
+		}
+		for _, p := range parts[1:] {
+			if q := strings.TrimSpace(p); q == "q=0" || q == "q=0.0" || q == "q=0.00" || q == "q=0.000" {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
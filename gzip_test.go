@@ -0,0 +1,73 @@
+package gohtmock
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGzip(t *testing.T) {
+	content := strings.Repeat(`{"id":1,"name":"fixture"}`, 1000)
+	filename := filepath.Join(t.TempDir(), "fixture.json")
+	assert.NoError(t, os.WriteFile(filename, []byte(content), 0o644))
+
+	mock := New()
+	defer mock.Close()
+	mr := mock.MockFile("/fixture", filename).Gzip()
+
+	get := func(acceptEncoding string) (*http.Response, []byte) {
+		req, _ := http.NewRequest(http.MethodGet, mock.URL()+"/fixture", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		return resp, body
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, body := get("br, gzip")
+		assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		assert.Less(t, len(body), len(content))
+		zr, err := gzip.NewReader(strings.NewReader(string(body)))
+		assert.NoError(t, err)
+		plain, err := ioutil.ReadAll(zr)
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(plain))
+	}
+	assert.NotNil(t, mr.gzipCache)
+
+	resp, body := get("gzip;q=0, identity")
+	assert.Equal(t, "", resp.Header.Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", resp.Header.Get("Vary"))
+	assert.Equal(t, content, string(body))
+
+	resp, body = get("")
+	assert.Equal(t, "", resp.Header.Get("Content-Encoding"))
+	assert.Equal(t, content, string(body))
+}
+
+func TestGzipSniffsContentType(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "page")
+	assert.NoError(t, os.WriteFile(filename, []byte("<html><body>hello</body></html>"), 0o644))
+
+	mock := New()
+	defer mock.Close()
+	mock.MockFile("/page", filename).Gzip()
+
+	req, _ := http.NewRequest(http.MethodGet, mock.URL()+"/page", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+}
@@ -0,0 +1,67 @@
+package gohtmock
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+)
+
+// Registrar is implemented by Mock, Owned and Partition.
+type Registrar interface {
+	MockFunc(path string, fn http.HandlerFunc) *mockResponse
+}
+
+// Handle registers a typed JSON handler. The request body is decoded into a
+// Req which fn answers with a Resp that is encoded as the response body with
+// the returned status, 0 meaning 200. If decoding fails or fn returns an
+// error the client gets a 500 and the failure is reported to the owning test
+// when r is a mock.For(t) or a Partition, otherwise it is logged.
+func Handle[Req, Resp any](r Registrar, method, path string, fn func(Req) (Resp, int, error)) *mockResponse {
+	return HandleEncoded(r, JSON, method, path, fn)
+}
+
+// HandleEncoded is Handle with the bodies encoded by codec.
+func HandleEncoded[Req, Resp any](r Registrar, codec Codec, method, path string, fn func(Req) (Resp, int, error)) *mockResponse {
+	var mr *mockResponse
+	mr = r.MockFunc(path, func(w http.ResponseWriter, req *http.Request) {
+		fail := func(err error) {
+			msg := fmt.Sprintf("%s %s: %s%s", method, path, err, mr.ownedBy())
+			if !mr.fail(msg) {
+				log.Print("gohtmock: ", msg)
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, msg)
+		}
+
+		var in Req
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			fail(fmt.Errorf("reading request body: %w", err))
+			return
+		}
+		if len(body) > 0 {
+			if err := codec.Unmarshal(body, &in); err != nil {
+				fail(fmt.Errorf("decoding request body into %T: %w", in, err))
+				return
+			}
+		}
+
+		out, status, err := fn(in)
+		if err != nil {
+			fail(err)
+			return
+		}
+		b, err := codec.Marshal(out)
+		if err != nil {
+			fail(fmt.Errorf("encoding response %T: %w", out, err))
+			return
+		}
+		if status == 0 {
+			status = http.StatusOK
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write(b)
+	})
+	return mr.SetMethod(method).SetHeader("Content-Type", codec.ContentType())
+}
@@ -0,0 +1,57 @@
+package gohtmock
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type createUser struct {
+	Name string `json:"name"`
+}
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestHandle(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	Handle(mock, "POST", "/users", func(req createUser) (user, int, error) {
+		return user{ID: 1, Name: req.Name}, http.StatusCreated, nil
+	})
+
+	resp, err := http.Post(mock.URL()+"/users", "application/json", strings.NewReader(`{"name":"foo"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var u user
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&u))
+	assert.Equal(t, user{ID: 1, Name: "foo"}, u)
+	mock.AssertCallCount(t, "POST", "/users", 1)
+}
+
+func TestHandleErrors(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	owner := newRecordingT("TestOwner")
+	Handle(mock.For(owner), "POST", "/users", func(req createUser) (user, int, error) {
+		return user{}, 0, errors.New("name taken")
+	})
+
+	resp, err := http.Post(mock.URL()+"/users", "application/json", strings.NewReader(`{"name":"foo"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	resp, err = http.Post(mock.URL()+"/users", "application/json", strings.NewReader(`not json`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	errs := owner.Errors()
+	assert.Len(t, errs, 2)
+	assert.Equal(t, "POST /users: name taken (mocked by TestOwner)", errs[0])
+	assert.Contains(t, errs[1], "decoding request body into gohtmock.createUser")
+}
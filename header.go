@@ -0,0 +1,32 @@
+package gohtmock
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// MatchHeader makes mr only answer requests with a header key of value.
+// Requests without it fall through to the other mocks.
+func (mr *mockResponse) MatchHeader(key, value string) *mockResponse {
+	return mr.matchRequest(fmt.Sprintf("header %s: %s", key, value), func(r *http.Request) bool {
+		return containsString(r.Header.Values(key), value)
+	})
+}
+
+// MatchHeaderRegexp makes mr only answer requests with a header key whose
+// value matches pattern. It panics if pattern does not compile.
+func (mr *mockResponse) MatchHeaderRegexp(key, pattern string) *mockResponse {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		panic(fmt.Sprintf("gohtmock: MatchHeaderRegexp: %s", err))
+	}
+	return mr.matchRequest(fmt.Sprintf("header %s: /%s/", key, pattern), func(r *http.Request) bool {
+		for _, v := range r.Header.Values(key) {
+			if re.MatchString(v) {
+				return true
+			}
+		}
+		return false
+	})
+}
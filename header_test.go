@@ -0,0 +1,38 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchHeader(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/me", "admin").MatchHeader("Authorization", "Bearer admin")
+	mock.Mock("/me", "user").MatchHeaderRegexp("Authorization", `^Bearer \w+$`)
+
+	for auth, expected := range map[string]string{
+		"Bearer admin": "admin",
+		"Bearer anna":  "user",
+		"Basic YTpi":   "/me not found",
+		"":             "/me not found",
+	} {
+		r, _ := http.NewRequest("GET", mock.URL()+"/me", nil)
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(r)
+		if assert.NoError(t, err) {
+			body, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Equal(t, expected, string(body), auth)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", mock.URL()+"/me", nil)
+	assert.Contains(t, mock.Explain(r), `header Authorization: /^Bearer \w+$/ does not match`)
+	assert.Panics(t, func() { mock.Mock("/x", "").MatchHeaderRegexp("Accept", "(") })
+}
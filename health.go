@@ -0,0 +1,42 @@
+package gohtmock
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// EnableHealth serves a liveness/readiness probe on path. The probe answers
+// 200 while the mock is healthy and 503 after SetHealthy(false). Probe
+// requests are not counted as mock calls. It can be called several times to
+// serve the same state on e.g. both /healthz and /readyz.
+func (m *Mock) EnableHealth(path string) {
+	m.Lock()
+	m.healthPaths[path] = true
+	m.Unlock()
+}
+
+// SetHealthy toggles the state reported by the paths passed to EnableHealth.
+func (m *Mock) SetHealthy(healthy bool) {
+	m.Lock()
+	m.unhealthy = !healthy
+	m.Unlock()
+}
+
+func (m *Mock) serveHealth(w http.ResponseWriter, r *http.Request) bool {
+	m.Lock()
+	enabled := m.healthPaths[r.URL.Path]
+	unhealthy := m.unhealthy
+	m.Unlock()
+	if !enabled {
+		return false
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if unhealthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"status":"unavailable"}`)
+		return true
+	}
+	fmt.Fprint(w, `{"status":"ok"}`)
+	return true
+}
@@ -0,0 +1,38 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.EnableHealth("/healthz")
+	mock.EnableHealth("/readyz")
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		resp, err := http.Get(mock.URL() + path)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"status":"ok"}`, string(body))
+	}
+
+	mock.SetHealthy(false)
+	resp, err := http.Get(mock.URL() + "/healthz")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	mock.SetHealthy(true)
+	resp, err = http.Get(mock.URL() + "/readyz")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	mock.AssertNoMissingMocks(t)
+	mock.AssertCallCountAsserted(t)
+}
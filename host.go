@@ -0,0 +1,44 @@
+package gohtmock
+
+import (
+	"net/http"
+	"testing"
+)
+
+// HostMocks registers mocks that only answer requests for one host, see Host.
+type HostMocks struct {
+	mock *Mock
+	host string
+}
+
+// Host returns a value registering mocks that, like MockURL mocks, only
+// answer requests whose Host is host. Together with Client or Transport one
+// Mock can stand in for several APIs. Calls are counted per host, assert
+// them with the methods of HostMocks.
+func (m *Mock) Host(host string) *HostMocks {
+	return &HostMocks{mock: m, host: host}
+}
+
+func (h *HostMocks) Mock(path, resp string, callback ...func(*http.Request) int) *mockResponse {
+	mr := h.mock.newMockResponse(path, resp)
+	mr.callbacks = callback
+	mr.host = h.host
+	h.mock.add(mr)
+	return mr
+}
+
+func (h *HostMocks) MockFunc(path string, fn http.HandlerFunc) *mockResponse {
+	mr := h.mock.newMockResponse(path, "")
+	mr.handler = fn
+	mr.host = h.host
+	h.mock.add(mr)
+	return mr
+}
+
+func (h *HostMocks) AssertCallCount(tb testing.TB, method, path string, expected int) {
+	h.mock.AssertCallCount(tb, method, "//"+h.host+path, expected)
+}
+
+func (h *HostMocks) AssertStatusCount(tb testing.TB, method, path string, status, expected int) {
+	h.mock.AssertStatusCount(tb, method, "//"+h.host+path, status, expected)
+}
@@ -0,0 +1,40 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHost(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	github := mock.Host("api.github.com")
+	github.Mock("/v1/users", "github users")
+	gitlab := mock.Host("gitlab.example.com:8443")
+	gitlab.Mock("/v1/users", "gitlab users").WithStatus(201)
+
+	client := mock.Client()
+	for url, expected := range map[string]string{
+		"https://api.github.com/v1/users":          "github users",
+		"https://API.GITHUB.COM:443/v1/users":      "github users",
+		"https://gitlab.example.com:8443/v1/users": "gitlab users",
+		"https://gitlab.example.com/v1/users":      "/v1/users not found",
+		mock.URL() + "/v1/users":                   "/v1/users not found",
+	} {
+		resp, err := client.Get(url)
+		if assert.NoError(t, err, url) {
+			body, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Equal(t, expected, string(body), url)
+		}
+	}
+
+	github.AssertCallCount(t, "GET", "/v1/users", 2)
+	gitlab.AssertCallCount(t, "GET", "/v1/users", 1)
+	gitlab.AssertStatusCount(t, "GET", "/v1/users", 201, 1)
+	assert.EqualError(t, Check(func(tb testing.TB) { gitlab.AssertCallCount(tb, "GET", "/v1/users", 2) }),
+		"GET //gitlab.example.com:8443/v1/users called 1 times, expected 2")
+	mock.AssertCallCount(t, "GET", "https://api.github.com/v1/users", 2)
+}
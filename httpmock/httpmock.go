@@ -0,0 +1,265 @@
+// Package httpmock is a shim implementing the responder based API of
+// jarcoal/httpmock on top of a gohtmock.Mock, so suites can migrate
+// incrementally. Requests sent through the MockTransport are served
+// in-process by the Mock, which means responders registered here and mocks
+// registered directly on the Mock can be mixed freely and share assertions.
+package httpmock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/fortnoxab/gohtmock"
+)
+
+type Responder func(*http.Request) (*http.Response, error)
+
+type MockTransport struct {
+	mock      *gohtmock.Mock
+	callCount map[string]int
+	// responders are the mocks registered per "METHOD url", replaced when
+	// the same key is registered again
+	responders map[string]interface{ Remove() }
+	previous   http.RoundTripper
+	sync.Mutex
+}
+
+func New(m *gohtmock.Mock) *MockTransport {
+	return &MockTransport{mock: m, callCount: make(map[string]int), responders: make(map[string]interface{ Remove() })}
+}
+
+// Activate replaces http.DefaultTransport with t until Deactivate is called.
+func (t *MockTransport) Activate() {
+	t.Lock()
+	defer t.Unlock()
+	if t.previous == nil {
+		t.previous = http.DefaultTransport
+	}
+	http.DefaultTransport = t
+}
+
+func (t *MockTransport) ActivateNonDefault(client *http.Client) {
+	client.Transport = t
+}
+
+func (t *MockTransport) Deactivate() {
+	t.Lock()
+	defer t.Unlock()
+	if t.previous != nil {
+		http.DefaultTransport = t.previous
+		t.previous = nil
+	}
+}
+
+func (t *MockTransport) DeactivateAndReset() {
+	t.Deactivate()
+	t.ZeroCallCounters()
+}
+
+// RegisterResponder registers responder for method and url. An absolute url
+// is routed on its host and path, as by gohtmock's MockURL, a relative one on
+// its path only. If url has a query string the request must carry the same
+// query parameters. Registering the same method and url again replaces the
+// previous responder.
+func (t *MockTransport) RegisterResponder(method, rawURL string, responder Responder) {
+	if strings.HasPrefix(rawURL, "=~") {
+		panic("httpmock: regexp responders are not supported by the gohtmock shim")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		panic(fmt.Sprintf("httpmock: invalid url %q: %s", rawURL, err))
+	}
+	key := method + " " + rawURL
+	query := u.Query()
+
+	t.Lock()
+	t.callCount[key] = 0
+	previous := t.responders[key]
+	t.Unlock()
+	if previous != nil {
+		previous.Remove()
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		t.Lock()
+		t.callCount[key]++
+		t.Unlock()
+
+		resp, err := responder(r)
+		if err != nil {
+			if holder, ok := r.Context().Value(errorKey{}).(*error); ok {
+				*holder = err
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writeResponse(w, resp)
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	mockFunc := t.mock.MockFunc
+	if u.Host != "" {
+		mockFunc = t.mock.Host(u.Host).MockFunc
+	}
+	mr := mockFunc(path, handler).SetMethod(method)
+	if len(query) > 0 {
+		mr.Filter(func(r *http.Request) bool {
+			actual := r.URL.Query()
+			for k, v := range query {
+				if strings.Join(actual[k], ",") != strings.Join(v, ",") {
+					return false
+				}
+			}
+			return true
+		})
+	}
+	t.Lock()
+	t.responders[key] = mr
+	t.Unlock()
+}
+
+// GetCallCountInfo returns the number of calls per registered "METHOD url".
+func (t *MockTransport) GetCallCountInfo() map[string]int {
+	t.Lock()
+	defer t.Unlock()
+	info := make(map[string]int, len(t.callCount))
+	for k, v := range t.callCount {
+		info[k] = v
+	}
+	return info
+}
+
+func (t *MockTransport) GetTotalCallCount() int {
+	t.Lock()
+	defer t.Unlock()
+	total := 0
+	for _, v := range t.callCount {
+		total += v
+	}
+	return total
+}
+
+func (t *MockTransport) ZeroCallCounters() {
+	t.Lock()
+	defer t.Unlock()
+	for k := range t.callCount {
+		t.callCount[k] = 0
+	}
+}
+
+type errorKey struct{}
+
+// ErrNoResponderFound is returned by RoundTrip, wrapped, for requests that no
+// responder or mock answers, like httpmock does.
+var ErrNoResponderFound = errors.New("no responder found")
+
+// RoundTrip serves req in-process with the wrapped Mock. Errors returned by
+// responders are returned as is, like httpmock does.
+func (t *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var respErr error
+	var unmatched bool
+	ctx := context.WithValue(req.Context(), errorKey{}, &respErr)
+	req = req.WithContext(gohtmock.WithUnmatched(ctx, &unmatched))
+	if req.Body == nil {
+		req.Body = http.NoBody
+	}
+
+	rec := httptest.NewRecorder()
+	t.mock.ServeHTTP(rec, req)
+	if respErr != nil {
+		return nil, respErr
+	}
+	if unmatched {
+		return nil, fmt.Errorf("%w for %s %s", ErrNoResponderFound, req.Method, req.URL)
+	}
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+func writeResponse(w http.ResponseWriter, resp *http.Response) {
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.StatusCode)
+	if resp.Body != nil {
+		_, _ = io.Copy(w, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+func NewStringResponse(status int, body string) *http.Response {
+	return NewBytesResponse(status, []byte(body))
+}
+
+func NewBytesResponse(status int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Header:        http.Header{},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+}
+
+func NewJsonResponse(status int, body any) (*http.Response, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	resp := NewBytesResponse(status, b)
+	resp.Header.Set("Content-Type", "application/json")
+	return resp, nil
+}
+
+func NewStringResponder(status int, body string) Responder {
+	return func(*http.Request) (*http.Response, error) {
+		return NewStringResponse(status, body), nil
+	}
+}
+
+func NewBytesResponder(status int, body []byte) Responder {
+	return func(*http.Request) (*http.Response, error) {
+		return NewBytesResponse(status, body), nil
+	}
+}
+
+func NewJsonResponder(status int, body any) (Responder, error) {
+	resp, err := NewJsonResponse(status, body)
+	if err != nil {
+		return nil, err
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	return func(*http.Request) (*http.Response, error) {
+		resp := NewBytesResponse(status, b)
+		resp.Header.Set("Content-Type", "application/json")
+		return resp, nil
+	}, nil
+}
+
+func NewJsonResponderOrPanic(status int, body any) Responder {
+	responder, err := NewJsonResponder(status, body)
+	if err != nil {
+		panic(err)
+	}
+	return responder
+}
+
+// NewErrorResponder returns a responder making RoundTrip fail with err.
+func NewErrorResponder(err error) Responder {
+	return func(*http.Request) (*http.Response, error) {
+		return nil, err
+	}
+}
@@ -0,0 +1,123 @@
+package httpmock
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/fortnoxab/gohtmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterResponder(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	transport := New(mock)
+	client := &http.Client{}
+	transport.ActivateNonDefault(client)
+
+	transport.RegisterResponder("GET", "https://api.example.com/articles?page=2", NewStringResponder(200, "page 2"))
+	transport.RegisterResponder("POST", "https://api.example.com/articles", NewJsonResponderOrPanic(201, map[string]int{"id": 1}))
+	mock.Mock("/native", "mixed")
+
+	resp, err := client.Get("https://api.example.com/articles?page=2")
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "page 2", string(body))
+
+	resp, err = client.Post("https://api.example.com/articles", "application/json", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	resp, err = client.Get("https://other.example.com/native")
+	assert.NoError(t, err)
+	body, _ = ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "mixed", string(body))
+
+	assert.Equal(t, 2, transport.GetTotalCallCount())
+	assert.Equal(t, 1, transport.GetCallCountInfo()["GET https://api.example.com/articles?page=2"])
+	mock.AssertCallCount(t, "GET", "https://api.example.com/articles", 1)
+	mock.AssertCallCount(t, "GET", "/native", 1)
+}
+
+func TestErrorResponderAndActivate(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	transport := New(mock)
+	transport.Activate()
+	defer transport.DeactivateAndReset()
+
+	failure := errors.New("connection refused")
+	transport.RegisterResponder("GET", "/fail", NewErrorResponder(failure))
+
+	_, err := http.Get("http://example.com/fail")
+	assert.ErrorIs(t, err, failure)
+}
+
+func TestRegisterResponderReplaces(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	transport := New(mock)
+	client := &http.Client{}
+	transport.ActivateNonDefault(client)
+
+	transport.RegisterResponder("GET", "https://api.example.com/status", NewStringResponder(200, "up"))
+	transport.RegisterResponder("GET", "https://api.example.com/status", NewStringResponder(503, "down"))
+
+	resp, err := client.Get("https://api.example.com/status")
+	assert.NoError(t, err)
+	assert.Equal(t, 503, resp.StatusCode)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "down", string(body))
+	assert.Equal(t, 1, transport.GetTotalCallCount())
+}
+
+func TestRegisterResponderRoutesOnHost(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	transport := New(mock)
+	client := &http.Client{}
+	transport.ActivateNonDefault(client)
+
+	transport.RegisterResponder("GET", "https://a.example.com/x", NewStringResponder(200, "a"))
+	transport.RegisterResponder("GET", "https://b.example.com/x", NewStringResponder(200, "b"))
+
+	for _, host := range []string{"a", "b"} {
+		resp, err := client.Get("https://" + host + ".example.com/x")
+		if assert.NoError(t, err) {
+			body, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Equal(t, host, string(body))
+		}
+	}
+
+	_, err := client.Get("https://c.example.com/x")
+	assert.ErrorIs(t, err, ErrNoResponderFound)
+	assert.Contains(t, err.Error(), "no responder found for GET https://c.example.com/x")
+}
+
+func TestRawResponsesWithoutConnection(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	mock.Mock("/reason", "{}").WithStatus(599).WithReason("Network Connect Timeout")
+	mock.Mock("/length", "{}").WithWrongContentLength(10)
+	client := &http.Client{}
+	New(mock).ActivateNonDefault(client)
+
+	for _, path := range []string{"/reason", "/length"} {
+		resp, err := client.Get("http://example.com" + path)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "{}", string(body), path)
+	}
+	resp, err := client.Get("http://example.com/reason")
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, 599, resp.StatusCode)
+	}
+}
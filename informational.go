@@ -0,0 +1,38 @@
+package gohtmock
+
+import "net/http"
+
+// informational is a 1xx response sent before the final response.
+type informational struct {
+	status int
+	header http.Header
+}
+
+// EarlyHints makes mr send a 103 Early Hints response with header, such as
+// Link preload hints, before its final response. It can be called several
+// times to send several hints.
+func (mr *mockResponse) EarlyHints(header http.Header) *mockResponse {
+	return mr.Informational(http.StatusEarlyHints, header)
+}
+
+// Informational makes mr send an informational response with status, which
+// must be a 1xx other than 101, and header before its final response. They
+// are sent in the order added, after Barrier and Gate let the request
+// through and before WithDelay. Their headers are not part of the final
+// response. Sending them needs the net/http of Go 1.19 or later, with older
+// versions they are left out.
+func (mr *mockResponse) Informational(status int, header http.Header) *mockResponse {
+	if !isInformational(status) {
+		panic("gohtmock: informational status must be 1xx other than 101")
+	}
+	mr.Lock()
+	mr.informational = append(mr.informational, informational{status: status, header: header.Clone()})
+	mr.Unlock()
+	return mr
+}
+
+// isInformational reports if status is an interim response status, which
+// the writers of this package must not take as the final status.
+func isInformational(status int) bool {
+	return status >= 100 && status < 200 && status != http.StatusSwitchingProtocols
+}
@@ -0,0 +1,9 @@
+//go:build !go1.19
+
+package gohtmock
+
+import "net/http"
+
+// sendInformational leaves out the informational responses, the net/http of
+// Go 1.18 takes any status written as the final one.
+func sendInformational(w http.ResponseWriter, responses []informational) {}
@@ -0,0 +1,26 @@
+//go:build go1.19
+
+package gohtmock
+
+import "net/http"
+
+// sendInformational writes the informational responses to w, keeping the
+// headers of the final response.
+func sendInformational(w http.ResponseWriter, responses []informational) {
+	for _, resp := range responses {
+		final := w.Header().Clone()
+		for k := range w.Header() {
+			delete(w.Header(), k)
+		}
+		for k, v := range resp.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(resp.status)
+		for k := range w.Header() {
+			delete(w.Header(), k)
+		}
+		for k, v := range final {
+			w.Header()[k] = v
+		}
+	}
+}
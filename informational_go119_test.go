@@ -0,0 +1,48 @@
+//go:build go1.19
+
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEarlyHints(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	hints := http.Header{"Link": {"</style.css>; rel=preload; as=style"}}
+	mr := mock.Mock("/page", "<html>").SetHeader("Content-Type", "text/html").EarlyHints(hints)
+	mock.Mock("/raw", "{}").EarlyHints(hints).WithReason("Fine")
+
+	for _, path := range []string{"/page", "/raw"} {
+		var interim []http.Header
+		trace := &httptrace.ClientTrace{
+			Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+				assert.Equal(t, http.StatusEarlyHints, code)
+				interim = append(interim, http.Header(header))
+				return nil
+			},
+		}
+		req, err := http.NewRequest("GET", mock.URL()+path, nil)
+		assert.NoError(t, err)
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Empty(t, resp.Header.Get("Link"), path)
+		assert.NotEmpty(t, body, path)
+		if assert.Len(t, interim, 1, path) {
+			assert.Equal(t, "</style.css>; rel=preload; as=style", interim[0].Get("Link"))
+			assert.Empty(t, interim[0].Get("Content-Type"))
+		}
+	}
+	assert.Equal(t, []SentStatus{{Code: 200, Reason: "OK"}}, mr.SentStatuses())
+}
@@ -0,0 +1,16 @@
+package gohtmock
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInformationalRejectsFinalStatus(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	assert.Panics(t, func() {
+		mock.Mock("/x", "{}").Informational(http.StatusOK, nil)
+	})
+}
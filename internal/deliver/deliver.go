@@ -0,0 +1,54 @@
+// Package deliver runs the background deliveries of presets, such as
+// webhook posts sent after a response, so that tests can wait for them.
+package deliver
+
+import "sync"
+
+// Queue runs deliveries in their own goroutines and keeps their errors.
+// The zero value is ready to use.
+type Queue struct {
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	closed bool
+	errs   []error
+}
+
+// Go runs fn in the background unless the queue is closed, in which case
+// fn is dropped and Go returns false.
+func (q *Queue) Go(fn func() error) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return false
+	}
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		if err := fn(); err != nil {
+			q.mu.Lock()
+			q.errs = append(q.errs, err)
+			q.mu.Unlock()
+		}
+	}()
+	return true
+}
+
+// Wait blocks until all deliveries started so far have finished.
+func (q *Queue) Wait() {
+	q.wg.Wait()
+}
+
+// Close stops accepting deliveries and waits for the running ones.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.wg.Wait()
+}
+
+// Errors returns the errors of the finished deliveries.
+func (q *Queue) Errors() []error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]error(nil), q.errs...)
+}
@@ -0,0 +1,282 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Member is a key of an Object.
+type Member struct {
+	Key   string
+	Value any
+}
+
+// Object is a JSON object keeping the order of its members, which GraphQL
+// responses must have.
+type Object []Member
+
+func (o Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(m.Key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Executor executes queries against generated data.
+type Executor struct {
+	Schema *Schema
+	// Override returns the value of the field of typeName called field
+	// for args, if it is overridden. Object values are maps from field
+	// names to values; fields missing from them are generated.
+	Override func(typeName, field string, args map[string]any) (any, bool)
+	// ListLength is the number of generated list elements.
+	ListLength int
+
+	doc *Document
+}
+
+// Execute runs the operation called operationName of doc, the only one if
+// operationName is empty.
+func (e *Executor) Execute(doc *Document, operationName string) (Object, error) {
+	var op *Operation
+	for _, o := range doc.Operations {
+		if o.Name == operationName || operationName == "" {
+			op = o
+			break
+		}
+	}
+	if op == nil {
+		return nil, fmt.Errorf("unknown operation %q", operationName)
+	}
+	if operationName == "" && len(doc.Operations) > 1 {
+		return nil, fmt.Errorf("operationName is required for documents with several operations")
+	}
+	root := map[string]string{"query": e.Schema.Query, "mutation": e.Schema.Mutation, "subscription": e.Schema.Subscription}[op.Type]
+	if root == "" {
+		return nil, fmt.Errorf("schema does not support %s", op.Type)
+	}
+	e.doc = doc
+	return e.object(e.Schema.Types[root], op.Selections, nil, 0)
+}
+
+func (e *Executor) object(t *Type, sels []*Selection, source map[string]any, index int) (Object, error) {
+	obj := Object{}
+	for _, sel := range e.collect(t, sels) {
+		if sel.Name == "__typename" {
+			obj = append(obj, Member{sel.Alias, t.Name})
+			continue
+		}
+		f := t.Field(sel.Name)
+		if f == nil {
+			return nil, fmt.Errorf("cannot query field %q on type %q", sel.Name, t.Name)
+		}
+		v, has := source[sel.Name]
+		if !has && e.Override != nil {
+			if v, has = e.Override(t.Name, sel.Name, sel.Arguments); has {
+				v = normalize(v)
+			}
+		}
+		value, err := e.complete(f.Type, v, has, sel, index)
+		if err != nil {
+			return nil, err
+		}
+		obj = append(obj, Member{sel.Alias, value})
+	}
+	return obj, nil
+}
+
+func (e *Executor) complete(ref *TypeRef, v any, has bool, sel *Selection, index int) (any, error) {
+	if has && v == nil {
+		return nil, nil
+	}
+	if ref.Elem != nil {
+		var items []any
+		if has {
+			list, ok := v.([]any)
+			if !ok {
+				return nil, fmt.Errorf("value of %s is not a list", sel.Name)
+			}
+			items = list
+		}
+		n := len(items)
+		if !has {
+			n = e.ListLength
+		}
+		out := make([]any, n)
+		for i := range out {
+			var item any
+			if has {
+				item = items[i]
+			}
+			var err error
+			if out[i], err = e.complete(ref.Elem, item, has, sel, i); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+
+	t := e.Schema.Types[ref.Name]
+	switch t.Kind {
+	case KindScalar, KindEnum:
+		if has {
+			return v, nil
+		}
+		return generate(t, sel.Name, index), nil
+	}
+	if len(sel.Selections) == 0 {
+		return nil, fmt.Errorf("field %q of type %q must have a selection of subfields", sel.Name, t.Name)
+	}
+	source, _ := v.(map[string]any)
+	if has && source == nil {
+		return nil, fmt.Errorf("value of %s is not an object", sel.Name)
+	}
+	if t.Kind == KindInterface || t.Kind == KindUnion {
+		t = e.concrete(t, source)
+		if t == nil {
+			return nil, nil
+		}
+	}
+	return e.object(t, sel.Selections, source, index)
+}
+
+// concrete picks the object type of a value of the abstract type t: the one
+// named by its __typename or else the first possible type by name.
+func (e *Executor) concrete(t *Type, source map[string]any) *Type {
+	if name, ok := source["__typename"].(string); ok && e.Schema.Types[name] != nil {
+		return e.Schema.Types[name]
+	}
+	possible := e.possibleTypes(t)
+	if len(possible) == 0 {
+		return nil
+	}
+	return e.Schema.Types[possible[0]]
+}
+
+func (e *Executor) possibleTypes(t *Type) []string {
+	if t.Kind == KindUnion {
+		return t.Members
+	}
+	var names []string
+	for _, o := range e.Schema.Types {
+		if o.Kind == KindObject && containsString(o.Interfaces, t.Name) {
+			names = append(names, o.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// collect flattens the fragments in sels that apply to t.
+func (e *Executor) collect(t *Type, sels []*Selection) []*Selection {
+	var fields []*Selection
+	for _, sel := range sels {
+		if sel.Skip {
+			continue
+		}
+		var frag *Fragment
+		switch {
+		case sel.FragmentSpread != "":
+			frag = e.doc.Fragments[sel.FragmentSpread]
+			if frag == nil {
+				continue
+			}
+		case sel.Fragment != nil:
+			frag = sel.Fragment
+		default:
+			fields = append(fields, sel)
+			continue
+		}
+		if e.applies(frag.TypeCondition, t) {
+			fields = append(fields, e.collect(t, frag.Selections)...)
+		}
+	}
+	return fields
+}
+
+func (e *Executor) applies(condition string, t *Type) bool {
+	if condition == "" || condition == t.Name || containsString(t.Interfaces, condition) {
+		return true
+	}
+	if c := e.Schema.Types[condition]; c != nil && c.Kind == KindUnion {
+		return containsString(c.Members, t.Name)
+	}
+	return false
+}
+
+// generate returns a value of the scalar or enum t for the index'th element
+// of a field called field.
+func generate(t *Type, field string, index int) any {
+	switch t.Name {
+	case "ID":
+		return strconv.Itoa(index + 1)
+	case "String":
+		return fmt.Sprintf("%s %d", field, index+1)
+	case "Int":
+		return index + 1
+	case "Float":
+		return float64(index+1) + 0.5
+	case "Boolean":
+		return true
+	}
+	if t.Kind == KindEnum && len(t.Values) > 0 {
+		return t.Values[index%len(t.Values)]
+	}
+	return fmt.Sprintf("%s %d", t.Name, index+1)
+}
+
+// normalize turns v into the maps, slices and scalars encoding/json decodes
+// to, so overrides may use structs. Maps and slices are copied, not
+// modified, since overrides may return shared values.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case nil, string, bool, int, int64, float64:
+		return v
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = normalize(e)
+		}
+		return m
+	case []any:
+		l := make([]any, len(v))
+		for i, e := range v {
+			l[i] = normalize(e)
+		}
+		return l
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package graphql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSchemaErrors(t *testing.T) {
+	for sdl, msg := range map[string]string{
+		"type Query { a: Missing }":                                      "Query.a has unknown type Missing",
+		"type User { id: ID }":                                           "schema has no query type",
+		"type Query { a: String ":                                        `unexpected end of input at 23`,
+		"type Query { a: \"String }":                                     "unterminated string at 16",
+		"union U = A type Query {}":                                      "union U has unknown member A",
+		"type Query { a: [String }":                                      `unexpected "}" at 24`,
+		"directive @x on FIELD | 1":                                      `unexpected "1" at 24`,
+		"type Query { a(b: Int = 1): Int } extend type Query { c: Int }": "",
+	} {
+		_, err := ParseSchema(sdl)
+		if msg == "" {
+			assert.NoError(t, err, sdl)
+		} else if assert.Error(t, err, sdl) {
+			assert.Equal(t, msg, err.Error(), sdl)
+		}
+	}
+}
+
+func TestExecuteDefaults(t *testing.T) {
+	s, err := ParseSchema(`type Query { items(first: Int = 3): [Int] } extend type Query { name: String }`)
+	assert.NoError(t, err)
+	vars := map[string]any{}
+	doc, err := ParseQuery(`query Q($n: Int = 5) { a: items(first: $n) b: name }`, vars)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"n": int64(5)}, vars)
+	e := &Executor{Schema: s, ListLength: 1, Override: func(typeName, field string, args map[string]any) (any, bool) {
+		if field == "items" {
+			return []int{int(args["first"].(int64))}, true
+		}
+		return nil, false
+	}}
+	data, err := e.Execute(doc, "Q")
+	assert.NoError(t, err)
+	b, _ := json.Marshal(data)
+	assert.Equal(t, `{"a":[5],"b":"name 1"}`, string(b))
+
+	_, err = e.Execute(doc, "Other")
+	assert.EqualError(t, err, `unknown operation "Other"`)
+}
+
+func TestParseQueryRejectsFragmentCycles(t *testing.T) {
+	for query, msg := range map[string]string{
+		`{ ...A } fragment A on Query { ...A }`:                                      `fragment "A" spreads itself`,
+		`{ ...A } fragment A on Query { user { ...B } } fragment B on User { ...A }`: `spreads itself`,
+		`{ ...A } fragment A on Query { ... on Query { ...A } }`:                     `fragment "A" spreads itself`,
+		`{ ...A ...B } fragment A on Query { ...B } fragment B on Query { a }`:       "",
+	} {
+		_, err := ParseQuery(query, nil)
+		if msg == "" {
+			assert.NoError(t, err, query)
+		} else if assert.Error(t, err, query) {
+			assert.Contains(t, err.Error(), msg, query)
+		}
+	}
+}
+
+func TestOverridesAreNotModified(t *testing.T) {
+	s, err := ParseSchema(`type Query { user: User } type User { tags: [Tag] } type Tag { name: String }`)
+	assert.NoError(t, err)
+	type tag struct {
+		Name string `json:"name"`
+	}
+	user := map[string]any{"tags": []any{tag{"a"}}}
+	e := &Executor{Schema: s, ListLength: 1, Override: func(typeName, field string, args map[string]any) (any, bool) {
+		return user, field == "user"
+	}}
+	doc, err := ParseQuery(`{ user { tags { name } } }`, nil)
+	assert.NoError(t, err)
+	data, err := e.Execute(doc, "")
+	assert.NoError(t, err)
+	b, _ := json.Marshal(data)
+	assert.Equal(t, `{"user":{"tags":[{"name":"a"}]}}`, string(b))
+	assert.Equal(t, tag{"a"}, user["tags"].([]any)[0])
+}
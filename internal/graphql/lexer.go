@@ -0,0 +1,195 @@
+// Package graphql parses GraphQL schemas and queries and executes queries
+// against generated data. It supports the subset of GraphQL needed to mock
+// a backend: object, interface, union, enum, input and scalar types,
+// operations with variables, aliases, arguments and fragments.
+package graphql
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of input"
+	}
+	return fmt.Sprintf("%q", t.value)
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, token{tokenPunct, "...", i})
+			i += 3
+		case strings.ContainsRune("!$&():=@[]{}|", rune(c)):
+			tokens = append(tokens, token{tokenPunct, string(c), i})
+			i++
+		case c == '_' || isLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokenName, src[start:i], start})
+		case c == '-' || isDigit(c):
+			start := i
+			i++
+			kind := tokenInt
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.' || src[i] == 'e' || src[i] == 'E' ||
+				((src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E'))) {
+				if !isDigit(src[i]) {
+					kind = tokenFloat
+				}
+				i++
+			}
+			tokens = append(tokens, token{kind, src[start:i], start})
+		case strings.HasPrefix(src[i:], `"""`):
+			end := strings.Index(src[i+3:], `"""`)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated block string at %d", i)
+			}
+			tokens = append(tokens, token{tokenString, blockString(src[i+3 : i+3+end]), i})
+			i += end + 6
+		case c == '"':
+			start := i
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(src) || src[i] == '\n' {
+					return nil, fmt.Errorf("unterminated string at %d", start)
+				}
+				if src[i] == '"' {
+					i++
+					break
+				}
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+					switch src[i] {
+					case 'n':
+						b.WriteByte('\n')
+					case 't':
+						b.WriteByte('\t')
+					case 'r':
+						b.WriteByte('\r')
+					case 'b':
+						b.WriteByte('\b')
+					case 'f':
+						b.WriteByte('\f')
+					case 'u':
+						if i+4 < len(src) {
+							var r rune
+							fmt.Sscanf(src[i+1:i+5], "%04x", &r)
+							b.WriteRune(r)
+							i += 4
+						}
+					default:
+						b.WriteByte(src[i])
+					}
+					i++
+					continue
+				}
+				b.WriteByte(src[i])
+				i++
+			}
+			tokens = append(tokens, token{tokenString, b.String(), start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+func blockString(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, `\"""`, `"""`), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parser is a cursor over tokens shared by the schema and query parsers.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) is(kind tokenKind, value string) bool {
+	t := p.peek()
+	return t.kind == kind && t.value == value
+}
+
+// skip consumes the punctuator value if it is next and reports if it was.
+func (p *parser) skip(value string) bool {
+	if p.is(tokenPunct, value) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(value string) error {
+	if !p.skip(value) {
+		return p.unexpected()
+	}
+	return nil
+}
+
+func (p *parser) name() (string, error) {
+	t := p.peek()
+	if t.kind != tokenName {
+		return "", p.unexpected()
+	}
+	p.pos++
+	return t.value, nil
+}
+
+func (p *parser) unexpected() error {
+	t := p.peek()
+	return fmt.Errorf("unexpected %s at %d", t, t.pos)
+}
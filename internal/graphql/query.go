@@ -0,0 +1,345 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Selection is a field, fragment spread or inline fragment.
+type Selection struct {
+	// Field
+	Alias     string
+	Name      string
+	Arguments map[string]any
+	// FragmentSpread names a fragment, Fragment is an inline fragment
+	FragmentSpread string
+	Fragment       *Fragment
+	Selections     []*Selection
+	// Skip is set by @skip(if: true) and @include(if: false)
+	Skip bool
+}
+
+// Fragment is a named or inline fragment.
+type Fragment struct {
+	TypeCondition string
+	Selections    []*Selection
+}
+
+// Operation is a query, mutation or subscription.
+type Operation struct {
+	Type       string
+	Name       string
+	Selections []*Selection
+}
+
+// Document is a parsed query document.
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+// ParseQuery parses a query document, resolving $variables from variables.
+func ParseQuery(query string, variables map[string]any) (*Document, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	doc := &Document{Fragments: make(map[string]*Fragment)}
+	for p.peek().kind != tokenEOF {
+		if p.is(tokenName, "fragment") {
+			p.next()
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if !p.is(tokenName, "on") {
+				return nil, p.unexpected()
+			}
+			p.next()
+			cond, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.directives(); err != nil {
+				return nil, err
+			}
+			sels, err := p.selectionSet(variables)
+			if err != nil {
+				return nil, err
+			}
+			doc.Fragments[name] = &Fragment{TypeCondition: cond, Selections: sels}
+			continue
+		}
+		op := &Operation{Type: "query"}
+		if !p.is(tokenPunct, "{") {
+			typ, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if typ != "query" && typ != "mutation" && typ != "subscription" {
+				return nil, fmt.Errorf("unexpected %q at %d", typ, p.tokens[p.pos-1].pos)
+			}
+			op.Type = typ
+			if p.peek().kind == tokenName {
+				op.Name = p.next().value
+			}
+			if p.skip("(") {
+				for !p.skip(")") {
+					if err := p.variableDefinition(variables); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if err := p.directives(); err != nil {
+				return nil, err
+			}
+		}
+		if op.Selections, err = p.selectionSet(variables); err != nil {
+			return nil, err
+		}
+		doc.Operations = append(doc.Operations, op)
+	}
+	if len(doc.Operations) == 0 {
+		return nil, fmt.Errorf("document has no operation")
+	}
+	for name := range doc.Fragments {
+		if err := doc.checkCycles(name, map[string]bool{}); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// checkCycles reports if the fragment called name spreads itself, directly
+// or through the fragments in visiting.
+func (doc *Document) checkCycles(name string, visiting map[string]bool) error {
+	frag := doc.Fragments[name]
+	if frag == nil {
+		return nil
+	}
+	if visiting[name] {
+		return fmt.Errorf("fragment %q spreads itself", name)
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+	return doc.checkSpreads(frag.Selections, visiting)
+}
+
+func (doc *Document) checkSpreads(sels []*Selection, visiting map[string]bool) error {
+	for _, sel := range sels {
+		var err error
+		switch {
+		case sel.FragmentSpread != "":
+			err = doc.checkCycles(sel.FragmentSpread, visiting)
+		case sel.Fragment != nil:
+			err = doc.checkSpreads(sel.Fragment.Selections, visiting)
+		default:
+			err = doc.checkSpreads(sel.Selections, visiting)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// variableDefinition parses $name: Type = default, adding the default to
+// variables if the variable is not set.
+func (p *parser) variableDefinition(variables map[string]any) error {
+	if err := p.expect("$"); err != nil {
+		return err
+	}
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	if err := p.expect(":"); err != nil {
+		return err
+	}
+	if _, err := p.typeRef(); err != nil {
+		return err
+	}
+	if p.skip("=") {
+		v, err := p.value(variables)
+		if err != nil {
+			return err
+		}
+		if _, ok := variables[name]; !ok && variables != nil {
+			variables[name] = v
+		}
+	}
+	return p.directives()
+}
+
+func (p *parser) selectionSet(variables map[string]any) ([]*Selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var sels []*Selection
+	for !p.skip("}") {
+		sel, err := p.selection(variables)
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	return sels, nil
+}
+
+func (p *parser) selection(variables map[string]any) (*Selection, error) {
+	if p.skip("...") {
+		sel := &Selection{}
+		if p.peek().kind == tokenName && p.peek().value != "on" {
+			sel.FragmentSpread = p.next().value
+			skip, err := p.queryDirectives(variables)
+			sel.Skip = skip
+			return sel, err
+		}
+		frag := &Fragment{}
+		if p.is(tokenName, "on") {
+			p.next()
+			cond, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			frag.TypeCondition = cond
+		}
+		skip, err := p.queryDirectives(variables)
+		if err != nil {
+			return nil, err
+		}
+		sel.Skip = skip
+		if frag.Selections, err = p.selectionSet(variables); err != nil {
+			return nil, err
+		}
+		sel.Fragment = frag
+		return sel, nil
+	}
+
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	sel := &Selection{Alias: name, Name: name}
+	if p.skip(":") {
+		if sel.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if sel.Arguments, err = p.arguments(variables); err != nil {
+		return nil, err
+	}
+	if sel.Skip, err = p.queryDirectives(variables); err != nil {
+		return nil, err
+	}
+	if p.is(tokenPunct, "{") {
+		if sel.Selections, err = p.selectionSet(variables); err != nil {
+			return nil, err
+		}
+	}
+	return sel, nil
+}
+
+// queryDirectives parses directives, reporting if @skip or @include
+// exclude the selection.
+func (p *parser) queryDirectives(variables map[string]any) (bool, error) {
+	skip := false
+	for p.skip("@") {
+		name, err := p.name()
+		if err != nil {
+			return false, err
+		}
+		args, err := p.arguments(variables)
+		if err != nil {
+			return false, err
+		}
+		cond, _ := args["if"].(bool)
+		switch name {
+		case "skip":
+			skip = skip || cond
+		case "include":
+			skip = skip || !cond
+		}
+	}
+	return skip, nil
+}
+
+func (p *parser) arguments(variables map[string]any) (map[string]any, error) {
+	args := make(map[string]any)
+	if !p.skip("(") {
+		return args, nil
+	}
+	for !p.skip(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if args[name], err = p.value(variables); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+// value parses a value, replacing variables by their value.
+func (p *parser) value(variables map[string]any) (any, error) {
+	if p.skip("$") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		return variables[name], nil
+	}
+	if p.skip("[") {
+		list := []any{}
+		for !p.skip("]") {
+			v, err := p.value(variables)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	}
+	if p.skip("{") {
+		obj := map[string]any{}
+		for !p.skip("}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if obj[name], err = p.value(variables); err != nil {
+				return nil, err
+			}
+		}
+		return obj, nil
+	}
+	t := p.next()
+	switch t.kind {
+	case tokenInt:
+		return strconv.ParseInt(t.value, 10, 64)
+	case tokenFloat:
+		return strconv.ParseFloat(t.value, 64)
+	case tokenString:
+		return t.value, nil
+	case tokenName:
+		switch t.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return t.value, nil // enum value
+	}
+	p.pos--
+	return nil, p.unexpected()
+}
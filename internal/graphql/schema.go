@@ -0,0 +1,387 @@
+package graphql
+
+import "fmt"
+
+// Kind is the kind of a named type.
+type Kind int
+
+const (
+	KindScalar Kind = iota
+	KindObject
+	KindInterface
+	KindUnion
+	KindEnum
+	KindInput
+)
+
+// TypeRef refers to a type in a field or argument definition.
+type TypeRef struct {
+	Name    string
+	Elem    *TypeRef
+	NonNull bool
+}
+
+func (t *TypeRef) String() string {
+	s := t.Name
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	}
+	if t.NonNull {
+		s += "!"
+	}
+	return s
+}
+
+// Field is a field of an object, interface or input type.
+type Field struct {
+	Name string
+	Type *TypeRef
+}
+
+// Type is a named type of a schema.
+type Type struct {
+	Name       string
+	Kind       Kind
+	Fields     []*Field
+	Values     []string // enum values
+	Members    []string // union members
+	Interfaces []string
+}
+
+// Field returns the field called name, nil if there is none.
+func (t *Type) Field(name string) *Field {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Schema is a parsed GraphQL schema.
+type Schema struct {
+	Types        map[string]*Type
+	Query        string
+	Mutation     string
+	Subscription string
+}
+
+var builtinScalars = []string{"String", "Int", "Float", "Boolean", "ID"}
+
+// ParseSchema parses a schema in the GraphQL schema definition language.
+func ParseSchema(sdl string) (*Schema, error) {
+	tokens, err := lex(sdl)
+	if err != nil {
+		return nil, err
+	}
+	s := &Schema{Types: make(map[string]*Type)}
+	for _, name := range builtinScalars {
+		s.Types[name] = &Type{Name: name, Kind: KindScalar}
+	}
+	p := &parser{tokens: tokens}
+	for p.peek().kind != tokenEOF {
+		if err := p.definition(s); err != nil {
+			return nil, err
+		}
+	}
+	if s.Query == "" && s.Types["Query"] != nil {
+		s.Query = "Query"
+	}
+	if s.Mutation == "" && s.Types["Mutation"] != nil {
+		s.Mutation = "Mutation"
+	}
+	if s.Subscription == "" && s.Types["Subscription"] != nil {
+		s.Subscription = "Subscription"
+	}
+	if s.Query == "" {
+		return nil, fmt.Errorf("schema has no query type")
+	}
+	for _, t := range s.Types {
+		for _, f := range t.Fields {
+			if s.Types[f.Type.named()] == nil {
+				return nil, fmt.Errorf("%s.%s has unknown type %s", t.Name, f.Name, f.Type.named())
+			}
+		}
+		for _, m := range t.Members {
+			if s.Types[m] == nil {
+				return nil, fmt.Errorf("union %s has unknown member %s", t.Name, m)
+			}
+		}
+	}
+	return s, nil
+}
+
+// named returns the name of the named type t wraps.
+func (t *TypeRef) named() string {
+	for t.Elem != nil {
+		t = t.Elem
+	}
+	return t.Name
+}
+
+func (p *parser) definition(s *Schema) error {
+	if p.peek().kind == tokenString {
+		p.next() // description
+	}
+	keyword, err := p.name()
+	if err != nil {
+		return err
+	}
+	extend := keyword == "extend"
+	if extend {
+		if keyword, err = p.name(); err != nil {
+			return err
+		}
+	}
+	switch keyword {
+	case "schema":
+		if err := p.directives(); err != nil {
+			return err
+		}
+		if !p.is(tokenPunct, "{") && extend {
+			return nil
+		}
+		if err := p.expect("{"); err != nil {
+			return err
+		}
+		for !p.skip("}") {
+			op, err := p.name()
+			if err != nil {
+				return err
+			}
+			if err := p.expect(":"); err != nil {
+				return err
+			}
+			name, err := p.name()
+			if err != nil {
+				return err
+			}
+			switch op {
+			case "query":
+				s.Query = name
+			case "mutation":
+				s.Mutation = name
+			case "subscription":
+				s.Subscription = name
+			}
+		}
+		return nil
+	case "scalar":
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		s.Types[name] = &Type{Name: name, Kind: KindScalar}
+		return p.directives()
+	case "type", "interface", "input":
+		kind := map[string]Kind{"type": KindObject, "interface": KindInterface, "input": KindInput}[keyword]
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		t := s.Types[name]
+		if t == nil || !extend {
+			t = &Type{Name: name, Kind: kind}
+			s.Types[name] = t
+		}
+		if p.is(tokenName, "implements") {
+			p.next()
+			p.skip("&")
+			for {
+				iface, err := p.name()
+				if err != nil {
+					return err
+				}
+				t.Interfaces = append(t.Interfaces, iface)
+				if !p.skip("&") {
+					break
+				}
+			}
+		}
+		if err := p.directives(); err != nil {
+			return err
+		}
+		if !p.skip("{") {
+			return nil
+		}
+		for !p.skip("}") {
+			f, err := p.fieldDefinition()
+			if err != nil {
+				return err
+			}
+			t.Fields = append(t.Fields, f)
+		}
+		return nil
+	case "enum":
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		t := s.Types[name]
+		if t == nil || !extend {
+			t = &Type{Name: name, Kind: KindEnum}
+			s.Types[name] = t
+		}
+		if err := p.directives(); err != nil {
+			return err
+		}
+		if !p.skip("{") {
+			return nil
+		}
+		for !p.skip("}") {
+			if p.peek().kind == tokenString {
+				p.next()
+			}
+			v, err := p.name()
+			if err != nil {
+				return err
+			}
+			t.Values = append(t.Values, v)
+			if err := p.directives(); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "union":
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		t := s.Types[name]
+		if t == nil || !extend {
+			t = &Type{Name: name, Kind: KindUnion}
+			s.Types[name] = t
+		}
+		if err := p.directives(); err != nil {
+			return err
+		}
+		if !p.skip("=") {
+			return nil
+		}
+		p.skip("|")
+		for {
+			m, err := p.name()
+			if err != nil {
+				return err
+			}
+			t.Members = append(t.Members, m)
+			if !p.skip("|") {
+				return nil
+			}
+		}
+	case "directive":
+		if err := p.expect("@"); err != nil {
+			return err
+		}
+		if _, err := p.name(); err != nil {
+			return err
+		}
+		if p.skip("(") {
+			for !p.skip(")") {
+				if _, err := p.inputValueDefinition(); err != nil {
+					return err
+				}
+			}
+		}
+		if p.is(tokenName, "repeatable") {
+			p.next()
+		}
+		if !p.is(tokenName, "on") {
+			return p.unexpected()
+		}
+		p.next()
+		p.skip("|")
+		for {
+			if _, err := p.name(); err != nil {
+				return err
+			}
+			if !p.skip("|") {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unexpected %q at %d", keyword, p.tokens[p.pos-1].pos)
+}
+
+func (p *parser) fieldDefinition() (*Field, error) {
+	if p.peek().kind == tokenString {
+		p.next()
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if p.skip("(") {
+		for !p.skip(")") {
+			if _, err := p.inputValueDefinition(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	t, err := p.typeRef()
+	if err != nil {
+		return nil, err
+	}
+	return &Field{Name: name, Type: t}, p.directives()
+}
+
+func (p *parser) inputValueDefinition() (*Field, error) {
+	if p.peek().kind == tokenString {
+		p.next()
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	t, err := p.typeRef()
+	if err != nil {
+		return nil, err
+	}
+	if p.skip("=") {
+		if _, err := p.value(nil); err != nil {
+			return nil, err
+		}
+	}
+	return &Field{Name: name, Type: t}, p.directives()
+}
+
+func (p *parser) typeRef() (*TypeRef, error) {
+	var t *TypeRef
+	if p.skip("[") {
+		elem, err := p.typeRef()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		t = &TypeRef{Elem: elem}
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		t = &TypeRef{Name: name}
+	}
+	t.NonNull = p.skip("!")
+	return t, nil
+}
+
+// directives skips any directives.
+func (p *parser) directives() error {
+	for p.skip("@") {
+		if _, err := p.name(); err != nil {
+			return err
+		}
+		if _, err := p.arguments(nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package gohtmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// MockJSON answers GET requests to path with v marshaled as JSON. It panics
+// if v can not be marshaled.
+func (m *Mock) MockJSON(path string, v any) *mockResponse {
+	return m.Mock(path, "").JSON(v)
+}
+
+// JSON replaces the response of mr with v marshaled as JSON and sets the
+// Content-Type. v is marshaled once, see LiveJSON for a response following
+// changes of v. It panics if v can not be marshaled.
+func (mr *mockResponse) JSON(v any) *mockResponse {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("gohtmock: JSON: %s", err))
+	}
+	return mr.Update(string(b)).SetHeader("Content-Type", "application/json")
+}
+
+// LiveJSON is JSON with v marshaled again for every call, so that changes
+// to a map, slice or pointer v show up in later responses. Changes made
+// while the mock may be called must be synchronized by the caller.
+func (mr *mockResponse) LiveJSON(v any) *mockResponse {
+	return mr.UpdateResponder(func(w http.ResponseWriter, r *http.Request) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			msg := fmt.Sprintf("marshaling response for %s %s: %s", r.Method, r.URL.Path, err)
+			if !mr.fail("%s%s", msg, mr.ownedBy()) {
+				log.Print("gohtmock: ", msg)
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "gohtmock: ", msg)
+			return
+		}
+		mr.Lock()
+		status := mr.status
+		mr.Unlock()
+		if err := mr.writeBody(w, status, b); err != nil {
+			log.Print("gohtmock: writing response for ", r.URL.Path, ": ", err)
+		}
+	}).SetHeader("Content-Type", "application/json")
+}
@@ -0,0 +1,52 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockJSON(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	type user struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	u := &user{ID: 1, Name: "anna"}
+	mock.MockJSON("/users/1", u)
+	mock.Mock("/users/2", "").JSON(map[string]string{"error": "not found"}).Status(http.StatusNotFound)
+	live := map[string]int{"count": 1}
+	mock.Mock("/live", "").LiveJSON(live)
+	u.Name = "changed"
+
+	get := func(path string) (int, string, string) {
+		resp, err := http.Get(mock.URL() + path)
+		if !assert.NoError(t, err) {
+			return 0, "", ""
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return resp.StatusCode, resp.Header.Get("Content-Type"), string(body)
+	}
+
+	status, contentType, body := get("/users/1")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "application/json", contentType)
+	assert.Equal(t, `{"id":1,"name":"anna"}`, body)
+
+	status, _, body = get("/users/2")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, `{"error":"not found"}`, body)
+
+	_, contentType, body = get("/live")
+	assert.Equal(t, "application/json", contentType)
+	assert.Equal(t, `{"count":1}`, body)
+	live["count"] = 2
+	_, _, body = get("/live")
+	assert.Equal(t, `{"count":2}`, body)
+
+	assert.Panics(t, func() { mock.MockJSON("/bad", make(chan int)) })
+}
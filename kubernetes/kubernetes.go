@@ -0,0 +1,486 @@
+// Package kubernetes emulates a tiny, scripted slice of the Kubernetes API
+// server on top of a gohtmock.Mock. It serves list, get and watch for the
+// configured resources which is what most controllers and operators need.
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fortnoxab/gohtmock"
+)
+
+type EventType string
+
+const (
+	Added    EventType = "ADDED"
+	Modified EventType = "MODIFIED"
+	Deleted  EventType = "DELETED"
+	Bookmark EventType = "BOOKMARK"
+	Error    EventType = "ERROR"
+)
+
+// Object is an unstructured Kubernetes object such as
+// {"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "a", "namespace": "default"}}.
+type Object map[string]any
+
+type Event struct {
+	Type   EventType `json:"type"`
+	Object Object    `json:"object"`
+}
+
+// Resource describes a served resource, e.g. Resource{Version: "v1", Resource: "pods", Kind: "Pod", Namespaced: true}.
+type Resource struct {
+	Group      string
+	Version    string
+	Resource   string
+	Kind       string
+	Namespaced bool
+}
+
+func (r Resource) apiVersion() string {
+	if r.Group == "" {
+		return r.Version
+	}
+	return r.Group + "/" + r.Version
+}
+
+func (r Resource) prefix() string {
+	if r.Group == "" {
+		return "/api/" + r.Version
+	}
+	return "/apis/" + r.Group + "/" + r.Version
+}
+
+func (r Resource) listPath(namespace string) string {
+	if namespace == "" {
+		return r.prefix() + "/" + r.Resource
+	}
+	return r.prefix() + "/namespaces/" + namespace + "/" + r.Resource
+}
+
+var (
+	Namespaces  = Resource{Version: "v1", Resource: "namespaces", Kind: "Namespace"}
+	Pods        = Resource{Version: "v1", Resource: "pods", Kind: "Pod", Namespaced: true}
+	Services    = Resource{Version: "v1", Resource: "services", Kind: "Service", Namespaced: true}
+	ConfigMaps  = Resource{Version: "v1", Resource: "configmaps", Kind: "ConfigMap", Namespaced: true}
+	Secrets     = Resource{Version: "v1", Resource: "secrets", Kind: "Secret", Namespaced: true}
+	Deployments = Resource{Group: "apps", Version: "v1", Resource: "deployments", Kind: "Deployment", Namespaced: true}
+)
+
+func init() {
+	gohtmock.RegisterPreset("kubernetes", func() gohtmock.Preset {
+		return New(Namespaces, Pods, Services, ConfigMaps, Secrets, Deployments)
+	})
+}
+
+var _ gohtmock.Preset = (*APIServer)(nil)
+
+type objectKey struct {
+	resource  Resource
+	namespace string
+	name      string
+}
+
+type loggedEvent struct {
+	resource        Resource
+	namespace       string
+	resourceVersion int
+	event           Event
+}
+
+type APIServer struct {
+	mock            *gohtmock.Mock
+	resources       []Resource
+	objects         map[objectKey]Object
+	log             []loggedEvent
+	watchers        map[*watcher]bool
+	registered      map[string]bool
+	resourceVersion int
+	sync.Mutex
+}
+
+func New(resources ...Resource) *APIServer {
+	return &APIServer{
+		resources:  resources,
+		objects:    make(map[objectKey]Object),
+		watchers:   make(map[*watcher]bool),
+		registered: make(map[string]bool),
+	}
+}
+
+// Install registers the list and watch endpoints of all resources on m.
+// Paths for namespaces and single objects are registered as they appear.
+func (s *APIServer) Install(m *gohtmock.Mock) error {
+	if len(s.resources) == 0 {
+		return fmt.Errorf("kubernetes: no resources configured")
+	}
+	for _, res := range s.resources {
+		if res.Version == "" || res.Resource == "" || res.Kind == "" {
+			return fmt.Errorf("kubernetes: resource %+v must have Version, Resource and Kind", res)
+		}
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.mock = m
+	for _, res := range s.resources {
+		s.registerList(res, "")
+	}
+	for key := range s.objects {
+		s.registerObject(key)
+	}
+	return nil
+}
+
+// AddNamespace makes the namespaced list and watch endpoints available for
+// namespace before any object in it exists.
+func (s *APIServer) AddNamespace(namespace string) {
+	s.Lock()
+	defer s.Unlock()
+	for _, res := range s.resources {
+		if res.Namespaced {
+			s.registerList(res, namespace)
+		}
+	}
+}
+
+// Add creates obj and emits an ADDED event, or a MODIFIED event if it already exists.
+func (s *APIServer) Add(obj Object) error {
+	res, key, err := s.keyFor(obj)
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	typ := Added
+	if _, ok := s.objects[key]; ok {
+		typ = Modified
+	}
+	obj = s.store(key, obj)
+	s.registerObject(key)
+	s.emit(res, key.namespace, Event{Type: typ, Object: obj})
+	return nil
+}
+
+// Update replaces an existing obj and emits a MODIFIED event.
+func (s *APIServer) Update(obj Object) error {
+	res, key, err := s.keyFor(obj)
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.objects[key]; !ok {
+		return fmt.Errorf("kubernetes: %s %s/%s not found", res.Resource, key.namespace, key.name)
+	}
+	obj = s.store(key, obj)
+	s.emit(res, key.namespace, Event{Type: Modified, Object: obj})
+	return nil
+}
+
+// Delete removes the object and emits a DELETED event.
+func (s *APIServer) Delete(res Resource, namespace, name string) error {
+	key := objectKey{resource: res, namespace: namespace, name: name}
+
+	s.Lock()
+	defer s.Unlock()
+	obj, ok := s.objects[key]
+	if !ok {
+		return fmt.Errorf("kubernetes: %s %s/%s not found", res.Resource, namespace, name)
+	}
+	delete(s.objects, key)
+	s.resourceVersion++
+	obj = withResourceVersion(obj, s.resourceVersion)
+	s.emit(res, namespace, Event{Type: Deleted, Object: obj})
+	return nil
+}
+
+// Emit sends a raw event to all watchers of res in namespace without changing
+// the stored objects. Use it to script BOOKMARK or ERROR events.
+func (s *APIServer) Emit(res Resource, namespace string, event Event) {
+	s.Lock()
+	defer s.Unlock()
+	s.emit(res, namespace, event)
+}
+
+// CloseWatches ends all open watch streams, forcing clients to re-watch.
+func (s *APIServer) CloseWatches() {
+	s.Lock()
+	defer s.Unlock()
+	for w := range s.watchers {
+		w.close()
+		delete(s.watchers, w)
+	}
+}
+
+// Watchers returns the number of open watch streams.
+func (s *APIServer) Watchers() int {
+	s.Lock()
+	defer s.Unlock()
+	return len(s.watchers)
+}
+
+func (s *APIServer) keyFor(obj Object) (Resource, objectKey, error) {
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
+	metadata, _ := obj["metadata"].(map[string]any)
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+	if name == "" {
+		return Resource{}, objectKey{}, fmt.Errorf("kubernetes: object has no metadata.name")
+	}
+
+	for _, res := range s.resources {
+		if res.apiVersion() != apiVersion || res.Kind != kind {
+			continue
+		}
+		if res.Namespaced && namespace == "" {
+			namespace = "default"
+		}
+		if !res.Namespaced {
+			namespace = ""
+		}
+		return res, objectKey{resource: res, namespace: namespace, name: name}, nil
+	}
+	return Resource{}, objectKey{}, fmt.Errorf("kubernetes: no resource configured for %s %s", apiVersion, kind)
+}
+
+func (s *APIServer) store(key objectKey, obj Object) Object {
+	s.resourceVersion++
+	obj = withResourceVersion(obj, s.resourceVersion)
+	metadata := obj["metadata"].(map[string]any)
+	if key.namespace != "" {
+		metadata["namespace"] = key.namespace
+	}
+	s.objects[key] = obj
+	return obj
+}
+
+func (s *APIServer) emit(res Resource, namespace string, event Event) {
+	s.log = append(s.log, loggedEvent{
+		resource:        res,
+		namespace:       namespace,
+		resourceVersion: s.resourceVersion,
+		event:           event,
+	})
+	for w := range s.watchers {
+		if w.wants(res, namespace) {
+			w.send(event)
+		}
+	}
+}
+
+func (s *APIServer) registerList(res Resource, namespace string) {
+	path := res.listPath(namespace)
+	if s.mock == nil || s.registered[path] {
+		return
+	}
+	s.registered[path] = true
+	s.mock.MockFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if watch := r.URL.Query().Get("watch"); watch == "true" || watch == "1" {
+			s.serveWatch(w, r, res, namespace)
+			return
+		}
+		s.serveList(w, res, namespace)
+	})
+}
+
+func (s *APIServer) registerObject(key objectKey) {
+	if key.namespace != "" {
+		s.registerList(key.resource, key.namespace)
+	}
+	path := key.resource.listPath(key.namespace) + "/" + key.name
+	if s.mock == nil || s.registered[path] {
+		return
+	}
+	s.registered[path] = true
+	s.mock.MockFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		s.Lock()
+		obj, ok := s.objects[key]
+		s.Unlock()
+		if !ok {
+			writeNotFound(w, key)
+			return
+		}
+		writeJSON(w, http.StatusOK, obj)
+	})
+}
+
+func (s *APIServer) serveList(w http.ResponseWriter, res Resource, namespace string) {
+	s.Lock()
+	items := []Object{}
+	for key, obj := range s.objects {
+		if key.resource == res && (namespace == "" || key.namespace == namespace) {
+			items = append(items, obj)
+		}
+	}
+	resourceVersion := s.resourceVersion
+	s.Unlock()
+
+	sort.Slice(items, func(i, j int) bool {
+		return metadataString(items[i], "namespace")+"/"+metadataString(items[i], "name") <
+			metadataString(items[j], "namespace")+"/"+metadataString(items[j], "name")
+	})
+	writeJSON(w, http.StatusOK, map[string]any{
+		"apiVersion": res.apiVersion(),
+		"kind":       res.Kind + "List",
+		"metadata":   map[string]any{"resourceVersion": strconv.Itoa(resourceVersion)},
+		"items":      items,
+	})
+}
+
+func (s *APIServer) serveWatch(w http.ResponseWriter, r *http.Request, res Resource, namespace string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	wt := newWatcher(res, namespace)
+	s.Lock()
+	since, _ := strconv.Atoi(r.URL.Query().Get("resourceVersion"))
+	if since == 0 {
+		for key, obj := range s.objects {
+			if wt.wants(key.resource, key.namespace) {
+				wt.send(Event{Type: Added, Object: obj})
+			}
+		}
+	} else {
+		for _, e := range s.log {
+			if e.resourceVersion > since && wt.wants(e.resource, e.namespace) {
+				wt.send(e.event)
+			}
+		}
+	}
+	s.watchers[wt] = true
+	s.Unlock()
+
+	defer func() {
+		s.Lock()
+		delete(s.watchers, wt)
+		s.Unlock()
+	}()
+
+	var timeout <-chan time.Time
+	if seconds, err := strconv.Atoi(r.URL.Query().Get("timeoutSeconds")); err == nil && seconds > 0 {
+		timeout = time.After(time.Duration(seconds) * time.Second)
+	}
+
+	w.Header().Set("Transfer-Encoding", "chunked")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	enc := json.NewEncoder(w)
+	for {
+		events, closed := wt.receive()
+		for _, e := range events {
+			if err := enc.Encode(e); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+		if closed {
+			return
+		}
+
+		select {
+		case <-wt.notify:
+		case <-r.Context().Done():
+			return
+		case <-timeout:
+			return
+		}
+	}
+}
+
+func withResourceVersion(obj Object, resourceVersion int) Object {
+	cp := make(Object, len(obj))
+	for k, v := range obj {
+		cp[k] = v
+	}
+	metadata := map[string]any{}
+	if m, ok := obj["metadata"].(map[string]any); ok {
+		for k, v := range m {
+			metadata[k] = v
+		}
+	}
+	metadata["resourceVersion"] = strconv.Itoa(resourceVersion)
+	cp["metadata"] = metadata
+	return cp
+}
+
+func metadataString(obj Object, field string) string {
+	metadata, _ := obj["metadata"].(map[string]any)
+	v, _ := metadata[field].(string)
+	return v
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeNotFound(w http.ResponseWriter, key objectKey) {
+	writeJSON(w, http.StatusNotFound, map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Status",
+		"status":     "Failure",
+		"reason":     "NotFound",
+		"code":       http.StatusNotFound,
+		"message":    fmt.Sprintf("%s %q not found", key.resource.Resource, key.name),
+	})
+}
+
+type watcher struct {
+	resource  Resource
+	namespace string
+	pending   []Event
+	closed    bool
+	notify    chan struct{}
+	sync.Mutex
+}
+
+func newWatcher(res Resource, namespace string) *watcher {
+	return &watcher{resource: res, namespace: namespace, notify: make(chan struct{}, 1)}
+}
+
+func (w *watcher) wants(res Resource, namespace string) bool {
+	return w.resource == res && (w.namespace == "" || w.namespace == namespace)
+}
+
+func (w *watcher) send(e Event) {
+	w.Lock()
+	w.pending = append(w.pending, e)
+	w.Unlock()
+	w.signal()
+}
+
+func (w *watcher) close() {
+	w.Lock()
+	w.closed = true
+	w.Unlock()
+	w.signal()
+}
+
+func (w *watcher) signal() {
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (w *watcher) receive() ([]Event, bool) {
+	w.Lock()
+	defer w.Unlock()
+	events := w.pending
+	w.pending = nil
+	return events, w.closed
+}
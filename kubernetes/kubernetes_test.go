@@ -0,0 +1,106 @@
+package kubernetes
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/fortnoxab/gohtmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var pods = Pods
+
+func pod(name string) Object {
+	return Object{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]any{"name": name, "namespace": "default"},
+	}
+}
+
+func TestListAndGet(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	api := New(pods)
+	assert.NoError(t, api.Add(pod("a")))
+	assert.NoError(t, api.Install(mock))
+	assert.NoError(t, api.Add(pod("b")))
+
+	resp, err := http.Get(mock.URL() + "/api/v1/namespaces/default/pods")
+	assert.NoError(t, err)
+	list := struct {
+		Kind     string
+		Metadata struct{ ResourceVersion string }
+		Items    []Object
+	}{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	assert.Equal(t, "PodList", list.Kind)
+	assert.Equal(t, "2", list.Metadata.ResourceVersion)
+	assert.Len(t, list.Items, 2)
+
+	resp, err = http.Get(mock.URL() + "/api/v1/namespaces/default/pods/b")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.NoError(t, api.Delete(pods, "default", "b"))
+	resp, err = http.Get(mock.URL() + "/api/v1/namespaces/default/pods/b")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	mock.AssertCallCount(t, "GET", "/api/v1/namespaces/default/pods/b", 2)
+}
+
+func TestWatch(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	api := New(pods)
+	assert.NoError(t, api.Install(mock))
+	assert.NoError(t, api.Add(pod("a")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", mock.URL()+"/api/v1/pods?watch=true&resourceVersion=1", nil)
+	assert.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.NoError(t, api.Add(pod("b")))
+	updated := pod("b")
+	updated["spec"] = map[string]any{"nodeName": "node1"}
+	assert.NoError(t, api.Update(updated))
+	api.Emit(pods, "default", Event{Type: Bookmark, Object: Object{"kind": "Pod"}})
+
+	scanner := bufio.NewScanner(resp.Body)
+	var types []EventType
+	for len(types) < 3 && scanner.Scan() {
+		var e Event
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		types = append(types, e.Type)
+	}
+	assert.Equal(t, []EventType{Added, Modified, Bookmark}, types)
+	assert.Equal(t, 1, api.Watchers())
+
+	api.CloseWatches()
+	assert.False(t, scanner.Scan())
+}
+
+func TestRegisteredPreset(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	p, err := mock.InstallPreset("kubernetes")
+	assert.NoError(t, err)
+	api := p.(*APIServer)
+	assert.NoError(t, api.Add(Object{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]any{"name": "web", "namespace": "prod"},
+	}))
+
+	resp, err := http.Get(mock.URL() + "/apis/apps/v1/namespaces/prod/deployments/web")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
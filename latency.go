@@ -0,0 +1,190 @@
+package gohtmock
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Latencies is a set of observed durations.
+type Latencies []time.Duration
+
+func (l Latencies) sorted() Latencies {
+	s := append(Latencies(nil), l...)
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	return s
+}
+
+// Min returns the shortest duration, or 0 if l is empty.
+func (l Latencies) Min() time.Duration {
+	if len(l) == 0 {
+		return 0
+	}
+	return l.sorted()[0]
+}
+
+// Max returns the longest duration, or 0 if l is empty.
+func (l Latencies) Max() time.Duration {
+	if len(l) == 0 {
+		return 0
+	}
+	return l.sorted()[len(l)-1]
+}
+
+// Percentile returns the duration below which p percent of l falls, using
+// the nearest rank. It returns 0 if l is empty.
+func (l Latencies) Percentile(p float64) time.Duration {
+	if len(l) == 0 {
+		return 0
+	}
+	s := l.sorted()
+	i := int(p/100*float64(len(s))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(s) {
+		i = len(s) - 1
+	}
+	return s[i]
+}
+
+// Histogram counts the durations of l into the buckets bounded by the given
+// upper bounds, which must be ascending. The last count holds durations
+// above the last bound.
+func (l Latencies) Histogram(bounds ...time.Duration) []int {
+	counts := make([]int, len(bounds)+1)
+	for _, d := range l {
+		i := sort.Search(len(bounds), func(i int) bool { return d <= bounds[i] })
+		counts[i]++
+	}
+	return counts
+}
+
+// InterArrivalTimes returns the time between consecutive requests answered
+// by mr.
+func (mr *mockResponse) InterArrivalTimes() Latencies {
+	mr.Lock()
+	defer mr.Unlock()
+	return append(Latencies(nil), mr.interArrivals...)
+}
+
+// HandlerDurations returns how long mr took to answer each request,
+// including any delay.
+func (mr *mockResponse) HandlerDurations() Latencies {
+	mr.Lock()
+	defer mr.Unlock()
+	return append(Latencies(nil), mr.durations...)
+}
+
+// AssertInterArrivalAtLeast asserts that consecutive requests answered by
+// mr arrived at least min apart.
+func (mr *mockResponse) AssertInterArrivalAtLeast(tb testing.TB, min time.Duration) {
+	if mr.httpMock.assertionsDisabled(tb) {
+		return
+	}
+	for i, d := range mr.InterArrivalTimes() {
+		if d < min {
+			tb.Errorf("%s %s: request %d arrived %s after the previous one, expected at least %s", mr.method, mr.path, i+2, d, min)
+		}
+	}
+}
+
+// AssertHandlerDurationAtMost asserts that mr answered every request within max.
+func (mr *mockResponse) AssertHandlerDurationAtMost(tb testing.TB, max time.Duration) {
+	if mr.httpMock.assertionsDisabled(tb) {
+		return
+	}
+	for i, d := range mr.HandlerDurations() {
+		if d > max {
+			tb.Errorf("%s %s: request %d took %s, expected at most %s", mr.method, mr.path, i+1, d, max)
+		}
+	}
+}
+
+// AssertCalledWithinDuration asserts that mr was called at least n times
+// and that the first n calls arrived within window of the first call. It
+// needs the arrival times kept unless the mock was created WithoutRecording.
+func (mr *mockResponse) AssertCalledWithinDuration(tb testing.TB, n int, window time.Duration) {
+	if mr.httpMock.assertionsDisabled(tb) {
+		return
+	}
+	if mr.httpMock.withoutRecording {
+		tb.Errorf("AssertCalledWithinDuration needs recording, which is disabled by WithoutRecording")
+		return
+	}
+	interArrivals := mr.InterArrivalTimes()
+	calls := len(interArrivals)
+	if calls > 0 || mr.called() {
+		calls++
+	}
+	if calls < n {
+		tb.Errorf("%s %s called %d times, expected at least %d within %s", mr.method, mr.path, calls, n, window)
+		return
+	}
+	var elapsed time.Duration
+	for i := 0; i < n-1; i++ {
+		elapsed += interArrivals[i]
+	}
+	if elapsed > window {
+		tb.Errorf("%s %s: first %d calls took %s, expected at most %s", mr.method, mr.path, n, elapsed, window)
+	}
+}
+
+// AssertRetriedWithBackoff asserts that mr was called at least minAttempts
+// times and that every retry waited at least minInterval times factor to
+// the power of the retries before it: with minInterval 100ms and factor 2
+// the retries must come at least 100ms, 200ms, 400ms and so on after the
+// previous attempt.
+func (mr *mockResponse) AssertRetriedWithBackoff(tb testing.TB, minAttempts int, minInterval time.Duration, factor float64) {
+	if mr.httpMock.assertionsDisabled(tb) {
+		return
+	}
+	interArrivals := mr.InterArrivalTimes()
+	attempts := len(interArrivals)
+	if attempts > 0 || mr.called() {
+		attempts++
+	}
+	if attempts < minAttempts {
+		tb.Errorf("%s %s attempted %d times, expected at least %d", mr.method, mr.path, attempts, minAttempts)
+		return
+	}
+	expected := float64(minInterval)
+	for i, d := range interArrivals {
+		if d < time.Duration(expected) {
+			tb.Errorf("%s %s: retry %d came %s after the previous attempt, expected at least %s (waits: %s)",
+				mr.method, mr.path, i+1, d, time.Duration(expected), formatLatencies(interArrivals))
+		}
+		expected *= factor
+	}
+}
+
+func formatLatencies(l Latencies) string {
+	s := make([]string, len(l))
+	for i, d := range l {
+		s[i] = d.String()
+	}
+	return strings.Join(s, ", ")
+}
+
+func (mr *mockResponse) called() bool {
+	mr.Lock()
+	defer mr.Unlock()
+	return !mr.lastArrival.IsZero()
+}
+
+// observeArrival records the inter-arrival time of a request arriving at
+// now. mr must be locked.
+func (mr *mockResponse) observeArrival(now time.Time) {
+	if !mr.lastArrival.IsZero() {
+		mr.interArrivals = append(mr.interArrivals, now.Sub(mr.lastArrival))
+	}
+	mr.lastArrival = now
+}
+
+func (mr *mockResponse) observeDuration(start time.Time) {
+	d := time.Since(start)
+	mr.Lock()
+	mr.durations = append(mr.durations, d)
+	mr.Unlock()
+}
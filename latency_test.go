@@ -0,0 +1,179 @@
+package gohtmock
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencies(t *testing.T) {
+	l := Latencies{30 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond}
+	assert.Equal(t, 10*time.Millisecond, l.Min())
+	assert.Equal(t, 40*time.Millisecond, l.Max())
+	assert.Equal(t, 20*time.Millisecond, l.Percentile(50))
+	assert.Equal(t, 40*time.Millisecond, l.Percentile(99))
+	assert.Equal(t, []int{1, 2, 1}, l.Histogram(10*time.Millisecond, 30*time.Millisecond))
+	assert.Equal(t, time.Duration(0), Latencies{}.Percentile(50))
+}
+
+func TestAssertInterArrival(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mr := mock.Mock("/paced", "{}").WithDelay(5 * time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(mock.URL() + "/paced")
+		assert.NoError(t, err)
+		resp.Body.Close()
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	assert.Len(t, mr.InterArrivalTimes(), 2)
+	assert.Len(t, mr.HandlerDurations(), 3)
+	assert.GreaterOrEqual(t, int64(mr.HandlerDurations().Min()), int64(5*time.Millisecond))
+	mr.AssertInterArrivalAtLeast(t, 20*time.Millisecond)
+	mr.AssertHandlerDurationAtMost(t, time.Second)
+
+	rt := newRecordingT("TestAssertInterArrival")
+	mr.AssertInterArrivalAtLeast(rt, time.Second)
+	assert.Len(t, rt.Errors(), 2)
+	rt = newRecordingT("TestAssertInterArrival")
+	mr.AssertHandlerDurationAtMost(rt, time.Millisecond)
+	assert.Len(t, rt.Errors(), 3)
+}
+
+func TestConcurrentInterArrivals(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mr := mock.Mock("/burst", "{}")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Get(mock.URL() + "/burst")
+			assert.NoError(t, err)
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, mr.InterArrivalTimes(), 19)
+	assert.GreaterOrEqual(t, int64(mr.InterArrivalTimes().Min()), int64(0))
+}
+
+func TestAssertCalledWithinDuration(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mr := mock.Mock("/batch", "{}")
+
+	rt := newRecordingT("TestAssertCalledWithinDuration")
+	mr.AssertCalledWithinDuration(rt, 1, time.Second)
+	assert.Equal(t, []string{"GET /batch called 0 times, expected at least 1 within 1s"}, rt.Errors())
+
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(mock.URL() + "/batch")
+		assert.NoError(t, err)
+		resp.Body.Close()
+		if i == 1 {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	mr.AssertCalledWithinDuration(t, 1, 0)
+	mr.AssertCalledWithinDuration(t, 2, 40*time.Millisecond)
+	mr.AssertCalledWithinDuration(t, 3, time.Second)
+
+	rt = newRecordingT("TestAssertCalledWithinDuration")
+	mr.AssertCalledWithinDuration(rt, 3, 40*time.Millisecond)
+	mr.AssertCalledWithinDuration(rt, 4, time.Second)
+	assert.Len(t, rt.Errors(), 2)
+}
+
+func TestAssertCalledWithinDurationWithoutRecording(t *testing.T) {
+	mock := New(WithoutRecording())
+	defer mock.Close()
+	mr := mock.Mock("/batch", "{}")
+	resp, err := http.Get(mock.URL() + "/batch")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	rt := newRecordingT("TestAssertCalledWithinDurationWithoutRecording")
+	mr.AssertCalledWithinDuration(rt, 1, time.Second)
+	assert.Equal(t, []string{"AssertCalledWithinDuration needs recording, which is disabled by WithoutRecording"}, rt.Errors())
+}
+
+func TestAssertRetriedWithBackoff(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mr := mock.Mock("/flaky", "").WithStatus(http.StatusServiceUnavailable)
+
+	wait := 10 * time.Millisecond
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(mock.URL() + "/flaky")
+		assert.NoError(t, err)
+		resp.Body.Close()
+		time.Sleep(wait)
+		wait *= 2
+	}
+
+	mr.AssertRetriedWithBackoff(t, 3, 10*time.Millisecond, 2)
+
+	rt := newRecordingT("TestAssertRetriedWithBackoff")
+	mr.AssertRetriedWithBackoff(rt, 4, 10*time.Millisecond, 2)
+	mr.AssertRetriedWithBackoff(rt, 3, 10*time.Millisecond, 10)
+	if assert.Len(t, rt.Errors(), 2) {
+		assert.Equal(t, "GET /flaky attempted 3 times, expected at least 4", rt.Errors()[0])
+		assert.Contains(t, rt.Errors()[1], "GET /flaky: retry 2 came ")
+		assert.Contains(t, rt.Errors()[1], "after the previous attempt, expected at least 100ms (waits: ")
+	}
+}
+
+func TestDelaySchedule(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/slow-first", "{}").DelaySchedule(200*time.Millisecond, 0)
+
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	_, err := client.Get(mock.URL() + "/slow-first")
+	assert.Error(t, err)
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(mock.URL() + "/slow-first")
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+	}
+}
+
+func TestDelayRange(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/jittered", "{}").DelayRange(30*time.Millisecond, 10*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		resp, err := http.Get(mock.URL() + "/jittered")
+		elapsed := time.Since(start)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+		assert.GreaterOrEqual(t, elapsed, 10*time.Millisecond)
+		assert.Less(t, elapsed, time.Second)
+	}
+}
+
+func TestDelayClientTimeout(t *testing.T) {
+	mock := New()
+	mock.Mock("/slow", "{}").Delay(time.Minute)
+
+	client := &http.Client{Timeout: 20 * time.Millisecond}
+	_, err := client.Get(mock.URL() + "/slow")
+	assert.Error(t, err)
+
+	start := time.Now()
+	mock.Close()
+	assert.Less(t, time.Since(start), 10*time.Second)
+	mock.AssertCallCount(t, "GET", "/slow", 1)
+}
@@ -0,0 +1,161 @@
+package gohtmock
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// WithLeakDetection makes the mock keep track of requests that outlive it:
+// handlers still running when Close is called and requests arriving after
+// it. After Close the mock keeps listening on its address, answering every
+// request with 503, until AssertNoLeaks reports what was found.
+func WithLeakDetection() Option {
+	return func(m *Mock) {
+		m.leakDetection = true
+		m.inflight = make(map[*inflightRequest]bool)
+	}
+}
+
+// inflightRequest is a request being handled, see WithLeakDetection.
+type inflightRequest struct {
+	method    string
+	path      string
+	goroutine string
+}
+
+// trackInflight registers r as being handled until the returned func is
+// called.
+func (m *Mock) trackInflight(r *http.Request) func() {
+	ir := &inflightRequest{method: r.Method, path: r.URL.Path, goroutine: currentGoroutine()}
+	m.Lock()
+	m.inflight[ir] = true
+	m.Unlock()
+	return func() {
+		m.Lock()
+		delete(m.inflight, ir)
+		m.Unlock()
+	}
+}
+
+// noteInflight keeps the handlers still running as leaks, with their stacks.
+func (m *Mock) noteInflight() {
+	m.Lock()
+	defer m.Unlock()
+	if len(m.inflight) == 0 {
+		return
+	}
+	stacks := goroutineStacks()
+	for ir := range m.inflight {
+		m.leaks = append(m.leaks, fmt.Sprintf("handler for %s %s%s still running at Close:\n%s",
+			ir.method, ir.path, m.mockedAt(ir.method, ir.path), stacks[ir.goroutine]))
+	}
+}
+
+// mockedAt names the first mock matching method and path. m must be locked.
+func (m *Mock) mockedAt(method, path string) string {
+	for _, mr := range m.mockResponses {
+		if mr.matches(method, path) {
+			return fmt.Sprintf(" (mocked at %s)", mr.registeredAt)
+		}
+	}
+	return " (not mocked)"
+}
+
+// listenAfterClose keeps listening on the address of the closed server to
+// catch requests that still arrive.
+func (m *Mock) listenAfterClose() {
+	l, err := net.Listen("tcp", m.server.Listener.Addr().String())
+	if err != nil {
+		log.Print("gohtmock: leak detection can not listen after Close: ", err)
+		return
+	}
+	m.Lock()
+	m.afterClose = l
+	m.Unlock()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go m.serveAfterClose(conn)
+		}
+	}()
+}
+
+func (m *Mock) serveAfterClose(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(time.Second))
+	var leak string
+	if r, err := http.ReadRequest(bufio.NewReader(conn)); err == nil {
+		m.Lock()
+		leak = fmt.Sprintf("%s %s from %s after Close%s", r.Method, r.URL.RequestURI(), conn.RemoteAddr(), m.mockedAt(r.Method, r.URL.Path))
+		m.Unlock()
+	} else {
+		leak = fmt.Sprintf("connection from %s after Close", conn.RemoteAddr())
+	}
+	m.Lock()
+	m.leaks = append(m.leaks, leak)
+	m.Unlock()
+	body := "gohtmock: mock is closed"
+	fmt.Fprintf(conn, "HTTP/1.1 503 Service Unavailable\r\nContent-Type: text/plain\r\nContent-Length: %d\r\nConnection: close\r\n\r\n%s", len(body), body)
+}
+
+// AssertNoLeaks fails tb for every handler that was still running at Close
+// and every request that arrived after it, and stops listening for them.
+// It needs WithLeakDetection and should be called after Close.
+func (m *Mock) AssertNoLeaks(tb testing.TB) {
+	if !m.leakDetection {
+		tb.Errorf("leak detection is disabled, see WithLeakDetection")
+		return
+	}
+	m.Lock()
+	l := m.afterClose
+	m.afterClose = nil
+	m.Unlock()
+	if l != nil {
+		l.Close()
+	}
+	m.Lock()
+	defer m.Unlock()
+	for _, leak := range m.leaks {
+		tb.Errorf("leak: %s", leak)
+	}
+}
+
+// currentGoroutine returns the id of the calling goroutine.
+func currentGoroutine() string {
+	b := make([]byte, 64)
+	b = b[:runtime.Stack(b, false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	return string(b[:bytes.IndexByte(b, ' ')])
+}
+
+// goroutineStacks returns the stacks of all goroutines by id.
+func goroutineStacks() map[string]string {
+	b := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(b, true)
+		if n < len(b) {
+			b = b[:n]
+			break
+		}
+		b = make([]byte, 2*len(b))
+	}
+	stacks := make(map[string]string)
+	for _, stack := range strings.Split(string(b), "\n\n") {
+		stack = strings.TrimPrefix(stack, "goroutine ")
+		if i := strings.IndexByte(stack, ' '); i > 0 {
+			stacks[stack[:i]] = "goroutine " + stack
+		}
+	}
+	return stacks
+}
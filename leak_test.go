@@ -0,0 +1,54 @@
+package gohtmock
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertNoLeaks(t *testing.T) {
+	mock := New(WithLeakDetection())
+	gate := mock.Mock("/slow", "{}").Gate()
+	mock.Mock("/late", "{}")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		resp, err := http.Get(mock.URL() + "/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	assert.True(t, gate.AwaitWaiting(1, time.Second))
+	mock.Close()
+	<-done
+
+	resp, err := http.Get(mock.URL() + "/late")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	rt := newRecordingT("TestAssertNoLeaks")
+	mock.AssertNoLeaks(rt)
+	if assert.Len(t, rt.Errors(), 2) {
+		assert.True(t, strings.HasPrefix(rt.Errors()[0], "leak: handler for GET /slow (mocked at "), rt.Errors()[0])
+		assert.Contains(t, rt.Errors()[0], "still running at Close:\ngoroutine ")
+		assert.Contains(t, rt.Errors()[0], "(*Gate).wait")
+		assert.Regexp(t, `^leak: GET /late from 127\.0\.0\.1:\d+ after Close \(mocked at .*leak_test\.go:\d+\)$`, rt.Errors()[1])
+	}
+
+	// the address is released by AssertNoLeaks
+	_, err = http.Get(mock.URL() + "/late")
+	assert.Error(t, err)
+}
+
+func TestAssertNoLeaksDisabled(t *testing.T) {
+	mock := New()
+	mock.Close()
+	rt := newRecordingT("TestAssertNoLeaksDisabled")
+	mock.AssertNoLeaks(rt)
+	assert.Equal(t, []string{"leak detection is disabled, see WithLeakDetection"}, rt.Errors())
+}
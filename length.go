@@ -0,0 +1,144 @@
+package gohtmock
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// How the length of a response body is communicated, see Chunked,
+// WithContentLength and WithWrongContentLength.
+const (
+	lengthAuto = iota
+	lengthChunked
+	lengthExplicit
+	lengthWrong
+)
+
+// Chunked makes mr send its body with chunked transfer encoding and no
+// Content-Length, however small the body is.
+func (mr *mockResponse) Chunked() *mockResponse {
+	mr.Lock()
+	mr.lengthMode = lengthChunked
+	mr.Unlock()
+	return mr
+}
+
+// WithContentLength makes mr always set Content-Length, however large the
+// body is. By default it is only set for bodies small enough to be buffered.
+func (mr *mockResponse) WithContentLength() *mockResponse {
+	mr.Lock()
+	mr.lengthMode = lengthExplicit
+	mr.Unlock()
+	return mr
+}
+
+// WithWrongContentLength makes mr declare a Content-Length of n whatever
+// the length of its body, and close the connection after the body. This
+// exercises clients reading truncated or overlong responses. Without a
+// connection to hijack, such as through httpmock, the body is sent normally.
+func (mr *mockResponse) WithWrongContentLength(n int) *mockResponse {
+	mr.Lock()
+	mr.lengthMode = lengthWrong
+	mr.wrongLength = n
+	mr.Unlock()
+	return mr
+}
+
+// writeBody writes status, 0 meaning 200, and body to w as set by the
+// length mode of mr.
+func (mr *mockResponse) writeBody(w http.ResponseWriter, status int, body []byte) error {
+	mr.Lock()
+	mode, wrongLength, reason, fault := mr.lengthMode, mr.wrongLength, mr.reason, mr.fault
+	mr.Unlock()
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if fault != faultNone {
+		return breakConnection(w, fault, status, reason, body)
+	}
+	if (mode == lengthWrong || reason != "") && canHijack(w) {
+		if reason == "" {
+			reason = http.StatusText(status)
+		}
+		switch mode {
+		case lengthWrong:
+			w.Header().Set("Content-Length", fmt.Sprint(wrongLength))
+		case lengthChunked:
+			// a raw body without length ends when the connection is closed
+			w.Header().Del("Content-Length")
+		default:
+			w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		}
+		return writeRaw(w, status, reason, body)
+	}
+	switch mode {
+	case lengthChunked:
+		w.Header().Del("Content-Length")
+		w.WriteHeader(status)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	case lengthExplicit:
+		w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		w.WriteHeader(status)
+	default:
+		w.WriteHeader(status)
+	}
+	_, err := w.Write(body)
+	return err
+}
+
+// canHijack reports if the connection under w can be hijacked. The writers
+// wrapping w all implement http.Hijacker, so the innermost one decides.
+func canHijack(w http.ResponseWriter) bool {
+	for {
+		if _, ok := w.(http.Hijacker); !ok {
+			return false
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return true
+		}
+		w = u.Unwrap()
+	}
+}
+
+// writeRaw bypasses the checks of net/http by hijacking the connection and
+// writing the response as is. The connection is closed afterwards. Header
+// names are written as stored in the header map.
+func writeRaw(w http.ResponseWriter, status int, reason string, body []byte) error {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return fmt.Errorf("gohtmock: %T can not be hijacked", w)
+	}
+	header := w.Header().Clone()
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return err
+	}
+	noteRawStatus(w, status, reason)
+	defer conn.Close()
+	return writeRawResponse(rw.Writer, status, reason, header, body)
+}
+
+func writeRawResponse(bw *bufio.Writer, status int, reason string, header http.Header, body []byte) error {
+	fmt.Fprintf(bw, "HTTP/1.1 %03d %s\r\n", status, reason)
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			fmt.Fprintf(bw, "%s: %s\r\n", k, v)
+		}
+	}
+	if header.Get("Connection") == "" {
+		fmt.Fprint(bw, "Connection: close\r\n")
+	}
+	fmt.Fprint(bw, "\r\n")
+	_, _ = bw.Write(body)
+	return bw.Flush()
+}
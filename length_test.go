@@ -0,0 +1,55 @@
+package gohtmock
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentLengthControl(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	large := strings.Repeat("x", 100000)
+	mock.Mock("/chunked", "{}").Chunked()
+	mock.Mock("/explicit", large).WithContentLength()
+	mock.Mock("/auto", large)
+	mock.Mock("/short", "0123456789").WithWrongContentLength(4)
+	mock.Mock("/long", "0123456789").WithWrongContentLength(20)
+
+	get := func(path string) (*http.Response, string, error) {
+		resp, err := http.Get(mock.URL() + path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		return resp, string(body), err
+	}
+
+	resp, body, err := get("/chunked")
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", body)
+	assert.Equal(t, int64(-1), resp.ContentLength)
+	assert.Equal(t, []string{"chunked"}, resp.TransferEncoding)
+
+	resp, body, err = get("/explicit")
+	assert.NoError(t, err)
+	assert.Equal(t, large, body)
+	assert.Equal(t, int64(len(large)), resp.ContentLength)
+
+	resp, _, err = get("/auto")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), resp.ContentLength)
+
+	resp, body, err = get("/short")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), resp.ContentLength)
+	assert.Equal(t, "0123", body)
+
+	resp, body, err = get("/long")
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	assert.Equal(t, int64(20), resp.ContentLength)
+	assert.Equal(t, "0123456789", body)
+}
@@ -0,0 +1,168 @@
+package gohtmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// MatchBody makes mr only answer requests whose body satisfies fn. fn gets
+// the whole body and the body is still there for the responder to read.
+func (mr *mockResponse) MatchBody(fn func(body []byte) bool) *mockResponse {
+	return mr.matchBody(fn)
+}
+
+// MatchBodyString makes mr only answer requests whose body is exactly
+// expected.
+func (mr *mockResponse) MatchBodyString(expected string) *mockResponse {
+	return mr.matchBodyDescribed(func(body []byte) bool {
+		return string(body) == expected
+	}, func(body []byte) (string, string) {
+		return expected, string(body)
+	})
+}
+
+// MatchBodyJSON makes mr only answer requests whose body is JSON equal to
+// expected, ignoring key order and whitespace. A string, []byte or
+// json.RawMessage expected is taken as JSON text, anything else is encoded
+// as JSON first. It panics if expected is not valid JSON.
+func (mr *mockResponse) MatchBodyJSON(expected any) *mockResponse {
+	expected = jsonText(expected)
+	if raw, ok := expected.(json.RawMessage); ok && !json.Valid(raw) {
+		panic(fmt.Sprintf("gohtmock: MatchBodyJSON: invalid JSON %q", raw))
+	}
+	return mr.MatchBodyEncoded(JSON, expected)
+}
+
+// jsonText returns a string or []byte v as json.RawMessage, so that it is
+// encoded as the JSON it holds, and any other v as is.
+func jsonText(v any) any {
+	switch t := v.(type) {
+	case string:
+		return json.RawMessage(t)
+	case []byte:
+		return json.RawMessage(t)
+	}
+	return v
+}
+
+// AssertLastRequestJSON asserts that the body of the last request mr
+// answered is JSON equal to expected, ignoring key order and whitespace.
+// expected is taken as by MatchBodyJSON.
+func (mr *mockResponse) AssertLastRequestJSON(tb testing.TB, expected any) {
+	if mr.httpMock.assertionsDisabled(tb) {
+		return
+	}
+	requests := mr.Requests()
+	if len(requests) == 0 {
+		tb.Errorf("%s %s: no requests recorded", mr.method, mr.path)
+		return
+	}
+	mr.assertRequestJSON(tb, requests, len(requests)-1, expected)
+}
+
+// AssertRequestJSONAt is AssertLastRequestJSON for the i'th request of
+// Requests, counting from 0.
+func (mr *mockResponse) AssertRequestJSONAt(tb testing.TB, i int, expected any) {
+	if mr.httpMock.assertionsDisabled(tb) {
+		return
+	}
+	mr.assertRequestJSON(tb, mr.Requests(), i, expected)
+}
+
+func (mr *mockResponse) assertRequestJSON(tb testing.TB, requests []*RecordedRequest, i int, expected any) {
+	if i < 0 || i >= len(requests) {
+		tb.Errorf("%s %s: no request %d, %d recorded", mr.method, mr.path, i, len(requests))
+		return
+	}
+	want, err := normalize(JSON, jsonText(expected))
+	if err != nil {
+		tb.Errorf("%s %s: invalid expected JSON: %s", mr.method, mr.path, err)
+		return
+	}
+	var got any
+	if err := json.Unmarshal(requests[i].Body, &got); err != nil {
+		tb.Errorf("%s %s: body of request %d is not JSON: %s", mr.method, mr.path, i, err)
+		return
+	}
+	if !reflect.DeepEqual(want, got) {
+		diff := Diff{Subject: "body", Expected: describeValue(want), Actual: describeValue(got)}
+		tb.Errorf("%s %s: body of request %d is not the expected JSON%s", mr.method, mr.path, i, mr.httpMock.formatDiffs([]Diff{diff}))
+	}
+}
+
+// bodyMatcher matches request bodies. describe, if set, renders what match
+// expects and the body it got for a Diff.
+type bodyMatcher struct {
+	match    func(body []byte) bool
+	describe func(body []byte) (expected, actual string)
+}
+
+// matchBody adds a matcher of the request body to mr.
+func (mr *mockResponse) matchBody(fn func(body []byte) bool) *mockResponse {
+	return mr.matchBodyDescribed(fn, nil)
+}
+
+// matchBodyDescribed adds a matcher of the request body to mr whose
+// mismatches describe renders as a Diff.
+func (mr *mockResponse) matchBodyDescribed(fn func(body []byte) bool, describe func(body []byte) (string, string)) *mockResponse {
+	mr.Lock()
+	mr.bodyMatchers = append(mr.bodyMatchers, bodyMatcher{match: fn, describe: describe})
+	mr.Unlock()
+	return mr
+}
+
+// matchesBody reports if the body of r satisfies the body matchers of mr.
+// The body is left in place to be read again.
+func (mr *mockResponse) matchesBody(r *http.Request) bool {
+	mr.Lock()
+	matchers := mr.bodyMatchers
+	mr.Unlock()
+	if len(matchers) == 0 {
+		return true
+	}
+	body, err := rebuffer(r)
+	if err != nil {
+		return false
+	}
+	for _, m := range matchers {
+		if !m.match(body) {
+			return false
+		}
+	}
+	return true
+}
+
+// bodyDiffs returns the diffs of the body matchers of mr that reject r and
+// can describe why.
+func (mr *mockResponse) bodyDiffs(r *http.Request) []Diff {
+	mr.Lock()
+	matchers := mr.bodyMatchers
+	mr.Unlock()
+	body, err := rebuffer(r)
+	if err != nil {
+		return nil
+	}
+	var diffs []Diff
+	for _, m := range matchers {
+		if m.describe != nil && !m.match(body) {
+			expected, actual := m.describe(body)
+			diffs = append(diffs, Diff{Subject: "body", Expected: expected, Actual: actual})
+		}
+	}
+	return diffs
+}
+
+// rebuffer reads the body of r and leaves it in place to be read again.
+func rebuffer(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = newReplayBody(body)
+	return body, err
+}
@@ -0,0 +1,82 @@
+package gohtmock
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchBody(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("POST /orders", "large").MatchBody(func(body []byte) bool { return len(body) > 10 })
+	mock.Mock("POST /orders", "ping").MatchBodyString("ping")
+
+	for body, expected := range map[string]string{
+		"ping":                  "ping",
+		"a much larger payload": "large",
+	} {
+		resp, err := http.Post(mock.URL()+"/orders", "text/plain", strings.NewReader(body))
+		if assert.NoError(t, err) {
+			got, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Equal(t, expected, string(got))
+		}
+	}
+
+	resp, err := http.Post(mock.URL()+"/orders", "text/plain", strings.NewReader("pong"))
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestMatchBodyJSON(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("POST /users", "text").MatchBodyJSON(`{"name": "anna", "roles": ["admin"]}`)
+	mock.Mock("POST /users", "struct").MatchBodyJSON(struct {
+		Name string `json:"name"`
+	}{"bob"})
+
+	for body, expected := range map[string]string{
+		`{"roles":["admin"],"name":"anna"}`: "text",
+		"{\n  \"name\": \"bob\"\n}":         "struct",
+		`{"name":"carl"}`:                   "/users not found",
+		`not json`:                          "/users not found",
+	} {
+		resp, err := http.Post(mock.URL()+"/users", "application/json", bytes.NewBufferString(body))
+		if assert.NoError(t, err) {
+			got, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Equal(t, expected, string(got), body)
+		}
+	}
+	assert.Panics(t, func() { mock.Mock("/x", "").MatchBodyJSON("{") })
+}
+
+func TestAssertRequestJSON(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mr := mock.Mock("POST /users", "{}")
+
+	for _, body := range []string{`{"name":"anna","age":30}`, "{\n  \"age\": 41,\n  \"name\": \"bob\"\n}"} {
+		resp, err := http.Post(mock.URL()+"/users", "application/json", strings.NewReader(body))
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	mr.AssertLastRequestJSON(t, map[string]any{"name": "bob", "age": 41})
+	mr.AssertRequestJSONAt(t, 0, `{"age": 30, "name": "anna"}`)
+
+	err := Check(func(tb testing.TB) { mr.AssertLastRequestJSON(tb, `{"name": "bob", "age": 42}`) })
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "POST /users: body of request 1 is not the expected JSON\nbody differs:")
+		assert.Contains(t, err.Error(), `"age": 42,    |   "age": 41,`)
+	}
+	assert.EqualError(t, Check(func(tb testing.TB) { mr.AssertRequestJSONAt(tb, 2, "{}") }), "POST /users: no request 2, 2 recorded")
+}
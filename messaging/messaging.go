@@ -0,0 +1,304 @@
+// Package messaging emulates a send-message REST API, as offered by email
+// and SMS providers, on top of a gohtmock.Mock. Sent messages get an id and
+// a status the client can poll, the test moves them on with Deliver and
+// Fail, and every status change is posted to the delivery webhook.
+package messaging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fortnoxab/gohtmock"
+	"github.com/fortnoxab/gohtmock/internal/deliver"
+)
+
+// MessagesPath takes a POST of a Message to send it, MessagesPath/{id}
+// returns a sent Message with its current status.
+const MessagesPath = "/messages"
+
+const (
+	StatusQueued    = "queued"
+	StatusSent      = "sent"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+func init() {
+	gohtmock.RegisterPreset("messaging", func() gohtmock.Preset { return New() })
+}
+
+var _ gohtmock.Preset = (*Provider)(nil)
+
+type Option func(*Provider)
+
+// WithWebhook posts the status changes of messages without a CallbackURL of
+// their own to url.
+func WithWebhook(url string) Option {
+	return func(p *Provider) {
+		p.webhook = url
+	}
+}
+
+// WithAutoDeliver delivers messages as soon as they are sent instead of
+// waiting for Deliver.
+func WithAutoDeliver() Option {
+	return func(p *Provider) {
+		p.autoDeliver = true
+	}
+}
+
+// Message is the body of a send request and, with ID, Status and Error
+// set, of the responses.
+type Message struct {
+	ID          string            `json:"id,omitempty"`
+	To          string            `json:"to"`
+	From        string            `json:"from,omitempty"`
+	Subject     string            `json:"subject,omitempty"`
+	Body        string            `json:"body"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	CallbackURL string            `json:"callback_url,omitempty"`
+	Status      string            `json:"status,omitempty"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// StatusEvent is the body of delivery webhooks.
+type StatusEvent struct {
+	ID     string    `json:"id"`
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+type Provider struct {
+	webhook     string
+	autoDeliver bool
+	messages    []*Message
+	byID        map[string]*Message
+	rejected    map[string]int
+	// deliveries are the webhook posts of WithAutoDeliver
+	deliveries deliver.Queue
+	client     *http.Client
+	sync.Mutex
+}
+
+func New(opts ...Option) *Provider {
+	p := &Provider{
+		byID:     make(map[string]*Message),
+		rejected: make(map[string]int),
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Install mounts the messages API on m.
+func (p *Provider) Install(m *gohtmock.Mock) error {
+	m.Mount(MessagesPath, http.HandlerFunc(p.serve))
+	return nil
+}
+
+// Reject makes sends to the recipient to fail with status, for example 400
+// for an invalid phone number or 429 for a rate limit.
+func (p *Provider) Reject(to string, status int) {
+	p.Lock()
+	p.rejected[to] = status
+	p.Unlock()
+}
+
+// Messages returns copies of the sent messages in the order they were sent.
+func (p *Provider) Messages() []Message {
+	p.Lock()
+	defer p.Unlock()
+	messages := make([]Message, len(p.messages))
+	for i, msg := range p.messages {
+		messages[i] = *msg
+	}
+	return messages
+}
+
+// Message returns a copy of the sent message with id.
+func (p *Provider) Message(id string) (Message, bool) {
+	p.Lock()
+	defer p.Unlock()
+	msg, ok := p.byID[id]
+	if !ok {
+		return Message{}, false
+	}
+	return *msg, true
+}
+
+// Send moves the message with id to sent and posts the change to its
+// webhook.
+func (p *Provider) Send(id string) error {
+	return p.setStatus(id, StatusSent, "")
+}
+
+// Deliver moves the message with id to delivered and posts the change to
+// its webhook.
+func (p *Provider) Deliver(id string) error {
+	return p.setStatus(id, StatusDelivered, "")
+}
+
+// Fail moves the message with id to failed with reason and posts the change
+// to its webhook.
+func (p *Provider) Fail(id, reason string) error {
+	return p.setStatus(id, StatusFailed, reason)
+}
+
+// AssertSent asserts that a message to the recipient was sent whose body
+// or subject contains each of the substrings.
+func (p *Provider) AssertSent(tb testing.TB, to string, substrings ...string) {
+	var bodies []string
+outer:
+	for _, msg := range p.Messages() {
+		if msg.To != to {
+			continue
+		}
+		for _, s := range substrings {
+			if !strings.Contains(msg.Subject, s) && !strings.Contains(msg.Body, s) {
+				bodies = append(bodies, fmt.Sprintf("%q", msg.Body))
+				continue outer
+			}
+		}
+		return
+	}
+	if len(bodies) == 0 {
+		tb.Errorf("messaging: no message sent to %s", to)
+		return
+	}
+	tb.Errorf("messaging: no message to %s contains %q, sent %s", to, substrings, strings.Join(bodies, ", "))
+}
+
+// AssertNotSent asserts that no message was sent to the recipient.
+func (p *Provider) AssertNotSent(tb testing.TB, to string) {
+	n := 0
+	for _, msg := range p.Messages() {
+		if msg.To == to {
+			n++
+		}
+	}
+	if n > 0 {
+		tb.Errorf("messaging: %d messages sent to %s, expected none", n, to)
+	}
+}
+
+// AssertSentCount asserts that n messages were sent.
+func (p *Provider) AssertSentCount(tb testing.TB, n int) {
+	if sent := len(p.Messages()); sent != n {
+		tb.Errorf("messaging: %d messages sent, expected %d", sent, n)
+	}
+}
+
+// AssertWebhooksDelivered waits for the webhook posts of WithAutoDeliver
+// and asserts that all of them succeeded.
+func (p *Provider) AssertWebhooksDelivered(tb testing.TB) {
+	p.deliveries.Wait()
+	for _, err := range p.deliveries.Errors() {
+		tb.Errorf("messaging: %s", err)
+	}
+}
+
+// Wait blocks until the webhook posts of WithAutoDeliver started so far
+// have been made.
+func (p *Provider) Wait() {
+	p.deliveries.Wait()
+}
+
+// Close stops WithAutoDeliver from delivering further messages and waits
+// for the webhook posts in flight.
+func (p *Provider) Close() {
+	p.deliveries.Close()
+}
+
+func (p *Provider) serve(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/" && r.Method == http.MethodPost:
+		p.serveSend(w, r)
+	case r.URL.Path != "/" && r.Method == http.MethodGet:
+		msg, ok := p.Message(strings.TrimPrefix(r.URL.Path, "/"))
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "message not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, msg)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+func (p *Provider) serveSend(w http.ResponseWriter, r *http.Request) {
+	var msg Message
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if msg.To == "" || msg.Body == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "to and body are required"})
+		return
+	}
+
+	p.Lock()
+	if status, ok := p.rejected[msg.To]; ok {
+		p.Unlock()
+		writeJSON(w, status, map[string]string{"error": fmt.Sprintf("recipient %s rejected", msg.To)})
+		return
+	}
+	msg.ID = fmt.Sprintf("msg_%d", len(p.messages)+1)
+	msg.Status = StatusQueued
+	msg.Error = ""
+	stored := msg
+	p.messages = append(p.messages, &stored)
+	p.byID[msg.ID] = &stored
+	autoDeliver := p.autoDeliver
+	p.Unlock()
+
+	writeJSON(w, http.StatusAccepted, msg)
+	if autoDeliver {
+		// after the response, like a real provider
+		p.deliveries.Go(func() error { return p.Deliver(msg.ID) })
+	}
+}
+
+func (p *Provider) setStatus(id, status, reason string) error {
+	p.Lock()
+	msg, ok := p.byID[id]
+	if !ok {
+		p.Unlock()
+		return fmt.Errorf("messaging: no message %s", id)
+	}
+	msg.Status = status
+	msg.Error = reason
+	url := msg.CallbackURL
+	if url == "" {
+		url = p.webhook
+	}
+	p.Unlock()
+
+	if url == "" {
+		return nil
+	}
+	b, _ := json.Marshal(StatusEvent{ID: id, Status: status, Error: reason, Time: time.Now().UTC()})
+	resp, err := p.client.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("messaging: posting %s of %s to %s: %w", status, id, url, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("messaging: posting %s of %s to %s: %s", status, id, url, resp.Status)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
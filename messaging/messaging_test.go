@@ -0,0 +1,92 @@
+package messaging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/fortnoxab/gohtmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func send(t *testing.T, url string, msg Message) (int, Message) {
+	b, _ := json.Marshal(msg)
+	resp, err := http.Post(url+MessagesPath, "application/json", bytes.NewReader(b))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	var sent Message
+	_ = json.NewDecoder(resp.Body).Decode(&sent)
+	return resp.StatusCode, sent
+}
+
+func TestSendAndDeliver(t *testing.T) {
+	hooks := gohtmock.New()
+	defer hooks.Close()
+	webhook := hooks.Mock("/hooks/status", "").SetMethod("POST")
+
+	mock := gohtmock.New()
+	defer mock.Close()
+	provider := New(WithWebhook(hooks.URL() + "/hooks/status"))
+	assert.NoError(t, mock.Install(provider))
+	provider.Reject("+4600000000", http.StatusBadRequest)
+
+	status, sent := send(t, mock.URL(), Message{To: "+46701234567", Body: "Your code is 1234"})
+	assert.Equal(t, http.StatusAccepted, status)
+	assert.Equal(t, "msg_1", sent.ID)
+	assert.Equal(t, StatusQueued, sent.Status)
+
+	status, _ = send(t, mock.URL(), Message{To: "+4600000000", Body: "hello"})
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = send(t, mock.URL(), Message{To: "+46701234567"})
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	assert.NoError(t, provider.Deliver("msg_1"))
+	resp, err := http.Get(mock.URL() + MessagesPath + "/msg_1")
+	assert.NoError(t, err)
+	var polled Message
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&polled))
+	resp.Body.Close()
+	assert.Equal(t, StatusDelivered, polled.Status)
+
+	resp, err = http.Get(mock.URL() + MessagesPath + "/msg_9")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	if assert.Len(t, webhook.Requests(), 1) {
+		var event StatusEvent
+		assert.NoError(t, json.Unmarshal(webhook.Requests()[0].Body, &event))
+		assert.Equal(t, "msg_1", event.ID)
+		assert.Equal(t, StatusDelivered, event.Status)
+	}
+
+	provider.AssertSent(t, "+46701234567", "code", "1234")
+	provider.AssertNotSent(t, "+4600000000")
+	provider.AssertSentCount(t, 1)
+	assert.EqualError(t, gohtmock.Check(func(tb testing.TB) { provider.AssertSent(tb, "+46701234567", "5678") }),
+		`messaging: no message to +46701234567 contains ["5678"], sent "Your code is 1234"`)
+	assert.Error(t, provider.Fail("msg_9", "unknown"))
+}
+
+func TestCallbackURLAndAutoDeliver(t *testing.T) {
+	hooks := gohtmock.New()
+	defer hooks.Close()
+	callback := hooks.Mock("/callback", "").SetMethod("POST")
+
+	mock := gohtmock.New()
+	defer mock.Close()
+	p, err := mock.InstallPreset("messaging")
+	assert.NoError(t, err)
+	provider := p.(*Provider)
+	WithAutoDeliver()(provider)
+
+	_, sent := send(t, mock.URL(), Message{To: "a@example.com", Subject: "Welcome", Body: "Hi", CallbackURL: hooks.URL() + "/callback"})
+	provider.Wait()
+	assert.Len(t, callback.Requests(), 1)
+	msg, ok := provider.Message(sent.ID)
+	assert.True(t, ok)
+	assert.Equal(t, StatusDelivered, msg.Status)
+	provider.AssertSent(t, "a@example.com", "Welcome")
+	provider.AssertWebhooksDelivered(t)
+}
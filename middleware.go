@@ -0,0 +1,52 @@
+package gohtmock
+
+import "net/http"
+
+// UseRequest adds fn to the functions applied to every request before it is
+// recorded and matched, in the order they were added. fn may modify r or
+// return a new request, for example to strip a gateway prefix or to
+// normalize headers. A nil result keeps the request unchanged.
+func (m *Mock) UseRequest(fn func(r *http.Request) *http.Request) {
+	m.Lock()
+	m.requestMiddleware = append(m.requestMiddleware, fn)
+	m.Unlock()
+}
+
+// applyRequestMiddleware returns r as transformed by the functions added
+// with UseRequest.
+func (m *Mock) applyRequestMiddleware(r *http.Request) *http.Request {
+	m.Lock()
+	middleware := m.requestMiddleware
+	m.Unlock()
+	for _, fn := range middleware {
+		if next := fn(r); next != nil {
+			r = next
+		}
+	}
+	return r
+}
+
+// UseResponse adds fn to the middleware wrapped around every matched mock.
+// fn answers the request, typically by calling next, which runs the
+// middleware added after it and finally the mock. Headers set by fn before
+// calling next are kept unless the mock sets them too.
+func (m *Mock) UseResponse(fn func(w http.ResponseWriter, r *http.Request, next http.Handler)) {
+	m.Lock()
+	m.responseMiddleware = append(m.responseMiddleware, fn)
+	m.Unlock()
+}
+
+// withResponseMiddleware wraps h in the middleware added with UseResponse.
+func (m *Mock) withResponseMiddleware(h http.HandlerFunc) http.Handler {
+	m.Lock()
+	middleware := m.responseMiddleware
+	m.Unlock()
+	var next http.Handler = h
+	for i := len(middleware) - 1; i >= 0; i-- {
+		fn, inner := middleware[i], next
+		next = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fn(w, r, inner)
+		})
+	}
+	return next
+}
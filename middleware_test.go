@@ -0,0 +1,83 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUseRequest(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.UseRequest(func(r *http.Request) *http.Request {
+		r.URL.Path = strings.TrimPrefix(r.URL.Path, "/gateway")
+		return r
+	})
+	mock.UseRequest(func(r *http.Request) *http.Request {
+		r.Header.Set("X-Tenant", strings.ToLower(r.Header.Get("X-Tenant")))
+		return nil
+	})
+	mr := mock.Mock("/users", `["u1"]`).Filter(func(r *http.Request) bool {
+		return r.Header.Get("X-Tenant") == "acme"
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, mock.URL()+"/gateway/users", nil)
+	req.Header.Set("X-Tenant", "ACME")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, `["u1"]`, string(body))
+	assert.Equal(t, "/users", mr.Requests()[0].URL.Path)
+	mock.AssertCallCount(t, "GET", "/users", 1)
+
+	req, _ = http.NewRequest(http.MethodGet, mock.URL()+"/gateway/users", nil)
+	req.Header.Set("X-Tenant", "Acme")
+	assert.Contains(t, mock.Explain(req), "is answered by GET /users")
+}
+
+func TestUseResponse(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	var order []string
+	mock.UseResponse(func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		order = append(order, "outer")
+		w.Header().Set("X-Request-Id", "42")
+		next.ServeHTTP(w, r)
+	})
+	mock.UseResponse(func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		order = append(order, "inner")
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+	mr := mock.Mock("/users", `["u1"]`)
+
+	req, _ := http.NewRequest(http.MethodGet, mock.URL()+"/users", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, `["u1"]`, string(body))
+	assert.Equal(t, "42", resp.Header.Get("X-Request-Id"))
+	assert.Equal(t, []string{"outer", "inner"}, order)
+
+	resp, err = http.Get(mock.URL() + "/users")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "42", resp.Header.Get("X-Request-Id"))
+	assert.Len(t, mr.Requests(), 2)
+
+	resp, err = http.Get(mock.URL() + "/missing")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "", resp.Header.Get("X-Request-Id"))
+	assert.Len(t, order, 4)
+}
@@ -1,115 +1,779 @@
 package gohtmock
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"regexp"
 	"sync"
+	"sync/atomic"
 	"testing"
-
-	"github.com/stretchr/testify/assert"
+	"time"
 )
 
 type Mock struct {
-	server                *httptest.Server
+	server        *httptest.Server
+	mockResponses []*mockResponse
+	healthPaths   map[string]bool
+	unhealthy     bool
+	partitions    map[string]*Partition
+	defaults      []defaultResponder
+	// notFound answers unmatched requests instead of a 404, see SetNotFoundHandler
+	notFound   http.Handler
+	scenarios  map[string]*Scenario
+	forbidden  []*forbidden
+	allowed    []allowed
+	allowOwner *owner
+	// disallowed describes the requests rejected by the allowlist
+	disallowed []string
+	// callSeq counts the calls answered by any mock
+	callSeq            uint64
+	requestMiddleware  []func(*http.Request) *http.Request
+	responseMiddleware []func(http.ResponseWriter, *http.Request, http.Handler)
+	// redirects and redirectLoops are kept for AssertRedirectFollowed
+	redirects         []*redirect
+	redirectLoops     []string
+	stopRedirectLoops bool
+	// tenantHeaders are the header keys of partitions created by Tenant
+	tenantHeaders []string
+	onAmbiguous   func(msg string)
+	// strict is the test failed by unmatched requests, see Strict
+	strict     testing.TB
+	verbose    bool
+	requestLog *requestLog
+	// set by options in New and never changed after
+	withoutRecording  bool
+	withoutAssertions bool
+	maxRecordedBody   int
+	maxHistory        int
+	rawHeaders        bool
+	leakDetection     bool
+	tls               bool
+	cleanupAssertions bool
+	colorDiffs        bool
+	jsonDiffs         bool
+	// inflight, leaks and afterClose are used by WithLeakDetection
+	inflight   map[*inflightRequest]bool
+	leaks      []string
+	afterClose net.Listener
+	// deferred holds the *deferredFailures of DeferFailures
+	deferred atomic.Value
+	counters
+	sync.Mutex
+}
+
+// counters holds the bookkeeping behind the assertions, keyed by method+path.
+type counters struct {
 	callCount             map[string]int
 	assertCallCountCalled map[string]bool
-	mockResponses         []*mockResponse
 	unmockedRequests      map[string]int
-	sync.Mutex
+	// unmatched keeps the first few unmocked requests per method+path
+	unmatched map[string][]*RecordedRequest
+	// statusCount counts the responses sent per call key and status code
+	statusCount map[string]map[int]int
+	// nearMisses describes the mocks that almost answered the unmocked
+	// requests per method+path, see noteNearMisses
+	nearMisses map[string][]string
 }
 
-func New() *Mock {
-	m := &Mock{
+func newCounters() counters {
+	return counters{
 		callCount:             make(map[string]int),
 		assertCallCountCalled: make(map[string]bool),
 		unmockedRequests:      make(map[string]int),
+		unmatched:             make(map[string][]*RecordedRequest),
+		statusCount:           make(map[string]map[int]int),
+		nearMisses:            make(map[string][]string),
+	}
+}
+
+// Option configures a Mock created by New.
+type Option func(*Mock)
+
+// WithoutRecording disables keeping copies of requests, see Requests.
+func WithoutRecording() Option {
+	return func(m *Mock) {
+		m.withoutRecording = true
+	}
+}
+
+// WithoutAssertions disables the per method and path bookkeeping behind the
+// Assert methods. Together with WithoutRecording it makes the mock cheap
+// enough to be used as a load target in benchmarks.
+func WithoutAssertions() Option {
+	return func(m *Mock) {
+		m.withoutAssertions = true
+	}
+}
+
+// WithMaxRecordedBody keeps only the first bytes of each recorded request
+// body, marking the RecordedRequest as Truncated. The rest of the body is
+// streamed to the mock instead of buffered.
+func WithMaxRecordedBody(bytes int) Option {
+	return func(m *Mock) {
+		m.maxRecordedBody = bytes
+	}
+}
+
+// WithMaxHistory keeps only the n most recent requests per mock, see DroppedRequests.
+func WithMaxHistory(n int) Option {
+	return func(m *Mock) {
+		m.maxHistory = n
+	}
+}
+
+func New(opts ...Option) *Mock {
+	m := &Mock{
+		counters:    newCounters(),
+		healthPaths: make(map[string]bool),
+		partitions:  make(map[string]*Partition),
+	}
+	for _, opt := range opts {
+		opt(m)
 	}
 
 	m.server = httptest.NewUnstartedServer(m)
-	m.server.Start()
+	if m.rawHeaders {
+		if m.tls {
+			panic("gohtmock: WithRawHeaders can not be combined with WithTLS")
+		}
+		m.recordRawHeaders()
+	}
+	if m.tls {
+		m.server.StartTLS()
+	} else {
+		m.server.Start()
+	}
 	return m
 }
 
 func (m *Mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if m.leakDetection {
+		defer m.trackInflight(r)()
+	}
+	if m.serveHealth(w, r) {
+		return
+	}
+	if m.rawHeaders {
+		r = withRawHeaders(r)
+	}
+	r = m.applyRequestMiddleware(r)
+	if m.serveForbidden(w, r) || m.serveDisallowed(w, r) {
+		return
+	}
+	m.Lock()
+	requestLog := m.requestLog
+	verbose := m.verbose
+	m.Unlock()
+	if requestLog == nil {
+		m.serve(w, r, !m.withoutRecording || verbose)
+		return
+	}
+
+	start := time.Now()
+	cw := newResponseCapture(w, requestLog.maxBody)
+	recorded, mr := m.serve(cw, r, true)
+	requestLog.write(recorded, mr, cw, time.Since(start))
+}
+
+// serve answers r and returns the recorded request, nil unless
+// recording, and the mock that answered it, nil if no mock matched.
+func (m *Mock) serve(w http.ResponseWriter, r *http.Request, recording bool) (recorded *RecordedRequest, mr *mockResponse) {
 	method := r.Method
 	path := r.URL.Path
-	var mr *mockResponse
+	// the body is buffered so that every filter and the responder can
+	// read it, see rewind
+	var err error
+	if recording {
+		recorded, err = record(r, m.maxRecordedBody)
+	} else {
+		_, _, err = bufferBody(r, m.maxRecordedBody)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "gohtmock: reading request body: %s", err)
+		return recorded, nil
+	}
+	via, loop := m.followRedirect(r, recorded)
+	if loop != "" {
+		serveRedirectLoop(w, loop)
+		return recorded, nil
+	}
+	var call int
+	var retry, pending *dedupedResponse
+	var guardHeader http.Header
+	var rejected *httptest.ResponseRecorder
+	// matching, reserving a call and counting it happens in one critical
+	// section so that concurrent requests can never exceed Times
 	m.Lock()
-	for _, v := range m.mockResponses {
-		if v.path == path && v.method == method && v.checkFilter(r) {
+	partition, c := m.partitionFor(r)
+	candidates := m.candidates(partition)
+	var depleted *mockResponse
+	for _, v := range candidates {
+		if !v.matches(method, path) {
+			continue
+		}
+		rewind(r)
+		if !v.checkFilter(r) {
+			continue
+		}
+		// guards run before reserving so that rejected requests, such as
+		// authentication challenges, neither use up Times nor count
+		if !v.depleted() {
+			if guardHeader, rejected = v.guard(r); rejected != nil {
+				mr = v
+				break
+			}
+		}
+		key := v.dedupeKey(r)
+		if key != "" {
+			if retry = v.retry(key); retry != nil {
+				mr = v
+				break
+			}
+		}
+		if calls, ok := v.reserve(); ok {
 			mr = v
+			// callbacks and delay schedules are indexed by the calls of
+			// the mock, whether or not the assertions count them too
+			call = calls
+			if !m.withoutAssertions {
+				ck := callKey(method, v.host, path)
+				c.callCount[ck]++
+				v.noteCounted(c, ck, 0)
+			}
+			if key != "" {
+				pending = v.startDedupe(key)
+			}
 			break
 		}
+		if depleted == nil {
+			depleted = v
+		}
+	}
+	var fallback http.Handler
+	notFound := m.notFound
+	if mr == nil {
+		fallback = m.defaultFor(r)
+	}
+	if mr == nil && fallback == nil {
+		noteUnmatchedContext(r)
+		if !m.withoutAssertions {
+			c.unmockedRequests[method+path]++
+			if recorded != nil && len(c.unmatched[method+path]) < maxReproduced {
+				c.unmatched[method+path] = append(c.unmatched[method+path], recorded)
+			}
+			noteNearMisses(c, r, candidates, partition)
+		}
+		m.logUnmatched(r, recorded)
+		m.noteUnmatched(r, depleted)
+		m.failStrict(r)
+	} else if mr != nil && retry == nil && rejected == nil {
+		m.checkAmbiguous(mr, candidates, method, path, r)
+		mr.notePath(r)
+		mr.advance()
+		m.callSeq++
+		mr.Lock()
+		if mr.firstCallSeq == 0 {
+			mr.firstCallSeq = m.callSeq
+		}
+		mr.Unlock()
 	}
 	m.Unlock()
+	rewind(r)
+	if fallback != nil {
+		fallback.ServeHTTP(w, r)
+		return recorded, nil
+	}
+	if mr == nil && notFound != nil {
+		rewind(r)
+		notFound.ServeHTTP(w, r)
+		return recorded, nil
+	}
 	if mr == nil {
 		w.WriteHeader(http.StatusNotFound)
 		fmt.Fprintf(w, "%s not found", path)
-		m.unmockedRequests[method+path]++
-		return
+		return recorded, nil
+	}
+	if rejected != nil {
+		m.withResponseMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			writeRecorded(w, rejected)
+		}).ServeHTTP(w, r)
+		return recorded, mr
+	}
+	for k, v := range guardHeader {
+		w.Header()[k] = v
 	}
 
+	mr.Lock()
+	// taken while locked so that arrivals are observed in the order of
+	// their timestamps
+	start := time.Now()
+	if !m.withoutRecording {
+		mr.addRequest(recorded, m.maxHistory)
+		mr.observeArrival(start)
+		defer mr.observeDuration(start)
+	}
+	mr.Unlock()
+	cw := &countingWriter{ResponseWriter: w, n: &mr.bytesServed}
+	defer func() {
+		if s := cw.sentStatus(); s != nil {
+			mr.noteSentStatus(*s)
+			if !m.withoutAssertions {
+				m.countStatus(c, mr, callKey(method, mr.host, path), s.Code)
+			}
+			if location := cw.Header().Get("Location"); location != "" && s.Code >= 300 && s.Code < 400 {
+				m.noteRedirect(r, via, recorded, mr, location)
+			}
+		}
+	}()
+	w = cw
+	if retry != nil {
+		retry.replay(w, r)
+		return recorded, mr
+	}
+	if pending != nil {
+		capture := newResponseCapture(w, -1)
+		defer pending.finish(capture)
+		w = capture
+	}
+	m.withResponseMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		mr.respond(w, r, call)
+	}).ServeHTTP(w, r)
+	return recorded, mr
+}
+
+// respond writes the response of mr to the call'th request it answers.
+func (mr *mockResponse) respond(w http.ResponseWriter, r *http.Request, call int) {
+	method := r.Method
+	path := r.URL.Path
+	r = mr.withPathParams(r)
 	mr.Lock()
 	for k, v := range mr.headers {
-		w.Header().Set(k, v)
+		w.Header()[k] = append([]string(nil), v...)
+	}
+	headerFuncs := mr.headerFuncs
+	mr.echo(w, r)
+	status := mr.status
+	resp := mr.resp
+	if variant, ok := mr.variant(w, r); ok {
+		resp = variant
 	}
+	handler := mr.handler
+	template := mr.template
+	delay := mr.delay
+	if n := len(mr.delaySchedule); n > 0 {
+		if call < n {
+			delay = mr.delaySchedule[call]
+		} else {
+			delay = mr.delaySchedule[n-1]
+		}
+	} else if mr.delayJitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(mr.delayJitter) + 1))
+	}
+	barrier := mr.barrier
+	gate := mr.gate
+	interim := mr.informational
+	fault := mr.fault
 	mr.Unlock()
 
-	var status int
-	m.Lock()
+	for _, h := range headerFuncs {
+		if v := h.fn(r); v != "" {
+			w.Header().Set(h.key, v)
+		}
+	}
+
+	if barrier != nil && !barrier.wait(r.Context()) {
+		return
+	}
+	if gate != nil && !gate.wait(r.Context()) {
+		return
+	}
+
+	sendInformational(w, interim)
+
 	if len(mr.callbacks) > 0 {
-		status = mr.callbacks[m.callCount[method+path]](r)
+		if call >= len(mr.callbacks) {
+			msg := fmt.Sprintf("%s %s called %d times but only %d callbacks given%s", method, path, call+1, len(mr.callbacks), mr.ownedBy())
+			if !mr.fail(msg) {
+				panic(msg)
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, msg)
+			return
+		}
+		if cbStatus := mr.callbacks[call](r); cbStatus != 0 {
+			status = cbStatus
+		}
 	}
-	m.Unlock()
 
-	m.Lock()
-	m.callCount[method+path]++
-	m.Unlock()
-	if status != 0 {
-		w.WriteHeader(status)
+	if !sleep(r.Context(), delay) {
+		return
+	}
+
+	if mr.serveCached(w, r) {
+		return
+	}
+
+	if fault != faultNone && !canHijack(w) {
+		mr.serveError(w, r, errors.New("DropConnection and ResetConnection need a connection to break, which this request does not have"))
+		return
+	}
+	if handler != nil {
+		if fault != faultNone {
+			if err := breakConnection(w, fault, 0, "", nil); err != nil {
+				mr.report("breaking connection of %s %s: %s%s", method, path, err, mr.ownedBy())
+			}
+			return
+		}
+		handler(w, r)
+		return
+	}
+	body, err := resolveBody(resp)
+	if err == nil && template {
+		body, err = mr.render(body, r)
 	}
-	_, err := w.Write([]byte(mr.resp))
 	if err != nil {
-		log.Fatal("error writing respose for ", path, err)
+		mr.fail("resolving response for %s %s: %s%s", method, path, err, mr.ownedBy())
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "gohtmock: resolving response for %s: %s", path, err)
+		return
+	}
+	if err := mr.writeBody(w, status, body); err != nil {
+		mr.report("writing response for %s %s: %s%s", method, path, err, mr.ownedBy())
 	}
 }
 
 type mockResponse struct {
-	resp      string
-	path      string
-	headers   map[string]string
+	resp    string
+	path    string
+	headers http.Header
+	// headerFuncs are set by SetHeaderFunc
+	headerFuncs []headerFunc
+	// host and query are set by MockURL
+	host      string
+	query     url.Values
 	method    string
 	httpMock  *Mock
 	callbacks []func(*http.Request) int
+	handler   http.HandlerFunc
 	filter    func(*http.Request) bool
+	partition string
+	owner     *owner
+	// pathPattern replaces the exact path match when set
+	pathPattern *regexp.Regexp
+	// matchedPaths counts the calls per path of mocks with a pathPattern
+	matchedPaths map[string]int
+	calls        int
+	status       int
+	times        int
+	delay        time.Duration
+	// delayJitter is set by DelayRange, a random part of it is added to delay
+	delayJitter time.Duration
+	// delaySchedule is set by DelaySchedule and replaces delay
+	delaySchedule []time.Duration
+	requests      []*RecordedRequest
+	// droppedRequests counts requests removed from requests by WithMaxHistory
+	droppedRequests int
+	// bytesServed is updated atomically
+	bytesServed int64
+	gzip        bool
+	barrier     *barrier
+	tags        []string
+	template    bool
+	echoHeaders []string
+	cache       *cacheState
+	variants    []variants
+	lengthMode  int
+	wrongLength int
+	// fault is set by DropConnection and ResetConnection
+	fault  int
+	reason string
+	// rawHeaderMatchers are set by MatchRawHeader
+	rawHeaderMatchers []RawHeader
+	// messageMatchers are set by MatchMessage
+	messageMatchers []func([]byte) bool
+	// bodyMatchers are set by MatchBody and MatchBodyEncoded
+	bodyMatchers []bodyMatcher
+	// requestMatchers are set by MatchRemoteAddr and MatchForwardedFor
+	requestMatchers []requestMatcher
+	// guards check requests before they are answered, see Guard
+	guards []func(http.ResponseWriter, *http.Request) bool
+	// sentStatuses are the status lines of the responses sent
+	sentStatuses []SentStatus
+	// counted are the calls and statuses mr added to the counters of the
+	// assertions, so that ResetByTag can take them back
+	counted map[countedKey]int
+	// dedupeHeader, deduped and retries are set by DedupeBy
+	dedupeHeader string
+	deduped      map[string]*dedupedResponse
+	retries      int
+	// priority is set by Priority, see there for locking
+	priority int
+	// firstCallSeq orders the first calls of all mocks, 0 if never called
+	firstCallSeq uint64
+	// scenario, givenState and nextState are set by Scenario steps
+	scenario   *Scenario
+	givenState string
+	nextState  string
+	gate       *Gate
+	// lastArrival, interArrivals and durations are observed latencies
+	lastArrival   time.Time
+	interArrivals []time.Duration
+	durations     []time.Duration
+	gzipCache     *gzipCache
+	// informational are set by Informational and EarlyHints
+	informational []informational
+	// disabled is set by Disable
+	disabled bool
+	// registeredAt is the file:line that registered the mock
+	registeredAt string
 	sync.Mutex
 }
 
+// SetHeader sets a response header, replacing all earlier values.
 func (mr *mockResponse) SetHeader(key, value string) *mockResponse {
 	mr.Lock()
-	mr.headers[key] = value
+	mr.headers.Set(key, value)
+	mr.Unlock()
+	return mr
+}
+
+// SetHeaderFunc sets the response header key to the value fn computes from
+// the request, for example a Location or pagination Link. It is applied
+// after the static headers and replaces their values. An empty result
+// leaves the header unchanged.
+func (mr *mockResponse) SetHeaderFunc(key string, fn func(r *http.Request) string) *mockResponse {
+	mr.Lock()
+	mr.headerFuncs = append(mr.headerFuncs, headerFunc{key: key, fn: fn})
+	mr.Unlock()
+	return mr
+}
+
+type headerFunc struct {
+	key string
+	fn  func(*http.Request) string
+}
+
+// AddHeader adds a response header value, keeping values added earlier.
+func (mr *mockResponse) AddHeader(key, value string) *mockResponse {
+	mr.Lock()
+	mr.headers.Add(key, value)
+	mr.Unlock()
+	return mr
+}
+
+// WithStatus sets the status code of the response. A status returned by a
+// callback takes precedence.
+func (mr *mockResponse) WithStatus(status int) *mockResponse {
+	mr.Lock()
+	mr.status = status
+	mr.Unlock()
+	return mr
+}
+
+// Status is WithStatus, e.g. Mock("/users/2", "not found").Status(404).
+func (mr *mockResponse) Status(status int) *mockResponse {
+	return mr.WithStatus(status)
+}
+
+// WithDelay makes the mock wait d before responding. If the client gives
+// up before, e.g. on a timeout, the mock does not respond at all.
+func (mr *mockResponse) WithDelay(d time.Duration) *mockResponse {
+	mr.Lock()
+	mr.delay = d
+	mr.delayJitter = 0
+	mr.Unlock()
+	return mr
+}
+
+// Delay is WithDelay.
+func (mr *mockResponse) Delay(d time.Duration) *mockResponse {
+	return mr.WithDelay(d)
+}
+
+// DelayRange is WithDelay with a delay picked at random between min and max
+// for every call.
+func (mr *mockResponse) DelayRange(min, max time.Duration) *mockResponse {
+	if max < min {
+		min, max = max, min
+	}
+	mr.Lock()
+	mr.delay = min
+	mr.delayJitter = max - min
+	mr.Unlock()
+	return mr
+}
+
+// DelaySchedule makes the mock wait delays[i] before responding to its i'th
+// call, counted like the callbacks of Mock. Calls after the last delay wait
+// as long as the last one. It takes precedence over WithDelay.
+func (mr *mockResponse) DelaySchedule(delays ...time.Duration) *mockResponse {
+	mr.Lock()
+	mr.delaySchedule = delays
 	mr.Unlock()
 	return mr
 }
+
 func (mr *mockResponse) SetMethod(method string) *mockResponse {
 	mr.Lock()
 	mr.method = method
 	mr.Unlock()
 	return mr
 }
+
+// Filter makes mr only answer requests callback returns true for. callback
+// may read the body, the next filter and the responder still get all of it.
 func (mr *mockResponse) Filter(callback func(*http.Request) bool) *mockResponse {
 	mr.Lock()
 	mr.filter = callback
 	mr.Unlock()
 	return mr
 }
+
+// Guard makes mr call fn before answering a request it matched. If fn
+// returns false it has written the response itself, such as an
+// authentication challenge, and mr does not answer. Rejected requests do
+// not count as calls of mr, neither for Times nor for the assertions. fn
+// runs while the mock is locked, like filters, and its response is
+// buffered.
+func (mr *mockResponse) Guard(fn func(http.ResponseWriter, *http.Request) bool) *mockResponse {
+	mr.Lock()
+	mr.guards = append(mr.guards, fn)
+	mr.Unlock()
+	return mr
+}
+
+// guard runs the guards of mr on r. It returns the headers set by guards
+// letting r pass, or the response of the guard rejecting it. m must be
+// locked.
+func (mr *mockResponse) guard(r *http.Request) (http.Header, *httptest.ResponseRecorder) {
+	mr.Lock()
+	guards := mr.guards
+	mr.Unlock()
+	if len(guards) == 0 {
+		return nil, nil
+	}
+	r = mr.withPathParams(r)
+	rec := httptest.NewRecorder()
+	for _, guard := range guards {
+		rewind(r)
+		if !guard(rec, r) {
+			return nil, rec
+		}
+	}
+	return rec.Header(), nil
+}
+
+// writeRecorded writes the response held by rec to w.
+func writeRecorded(w http.ResponseWriter, rec *httptest.ResponseRecorder) {
+	for k, v := range rec.Header() {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(rec.Code)
+	_, _ = w.Write(rec.Body.Bytes())
+}
+
+// Update replaces the response body of mr for the requests that follow,
+// also if mr was registered with a handler. Calls, history and other
+// settings are kept.
+func (mr *mockResponse) Update(resp string) *mockResponse {
+	mr.Lock()
+	mr.resp = resp
+	mr.handler = nil
+	mr.Unlock()
+	return mr
+}
+
+// UpdateResponder makes fn answer the requests that follow instead of the
+// current response or handler of mr.
+func (mr *mockResponse) UpdateResponder(fn http.HandlerFunc) *mockResponse {
+	mr.Lock()
+	mr.handler = fn
+	mr.Unlock()
+	return mr
+}
+
+// Times limits the mock to answer n requests. Later requests fall through
+// to other mocks or are treated as not mocked.
+func (mr *mockResponse) Times(n int) *mockResponse {
+	mr.Lock()
+	mr.times = n
+	mr.Unlock()
+	return mr
+}
+
+func (mr *mockResponse) Once() *mockResponse {
+	return mr.Times(1)
+}
+
+func (mr *mockResponse) depleted() bool {
+	mr.Lock()
+	defer mr.Unlock()
+	return mr.times > 0 && mr.calls >= mr.times
+}
+
+// reserve counts a call to mr unless it has already answered Times
+// requests. It returns the number of calls before this one.
+func (mr *mockResponse) reserve() (int, bool) {
+	mr.Lock()
+	defer mr.Unlock()
+	if mr.times > 0 && mr.calls >= mr.times {
+		return mr.calls, false
+	}
+	mr.calls++
+	return mr.calls - 1, true
+}
+
+// matches reports if method and path select mr. The method ANY matches all methods.
+func (mr *mockResponse) matches(method, path string) bool {
+	mr.Lock()
+	methodMatches := mr.method == method || mr.method == "ANY"
+	mr.Unlock()
+	return methodMatches && mr.matchesPath(path)
+}
+
+// matchesPath reports if mr answers requests to path, whatever their method.
+func (mr *mockResponse) matchesPath(path string) bool {
+	mr.Lock()
+	defer mr.Unlock()
+	if mr.pathPattern != nil {
+		return mr.pathPattern.MatchString(path)
+	}
+	return mr.path == path
+}
+
 func (mr *mockResponse) checkFilter(r *http.Request) bool {
-	if mr.filter == nil {
+	if mr.isDisabled() || !mr.inState() || !mr.matchesURL(r) || !mr.matchesRawHeaders(r) || !mr.matchesMessage(r) || !mr.matchesBody(r) || mr.failedMatcher(r) != "" {
+		return false
+	}
+	mr.Lock()
+	filter := mr.filter
+	mr.Unlock()
+	if filter == nil {
+		return true
+	}
+	return mr.runFilter(filter, r)
+}
+
+// sleep waits d, returning false if ctx is done before.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
 		return true
+	case <-ctx.Done():
+		return false
 	}
-	return mr.filter(r)
 }
 
 func (m *Mock) URL() string {
@@ -117,57 +781,157 @@ func (m *Mock) URL() string {
 }
 
 func (m *Mock) Close() {
+	if m.leakDetection {
+		m.noteInflight()
+	}
+	// long lived responses such as streams would otherwise block Close forever
+	m.server.CloseClientConnections()
 	m.server.Close()
+	m.Lock()
+	if m.requestLog != nil {
+		m.requestLog.close()
+		m.requestLog = nil
+	}
+	m.Unlock()
+	if m.leakDetection {
+		m.listenAfterClose()
+	}
 }
 
+// Mock registers a mock answering GET requests to path with resp. path may
+// be a pattern like those of net/http.ServeMux, such as "/users/{id}",
+// "/files/{path...}" or "POST /orders", or end in a * matching the rest of
+// the path. The captured segments are available through PathParams.
 func (m *Mock) Mock(path, resp string, callback ...func(*http.Request) int) *mockResponse {
+	mr := m.newMockResponse(path, resp)
+	mr.callbacks = callback
+	m.add(mr)
+	return mr
+}
+
+// MockFunc registers a mock where fn writes the whole response. It is meant
+// for responses that can not be described by a static body, such as streams.
+func (m *Mock) MockFunc(path string, fn http.HandlerFunc) *mockResponse {
+	mr := m.newMockResponse(path, "")
+	mr.handler = fn
+	m.add(mr)
+	return mr
+}
+
+// newMockResponse returns a mock for path, which may be a pattern as
+// described by Mock. It panics if the pattern is invalid.
+func (m *Mock) newMockResponse(path, resp string) *mockResponse {
+	method, path := splitMethod(path)
+	if method == "" {
+		method = "GET"
+	}
 	mr := &mockResponse{
-		callbacks: callback,
-		resp:      resp,
-		path:      path,
-		headers:   make(map[string]string),
-		method:    "GET",
-		httpMock:  m,
-	}
-	mr.headers["content-type"] = "application/json" // default here
+		resp:         resp,
+		path:         path,
+		headers:      make(http.Header),
+		method:       method,
+		httpMock:     m,
+		registeredAt: callerOutsidePackage(),
+	}
+	if isPathPattern(path) {
+		re, err := compilePathPattern(path)
+		if err != nil {
+			panic(fmt.Sprintf("gohtmock: invalid path pattern %s: %s", path, err))
+		}
+		mr.pathPattern = re
+	}
+	mr.headers.Set("Content-Type", "application/json") // default here
+	return mr
+}
+
+func (m *Mock) add(mr *mockResponse) {
 	m.Lock()
 	m.mockResponses = append(m.mockResponses, mr)
 	m.Unlock()
-
-	return mr
 }
 
 func (m *Mock) AssertCallCount(tb testing.TB, method, path string, expected int) {
+	m.assertCallCount(tb, &m.counters, method, path, expected)
+}
+
+func (m *Mock) AssertCallCountAsserted(tb testing.TB) {
+	m.assertCallCountAsserted(tb, &m.counters)
+}
+
+func (m *Mock) AssertNoMissingMocks(tb testing.TB) {
+	m.assertNoMissingMocks(tb, &m.counters)
+}
+
+func (m *Mock) AssertMocksCalled(tb testing.TB) {
+	m.assertMocksCalled(tb, &m.counters, "")
+}
+
+// assertionsDisabled fails tb if the mock was created WithoutAssertions.
+func (m *Mock) assertionsDisabled(tb testing.TB) bool {
+	if m.withoutAssertions {
+		tb.Errorf("assertions are disabled by WithoutAssertions")
+	}
+	return m.withoutAssertions
+}
+
+func (m *Mock) assertCallCount(tb testing.TB, c *counters, method, path string, expected int) {
+	if m.assertionsDisabled(tb) {
+		return
+	}
 	m.Lock()
-	cnt, ok := m.callCount[method+path]
+	key := assertKey(method, path)
+	cnt, ok := c.callCount[key]
 	if !ok {
 		tb.Errorf("mocked but never called path: %s method: %s", path, method)
 		m.Unlock()
 		return
 	}
-	m.assertCallCountCalled[method+path] = true
+	c.assertCallCountCalled[key] = true
 	m.Unlock()
-	assert.Equal(tb, expected, cnt, path)
+	if cnt != expected {
+		tb.Errorf("%s %s called %d times, expected %d", method, path, cnt, expected)
+	}
 }
 
-func (m *Mock) AssertCallCountAsserted(tb testing.TB) {
-	for url, cnt := range m.callCount {
-		if _, ok := m.assertCallCountCalled[url]; !ok {
+func (m *Mock) assertCallCountAsserted(tb testing.TB, c *counters) {
+	if m.assertionsDisabled(tb) {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+	for url, cnt := range c.callCount {
+		if _, ok := c.assertCallCountCalled[url]; !ok {
 			tb.Errorf("url: %s is mocked but never asserted. It was called %d times", url, cnt)
 		}
 	}
 }
 
-func (m *Mock) AssertNoMissingMocks(tb testing.TB) {
-	for url, cnt := range m.unmockedRequests {
-		tb.Errorf("url: %s is called but not mocked. It was called %d times", url, cnt)
+func (m *Mock) assertNoMissingMocks(tb testing.TB, c *counters) {
+	if m.assertionsDisabled(tb) {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+	for url, cnt := range c.unmockedRequests {
+		tb.Errorf("url: %s is called but not mocked. It was called %d times%s%s", url, cnt, formatNearMisses(c.nearMisses[url]), reproduce(c.unmatched[url]))
 	}
 }
 
-func (m *Mock) AssertMocksCalled(tb testing.TB) {
+func (m *Mock) assertMocksCalled(tb testing.TB, c *counters, partition string) {
+	if m.assertionsDisabled(tb) {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
 	for _, mr := range m.mockResponses {
-		if _, ok := m.callCount[mr.method+mr.path]; !ok {
-			tb.Errorf("%s %s mocked but never called.", mr.method, mr.path)
+		if mr.partition != partition {
+			continue
+		}
+		mr.Lock()
+		calls := mr.calls
+		mr.Unlock()
+		if _, ok := c.callCount[callKey(mr.method, mr.host, mr.path)]; !ok && calls == 0 {
+			tb.Errorf("%s %s mocked but never called.%s", mr.method, mr.path, mr.ownedBy())
 		}
 	}
 }
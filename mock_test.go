@@ -1,8 +1,12 @@
 package gohtmock
 
 import (
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -73,3 +77,108 @@ func TestNotAssertNoMissingMocks(t *testing.T) {
 	mock.AssertNoMissingMocks(newT)
 	assert.True(t, newT.Failed())
 }
+
+// recordingT records failures instead of failing, for asserting on messages.
+type recordingT struct {
+	*testing.T
+	name     string
+	errors   []string
+	cleanups []func()
+	sync.Mutex
+}
+
+func newRecordingT(name string) *recordingT {
+	return &recordingT{T: &testing.T{}, name: name}
+}
+
+func (r *recordingT) Name() string {
+	return r.name
+}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.Lock()
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+	r.Unlock()
+}
+
+func (r *recordingT) Cleanup(f func()) {
+	r.cleanups = append(r.cleanups, f)
+}
+
+func (r *recordingT) runCleanups() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
+
+func (r *recordingT) Errors() []string {
+	r.Lock()
+	defer r.Unlock()
+	return append([]string(nil), r.errors...)
+}
+
+func mustParseURL(s string) *url.URL {
+	u, err := url.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+func TestRepeatedHeaders(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/login", "{}").
+		SetHeader("content-type", "text/plain").
+		SetHeader("Content-Type", "application/problem+json").
+		AddHeader("Set-Cookie", "session=1").
+		AddHeader("set-cookie", "theme=dark").
+		SetHeader("X-Version", "1").
+		AddHeader("X-Version", "2")
+
+	resp, err := http.Get(mock.URL() + "/login")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []string{"application/problem+json"}, resp.Header.Values("Content-Type"))
+	assert.Equal(t, []string{"session=1", "theme=dark"}, resp.Header.Values("Set-Cookie"))
+	assert.Equal(t, []string{"1", "2"}, resp.Header.Values("X-Version"))
+	assert.Len(t, resp.Cookies(), 2)
+}
+
+func TestSetHeaderFunc(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/items", "[]").
+		SetHeader("Link", "static").
+		SetHeaderFunc("Link", func(r *http.Request) string {
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			return fmt.Sprintf(`</items?page=%d>; rel="next"`, page+1)
+		}).
+		SetHeaderFunc("ETag", func(r *http.Request) string { return "" })
+
+	resp, err := http.Get(mock.URL() + "/items?page=2")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []string{`</items?page=3>; rel="next"`}, resp.Header.Values("Link"))
+	assert.Equal(t, "", resp.Header.Get("ETag"))
+}
+
+func TestStatus(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/users/2", `{"error":"not found"}`).Status(http.StatusNotFound)
+	mock.Mock("/users/3", "{}", func(*http.Request) int { return http.StatusGone }).Status(http.StatusNotFound)
+
+	resp, err := http.Get(mock.URL() + "/users/2")
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, `{"error":"not found"}`, string(body))
+	}
+	resp, err = http.Get(mock.URL() + "/users/3")
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusGone, resp.StatusCode)
+	}
+}
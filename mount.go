@@ -0,0 +1,76 @@
+package gohtmock
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Mount registers h to answer all requests to prefix and the paths below
+// it, with prefix stripped from the path h sees. The requests are recorded
+// and counted like those of other mocks, and the ones h answers with 404
+// are reported by AssertNoMissingMocks. It lets an existing fake, such as a
+// generated stub, live inside the mock server.
+func (m *Mock) Mount(prefix string, h http.Handler) *mockResponse {
+	prefix = strings.TrimSuffix(prefix, "/")
+	mr := m.MockFunc(prefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		mw := &mountWriter{ResponseWriter: w}
+		h.ServeHTTP(mw, stripPrefix(r, prefix))
+		if mw.status == http.StatusNotFound {
+			m.noteMountMiss(r)
+		}
+	}).SetMethod("ANY")
+	mr.Lock()
+	mr.pathPattern = regexp.MustCompile("^" + regexp.QuoteMeta(prefix) + "(/.*)?$")
+	mr.Unlock()
+	return mr
+}
+
+// stripPrefix returns a shallow copy of r with prefix removed from its path.
+func stripPrefix(r *http.Request, prefix string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	u := *r.URL
+	u.Path = strings.TrimPrefix(r.URL.Path, prefix)
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.RawPath = ""
+	r2.URL = &u
+	return r2
+}
+
+// noteMountMiss counts r, answered with 404 by a mounted handler, as not
+// mocked.
+func (m *Mock) noteMountMiss(r *http.Request) {
+	if m.withoutAssertions {
+		return
+	}
+	m.Lock()
+	_, c := m.partitionFor(r)
+	c.unmockedRequests[r.Method+r.URL.Path]++
+	m.Unlock()
+}
+
+// mountWriter notes the status a mounted handler writes.
+type mountWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *mountWriter) WriteHeader(status int) {
+	if w.status == 0 && !isInformational(status) {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *mountWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *mountWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
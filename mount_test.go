@@ -0,0 +1,46 @@
+package gohtmock
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMount(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	fake := http.NewServeMux()
+	fake.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `["fake"]`)
+	})
+	mr := mock.Mount("/legacy/", fake)
+	mock.Mock("/other", "{}")
+
+	resp, err := http.Get(mock.URL() + "/legacy/users")
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `["fake"]`, string(body))
+
+	resp, err = http.Post(mock.URL()+"/legacy/missing", "application/json", nil)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Get(mock.URL() + "/legacyusers")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	if assert.Len(t, mr.Requests(), 2) {
+		assert.Equal(t, "/legacy/users", mr.Requests()[0].URL.Path)
+	}
+	mock.AssertCallCount(t, "GET", "/legacy/users", 1)
+	rt := newRecordingT("TestMount")
+	mock.AssertNoMissingMocks(rt)
+	assert.Len(t, rt.Errors(), 2)
+}
@@ -0,0 +1,20 @@
+// Package msgpack provides a gohtmock.Codec for MessagePack bodies.
+package msgpack
+
+import (
+	"github.com/fortnoxab/gohtmock"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ContentType is the content type of MessagePack bodies.
+const ContentType = "application/msgpack"
+
+// Codec encodes and decodes MessagePack, for use with MockEncoded,
+// MatchBodyEncoded and HandleEncoded.
+var Codec gohtmock.Codec = codec{}
+
+type codec struct{}
+
+func (codec) ContentType() string                { return ContentType }
+func (codec) Marshal(v any) ([]byte, error)      { return msgpack.Marshal(v) }
+func (codec) Unmarshal(data []byte, v any) error { return msgpack.Unmarshal(data, v) }
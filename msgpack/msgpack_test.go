@@ -0,0 +1,56 @@
+package msgpack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/fortnoxab/gohtmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type reading struct {
+	Sensor string  `msgpack:"sensor"`
+	Value  float64 `msgpack:"value"`
+}
+
+func TestCodec(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	mock.MockEncoded("/config", Codec, map[string]any{"interval": 30}).
+		SetMethod("POST").
+		MatchBodyEncoded(Codec, reading{Sensor: "t1", Value: 21.5})
+
+	body, _ := msgpack.Marshal(map[string]any{"sensor": "t1", "value": 21.5})
+	resp, err := http.Post(mock.URL()+"/config", ContentType, bytes.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, ContentType, resp.Header.Get("Content-Type"))
+	var config struct {
+		Interval int `msgpack:"interval"`
+	}
+	assert.NoError(t, msgpack.Unmarshal(b, &config))
+	assert.Equal(t, 30, config.Interval)
+}
+
+func TestHandleEncoded(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	gohtmock.HandleEncoded(mock, Codec, "POST", "/readings", func(in reading) (reading, int, error) {
+		in.Value *= 2
+		return in, http.StatusCreated, nil
+	})
+
+	body, _ := msgpack.Marshal(reading{Sensor: "t1", Value: 2})
+	resp, err := http.Post(mock.URL()+"/readings", ContentType, bytes.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var out reading
+	assert.NoError(t, msgpack.Unmarshal(b, &out))
+	assert.Equal(t, reading{Sensor: "t1", Value: 4}, out)
+}
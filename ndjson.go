@@ -0,0 +1,65 @@
+package gohtmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// NDJSONItem is a record of MockNDJSONScript, sent after Delay.
+type NDJSONItem struct {
+	Value any
+	Delay time.Duration
+}
+
+// MockNDJSON streams items as newline delimited JSON, flushing after every
+// record so that clients see them one at a time. It panics if an item can
+// not be marshalled.
+func (m *Mock) MockNDJSON(path string, items []any) *mockResponse {
+	script := make([]NDJSONItem, len(items))
+	for i, item := range items {
+		script[i].Value = item
+	}
+	return m.MockNDJSONScript(path, script)
+}
+
+// MockNDJSONScript is MockNDJSON with a delay before each record. The
+// stream stops early if the client goes away.
+func (m *Mock) MockNDJSONScript(path string, items []NDJSONItem) *mockResponse {
+	records := make([][]byte, len(items))
+	for i, item := range items {
+		b, err := json.Marshal(item.Value)
+		if err != nil {
+			panic(fmt.Sprintf("gohtmock: MockNDJSON: item %d: %s", i, err))
+		}
+		records[i] = append(b, '\n')
+	}
+	var mr *mockResponse
+	mr = m.MockFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		mr.Lock()
+		status := mr.status
+		mr.Unlock()
+		flusher, _ := w.(http.Flusher)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		if status != 0 {
+			w.WriteHeader(status)
+		}
+		for i, record := range records {
+			if items[i].Delay > 0 {
+				select {
+				case <-time.After(items[i].Delay):
+				case <-r.Context().Done():
+					return
+				}
+			}
+			if _, err := w.Write(record); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	})
+	return mr
+}
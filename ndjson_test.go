@@ -0,0 +1,49 @@
+package gohtmock
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockNDJSON(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.MockNDJSON("/events", []any{map[string]int{"id": 1}, "two"})
+
+	resp, err := http.Get(mock.URL() + "/events")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "{\"id\":1}\n\"two\"\n", string(b))
+}
+
+func TestMockNDJSONScript(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.MockNDJSONScript("/events", []NDJSONItem{
+		{Value: 1},
+		{Value: 2, Delay: time.Hour},
+	})
+
+	resp, err := http.Get(mock.URL() + "/events")
+	assert.NoError(t, err)
+	// the first record arrives while the second is still delayed
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "1\n", line)
+	resp.Body.Close()
+}
+
+func TestMockNDJSONPanicsOnInvalidItem(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	assert.PanicsWithValue(t, "gohtmock: MockNDJSON: item 0: json: unsupported type: chan int", func() {
+		mock.MockNDJSON("/events", []any{make(chan int)})
+	})
+}
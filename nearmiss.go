@@ -0,0 +1,57 @@
+package gohtmock
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxNearMisses limits the near misses kept per method+path.
+const maxNearMisses = 5
+
+// noteNearMisses keeps, for the unmocked r, the mocks among candidates
+// registered for its path and why they did not answer it: another method,
+// a filter or matcher returning false, Times or Disable. m must be locked.
+func noteNearMisses(c *counters, r *http.Request, candidates []*mockResponse, partition string) {
+	key := r.Method + r.URL.Path
+	for _, mr := range candidates {
+		if !mr.matchesPath(r.URL.Path) {
+			continue
+		}
+		mr.Lock()
+		calls := mr.calls
+		mr.Unlock()
+		rewind(r)
+		// the filter is not called again, if nothing else rejects r it
+		// was the filter
+		reason := mr.rejectionBeforeFilter(r.Method, r.URL.Path, partition, r, calls)
+		if reason == "" {
+			mr.Lock()
+			filtered := mr.filter != nil
+			mr.Unlock()
+			if !filtered {
+				continue
+			}
+			reason = "filter returned false"
+		}
+		miss := fmt.Sprintf("%s %s (%s): %s", mr.method, mr.path, mr.registeredAt, reason)
+		if len(c.nearMisses[key]) < maxNearMisses && !containsString(c.nearMisses[key], miss) {
+			c.nearMisses[key] = append(c.nearMisses[key], miss)
+		}
+	}
+	rewind(r)
+}
+
+// formatNearMisses formats near misses as the continuation of a failure
+// message, empty if there are none.
+func formatNearMisses(misses []string) string {
+	if len(misses) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\tnearest mocks:")
+	for _, miss := range misses {
+		fmt.Fprintf(&b, "\n\t  %s", strings.ReplaceAll(miss, "\n", "\n\t    "))
+	}
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package gohtmock
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNearMisses(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/users", "once").Times(1)
+	mock.Mock("POST /users", "created")
+	mock.Mock("/users", "admins").Filter(func(r *http.Request) bool { return r.URL.Query().Get("role") == "admin" })
+	mock.Mock("/users/{id}", "user")
+
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(mock.URL() + "/users")
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	unmatched := mock.UnmatchedRequests()
+	if assert.Len(t, unmatched, 1) {
+		assert.Equal(t, 2, unmatched[0].Count)
+		assert.Equal(t, []string{
+			"GET /users (nearmiss_test.go:15): filter returned false",
+			"GET /users (nearmiss_test.go:13): depleted after 1 calls",
+			"POST /users (nearmiss_test.go:14): method GET does not match",
+		}, unmatched[0].NearMisses)
+	}
+	err := Check(mock.AssertNoMissingMocks)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "url: GET/users is called but not mocked. It was called 2 times\n\tnearest mocks:\n\t  GET /users (nearmiss_test.go:15): filter returned false\n")
+	}
+}
@@ -0,0 +1,77 @@
+package gohtmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// MockOption configures a mock registered with MockWith.
+type MockOption func(*mockResponse)
+
+// MockWith registers a mock configured by opts, an alternative to chaining
+// that is easy to build from test tables or configuration.
+func (m *Mock) MockWith(path string, opts ...MockOption) *mockResponse {
+	mr := m.newMockResponse(path, "")
+	for _, opt := range opts {
+		opt(mr)
+	}
+	m.add(mr)
+	return mr
+}
+
+func WithMethod(method string) MockOption {
+	return func(mr *mockResponse) {
+		mr.method = method
+	}
+}
+
+func WithStatus(status int) MockOption {
+	return func(mr *mockResponse) {
+		mr.status = status
+	}
+}
+
+func WithBody(body string) MockOption {
+	return func(mr *mockResponse) {
+		mr.resp = body
+	}
+}
+
+// WithJSONBody marshals v as the body. It panics if v can not be marshaled.
+func WithJSONBody(v any) MockOption {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("gohtmock: WithJSONBody: %s", err))
+	}
+	return func(mr *mockResponse) {
+		mr.resp = string(b)
+		mr.headers.Set("Content-Type", "application/json")
+	}
+}
+
+func WithHeader(key, value string) MockOption {
+	return func(mr *mockResponse) {
+		mr.headers.Set(key, value)
+	}
+}
+
+// WithDelay makes the mock wait d before responding.
+func WithDelay(d time.Duration) MockOption {
+	return func(mr *mockResponse) {
+		mr.delay = d
+	}
+}
+
+func WithTimes(n int) MockOption {
+	return func(mr *mockResponse) {
+		mr.times = n
+	}
+}
+
+func WithFilter(filter func(*http.Request) bool) MockOption {
+	return func(mr *mockResponse) {
+		mr.filter = filter
+	}
+}
@@ -0,0 +1,151 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockWith(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.MockWith("/users",
+		WithMethod("POST"),
+		WithStatus(http.StatusCreated),
+		WithJSONBody(map[string]int{"id": 1}),
+		WithHeader("Location", "/users/1"),
+		WithDelay(20*time.Millisecond),
+		WithTimes(2),
+	)
+
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		resp, err := http.Post(mock.URL()+"/users", "application/json", nil)
+		assert.NoError(t, err)
+		assert.True(t, time.Since(start) >= 20*time.Millisecond)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "/users/1", resp.Header.Get("Location"))
+		body, _ := ioutil.ReadAll(resp.Body)
+		assert.Equal(t, `{"id":1}`, string(body))
+	}
+
+	resp, err := http.Post(mock.URL()+"/users", "application/json", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestMockWithFromTable(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	for _, tc := range []struct {
+		filter string
+		status int
+	}{{"a", 200}, {"b", 404}} {
+		filter := tc.filter
+		mock.MockWith("/items",
+			WithStatus(tc.status),
+			WithBody(tc.filter),
+			WithFilter(func(r *http.Request) bool { return r.URL.Query().Get("q") == filter }),
+		)
+	}
+
+	resp, err := http.Get(mock.URL() + "/items?q=b")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "b", string(body))
+}
+
+func TestChainableModifiers(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/missing", `{"error":"not found"}`).
+		WithStatus(http.StatusNotFound).
+		AddHeader("Link", "</a>; rel=next").
+		AddHeader("Link", "</b>; rel=last").
+		WithDelay(10 * time.Millisecond)
+	mock.Mock("/callback", "ok", func(*http.Request) int { return http.StatusAccepted }).WithStatus(http.StatusTeapot)
+
+	start := time.Now()
+	resp, err := http.Get(mock.URL() + "/missing")
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, []string{"</a>; rel=next", "</b>; rel=last"}, resp.Header.Values("Link"))
+
+	resp, err = http.Get(mock.URL() + "/callback")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+}
+
+func TestWithoutRecordingAndAssertions(t *testing.T) {
+	mock := New(WithoutRecording(), WithoutAssertions())
+	defer mock.Close()
+	calls := 0
+	mr := mock.Mock("/a", "ok", func(*http.Request) int { calls++; return 0 }, func(*http.Request) int { calls++; return 0 })
+
+	for i := 0; i < 2; i++ {
+		resp, err := http.Get(mock.URL() + "/a")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, mr.Requests())
+
+	newT := &testing.T{}
+	mock.AssertCallCount(newT, "GET", "/a", 2)
+	assert.True(t, newT.Failed())
+}
+
+func TestCallbackIndexSameInAllModes(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"default":     nil,
+		"lightweight": {WithoutRecording(), WithoutAssertions()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mock := New(opts...)
+			defer mock.Close()
+			status := func(code int) func(*http.Request) int {
+				return func(*http.Request) int { return code }
+			}
+			isAdmin := func(r *http.Request) bool { return r.Header.Get("X-Admin") != "" }
+			mock.Mock("/users", "admin", status(201), status(202)).Filter(isAdmin)
+			mock.Mock("/users", "user", status(206), status(207))
+
+			var codes []int
+			for _, admin := range []bool{false, true, false, true} {
+				req := httptest.NewRequest("GET", "/users", nil)
+				if admin {
+					req.Header.Set("X-Admin", "1")
+				}
+				w := httptest.NewRecorder()
+				mock.ServeHTTP(w, req)
+				codes = append(codes, w.Code)
+			}
+			assert.Equal(t, []int{206, 201, 207, 202}, codes)
+		})
+	}
+}
+
+func BenchmarkServeHTTP(b *testing.B) {
+	for name, opts := range map[string][]Option{
+		"default":     nil,
+		"lightweight": {WithoutRecording(), WithoutAssertions()},
+	} {
+		b.Run(name, func(b *testing.B) {
+			mock := New(opts...)
+			defer mock.Close()
+			mock.Mock("/bench", "ok")
+			req := httptest.NewRequest("GET", "/bench", nil)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				mock.ServeHTTP(httptest.NewRecorder(), req)
+			}
+		})
+	}
+}
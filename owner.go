@@ -0,0 +1,94 @@
+package gohtmock
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// owner is the test that registered a mock.
+type owner struct {
+	tb   testing.TB
+	done bool
+	sync.Mutex
+}
+
+func newOwner(tb testing.TB) *owner {
+	o := &owner{tb: tb}
+	tb.Cleanup(func() {
+		o.Lock()
+		o.done = true
+		o.Unlock()
+	})
+	return o
+}
+
+// Owned registers mocks bound to the test that created them, see For.
+type Owned struct {
+	mock  *Mock
+	owner *owner
+}
+
+// For binds mocks registered through the returned value to tb. Failures
+// caused by those mocks, also ones detected by other tests or at Cleanup,
+// name tb as the owner and are reported to tb while it is still running.
+func (m *Mock) For(tb testing.TB) *Owned {
+	return &Owned{mock: m, owner: newOwner(tb)}
+}
+
+func (o *Owned) Mock(path, resp string, callback ...func(*http.Request) int) *mockResponse {
+	mr := o.mock.newMockResponse(path, resp)
+	mr.callbacks = callback
+	mr.owner = o.owner
+	o.mock.add(mr)
+	return mr
+}
+
+func (o *Owned) MockFunc(path string, fn http.HandlerFunc) *mockResponse {
+	mr := o.mock.newMockResponse(path, "")
+	mr.handler = fn
+	mr.owner = o.owner
+	o.mock.add(mr)
+	return mr
+}
+
+// ownedBy returns a suffix for failure messages naming the owner of mr.
+func (mr *mockResponse) ownedBy() string {
+	if mr.owner == nil {
+		return ""
+	}
+	return fmt.Sprintf(" (mocked by %s)", mr.owner.tb.Name())
+}
+
+// fail reports a failure detected while serving mr to its owner, or to
+// DeferFailures. It returns false if there is no one to report to.
+func (mr *mockResponse) fail(format string, args ...any) bool {
+	if d := mr.httpMock.deferredFailures(); d != nil {
+		d.add("failures", fmt.Sprintf(format, args...))
+		return true
+	}
+	if mr.owner == nil {
+		return false
+	}
+	return mr.owner.fail(format, args...)
+}
+
+// report is fail logging the failure when there is no one to report to.
+func (mr *mockResponse) report(format string, args ...any) {
+	if !mr.fail(format, args...) {
+		log.Printf(format, args...)
+	}
+}
+
+// fail reports a failure to o unless its test has finished.
+func (o *owner) fail(format string, args ...any) bool {
+	o.Lock()
+	defer o.Unlock()
+	if o.done {
+		return false
+	}
+	o.tb.Errorf(format, args...)
+	return true
+}
@@ -0,0 +1,46 @@
+package gohtmock
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForNamesOwner(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	owner := newRecordingT("TestHelperUser")
+	mock.For(owner).Mock("/stale", "never called")
+	mock.Mock("/anonymous", "never called")
+
+	asserting := newRecordingT("TestOther")
+	mock.AssertMocksCalled(asserting)
+	assert.Equal(t, []string{
+		"GET /stale mocked but never called. (mocked by TestHelperUser)",
+		"GET /anonymous mocked but never called.",
+	}, asserting.Errors())
+}
+
+func TestForReportsServeErrorsToOwner(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	owner := newRecordingT("TestOwner")
+	mock.For(owner).Mock("/callbacks", "ok", func(*http.Request) int { return 200 })
+
+	resp, err := http.Get(mock.URL() + "/callbacks")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = http.Get(mock.URL() + "/callbacks")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, []string{
+		"GET /callbacks called 2 times but only 1 callbacks given (mocked by TestOwner)",
+	}, owner.Errors())
+
+	// once the owner has finished failures can not be reported to it anymore
+	owner.runCleanups()
+	_, err = http.Get(mock.URL() + "/callbacks")
+	assert.Error(t, err)
+	assert.Len(t, owner.Errors(), 1)
+}
@@ -0,0 +1,45 @@
+package gohtmock
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+type pathParamsKey struct{}
+
+// PathParams returns the groups captured by the path pattern of the mock
+// answering r, by name for named groups and by position, starting at "1",
+// for the others. It is nil for mocks with exact paths. It is meant for
+// SetHeaderFunc, Guard and MockFunc handlers; templates get the same
+// values as .Params.
+func PathParams(r *http.Request) map[string]string {
+	params, _ := r.Context().Value(pathParamsKey{}).(map[string]string)
+	return params
+}
+
+// withPathParams returns r carrying the groups the path pattern of mr
+// captures from its path, or r itself if there are none.
+func (mr *mockResponse) withPathParams(r *http.Request) *http.Request {
+	mr.Lock()
+	re := mr.pathPattern
+	mr.Unlock()
+	if re == nil || re.NumSubexp() == 0 {
+		return r
+	}
+	match := re.FindStringSubmatch(r.URL.Path)
+	if match == nil {
+		return r
+	}
+	params := make(map[string]string, len(match)-1)
+	for i, name := range re.SubexpNames() {
+		if i == 0 {
+			continue
+		}
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		params[name] = match[i]
+	}
+	return r.WithContext(context.WithValue(r.Context(), pathParamsKey{}, params))
+}
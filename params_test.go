@@ -0,0 +1,41 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathParams(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mr := mock.Mock("/users/{id}", `{"id": "{{ .Params.id }}", "tab": "{{ index .Params "2" }}"}`).Template().
+		SetHeaderFunc("Location", func(r *http.Request) string {
+			return "/users/" + PathParams(r)["id"]
+		})
+	mr.pathPattern = regexp.MustCompile(`^/users/(?P<id>[^/]+)/(\w+)$`)
+
+	resp, err := http.Get(mock.URL() + "/users/42/orders")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id": "42", "tab": "orders"}`, string(body))
+	assert.Equal(t, "/users/42", resp.Header.Get("Location"))
+}
+
+func TestPathParamsExactPath(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/users", `{{ .Params.id }}`).Template()
+
+	resp, err := http.Get(mock.URL() + "/users")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "", string(body))
+}
@@ -0,0 +1,191 @@
+package gohtmock
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestIDHeader carries the partition of a request, see Partition.
+const TestIDHeader = "X-Test-ID"
+
+// Partition is a view of a Mock scoped to one test. Mocks registered on it
+// only answer requests carrying its ID in the X-Test-ID header, and its
+// assertions only see those requests. This lets t.Parallel() tests share
+// one server without cross-talk. Requests from the partition's Client fall
+// back to mocks registered directly on the Mock.
+type Partition struct {
+	id    string
+	mock  *Mock
+	owner *owner
+	// header and value identify requests in the partition
+	header string
+	value  string
+	counters
+}
+
+// Partition returns the partition of tb, creating it on first use. It is
+// removed with its mocks when tb finishes, so that a test run again, e.g.
+// with -count, starts with a fresh partition.
+func (m *Mock) Partition(tb testing.TB) *Partition {
+	m.Lock()
+	defer m.Unlock()
+	id := partitionID(TestIDHeader, tb.Name())
+	p, ok := m.partitions[id]
+	if !ok {
+		p = &Partition{id: id, mock: m, owner: newOwner(tb), header: TestIDHeader, value: tb.Name(), counters: newCounters()}
+		m.partitions[id] = p
+		tb.Cleanup(func() {
+			m.Lock()
+			defer m.Unlock()
+			if m.partitions[id] == p {
+				delete(m.partitions, id)
+				m.removeWhere(func(mr *mockResponse) bool { return mr.partition == id })
+			}
+		})
+	}
+	return p
+}
+
+// partitionID is the ID of the partition of requests whose header is value.
+// Test partitions are those of TestIDHeader, so test names can not collide
+// with tenants.
+func partitionID(header, value string) string {
+	return http.CanonicalHeaderKey(header) + "=" + value
+}
+
+// Tenant returns the partition of requests whose header key equals value,
+// creating it on first use. Like a Partition from Partition(tb), its mocks
+// only answer requests of the tenant, falling back to mocks registered
+// directly on the Mock, and its assertions only see those requests. A
+// request carrying an X-Test-ID of a known partition belongs to that
+// partition rather than to a tenant.
+func (m *Mock) Tenant(key, value string) *Partition {
+	key = http.CanonicalHeaderKey(key)
+	m.Lock()
+	defer m.Unlock()
+	id := partitionID(key, value)
+	p, ok := m.partitions[id]
+	if !ok {
+		p = &Partition{id: id, mock: m, header: key, value: value, counters: newCounters()}
+		m.partitions[id] = p
+		if !containsString(m.tenantHeaders, key) {
+			m.tenantHeaders = append(m.tenantHeaders, key)
+		}
+	}
+	return p
+}
+
+// ClientFor returns an *http.Client sending requests in the partition of tb.
+func (m *Mock) ClientFor(tb testing.TB) *http.Client {
+	return m.Partition(tb).Client()
+}
+
+// partitionFor returns the partition of r and its counters. Requests without
+// a known partition use the counters of m. m must be locked.
+func (m *Mock) partitionFor(r *http.Request) (string, *counters) {
+	if p, ok := m.partitions[partitionID(TestIDHeader, r.Header.Get(TestIDHeader))]; ok {
+		return p.id, &p.counters
+	}
+	for _, key := range m.tenantHeaders {
+		if p, ok := m.partitions[partitionID(key, r.Header.Get(key))]; ok {
+			return p.id, &p.counters
+		}
+	}
+	return "", &m.counters
+}
+
+// candidates returns the mocks eligible for a request in partition, the
+// partition's own mocks first. m must be locked.
+func (m *Mock) candidates(partition string) []*mockResponse {
+	if len(m.partitions) == 0 {
+		return byPrecedence(m.mockResponses)
+	}
+	if partition == "" {
+		var shared []*mockResponse
+		for _, mr := range m.mockResponses {
+			if mr.partition == "" {
+				shared = append(shared, mr)
+			}
+		}
+		return byPrecedence(shared)
+	}
+
+	var own, shared []*mockResponse
+	for _, mr := range m.mockResponses {
+		switch mr.partition {
+		case partition:
+			own = append(own, mr)
+		case "":
+			shared = append(shared, mr)
+		}
+	}
+	return append(byPrecedence(own), byPrecedence(shared)...)
+}
+
+func (p *Partition) ID() string {
+	return p.id
+}
+
+func (p *Partition) Mock(path, resp string, callback ...func(*http.Request) int) *mockResponse {
+	mr := p.mock.newMockResponse(path, resp)
+	mr.callbacks = callback
+	mr.partition = p.id
+	mr.owner = p.owner
+	p.mock.add(mr)
+	return mr
+}
+
+func (p *Partition) MockFunc(path string, fn http.HandlerFunc) *mockResponse {
+	mr := p.mock.newMockResponse(path, "")
+	mr.handler = fn
+	mr.partition = p.id
+	mr.owner = p.owner
+	p.mock.add(mr)
+	return mr
+}
+
+// Client returns an *http.Client that adds the partition header to all requests.
+func (p *Partition) Client() *http.Client {
+	return &http.Client{Transport: &partitionTransport{header: p.header, value: p.value, next: p.mock.transport()}}
+}
+
+func (p *Partition) AssertCallCount(tb testing.TB, method, path string, expected int) {
+	p.mock.assertCallCount(tb, &p.counters, method, path, expected)
+}
+
+func (p *Partition) AssertStatusCount(tb testing.TB, method, path string, status, expected int) {
+	p.mock.assertStatusCount(tb, &p.counters, method, path, status, expected)
+}
+
+func (p *Partition) AssertCallCountAsserted(tb testing.TB) {
+	p.mock.assertCallCountAsserted(tb, &p.counters)
+}
+
+func (p *Partition) AssertNoMissingMocks(tb testing.TB) {
+	p.mock.assertNoMissingMocks(tb, &p.counters)
+}
+
+func (p *Partition) AssertMocksCalled(tb testing.TB) {
+	p.mock.assertMocksCalled(tb, &p.counters, p.id)
+}
+
+type partitionTransport struct {
+	header string
+	value  string
+	next   http.RoundTripper
+}
+
+func (t *partitionTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set(t.header, t.value)
+	return t.next.RoundTrip(r)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
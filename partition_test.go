@@ -0,0 +1,148 @@
+package gohtmock
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionParallel(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/shared", "shared")
+
+	t.Run("group", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			i := i
+			t.Run(fmt.Sprint(i), func(t *testing.T) {
+				t.Parallel()
+				p := mock.Partition(t)
+				p.Mock("/users", fmt.Sprint(i))
+				client := mock.ClientFor(t)
+
+				for j := 0; j <= i; j++ {
+					resp, err := client.Get(mock.URL() + "/users")
+					assert.NoError(t, err)
+					body, _ := ioutil.ReadAll(resp.Body)
+					assert.Equal(t, fmt.Sprint(i), string(body))
+				}
+				resp, err := client.Get(mock.URL() + "/shared")
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+				p.AssertCallCount(t, "GET", "/users", i+1)
+				p.AssertCallCount(t, "GET", "/shared", 1)
+				p.AssertCallCountAsserted(t)
+				p.AssertMocksCalled(t)
+				p.AssertNoMissingMocks(t)
+			})
+		}
+	})
+
+	newT := &testing.T{}
+	mock.AssertCallCount(newT, "GET", "/users", 0)
+	assert.True(t, newT.Failed())
+	mock.AssertNoMissingMocks(t)
+}
+
+func TestPartitionMissingMocks(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	p := mock.Partition(t)
+	t.Run("other", func(t *testing.T) {
+		mock.Partition(t).Mock("/other", "not visible")
+	})
+
+	_, err := p.Client().Get(mock.URL() + "/other")
+	assert.NoError(t, err)
+
+	newT := &testing.T{}
+	p.AssertNoMissingMocks(newT)
+	assert.True(t, newT.Failed())
+	mock.AssertNoMissingMocks(t)
+}
+
+func TestTenant(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/status", "shared")
+	acme := mock.Tenant("X-Api-Key", "acme")
+	globex := mock.Tenant("x-api-key", "globex")
+	acme.Mock("/account", "acme")
+	globex.Mock("/account", "globex")
+	assert.Same(t, acme, mock.Tenant("X-Api-Key", "acme"))
+
+	get := func(client *http.Client, path string) (int, string) {
+		resp, err := client.Get(mock.URL() + path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	_, body := get(acme.Client(), "/account")
+	assert.Equal(t, "acme", body)
+	_, body = get(globex.Client(), "/account")
+	assert.Equal(t, "globex", body)
+	_, body = get(globex.Client(), "/status")
+	assert.Equal(t, "shared", body)
+	status, _ := get(http.DefaultClient, "/account")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	acme.AssertCallCount(t, "GET", "/account", 1)
+	acme.AssertCallCountAsserted(t)
+	acme.AssertNoMissingMocks(t)
+	globex.AssertCallCount(t, "GET", "/account", 1)
+	globex.AssertCallCount(t, "GET", "/status", 1)
+	globex.AssertMocksCalled(t)
+
+	newT := &testing.T{}
+	mock.AssertNoMissingMocks(newT)
+	assert.True(t, newT.Failed())
+}
+
+func TestPartitionRemovedAtCleanup(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+
+	// the same test run twice, as with -count=2
+	for run := 0; run < 2; run++ {
+		c := NewChecker("TestRunTwice")
+		p := mock.Partition(c)
+		p.Mock("/x", "{}")
+		resp, err := mock.ClientFor(c).Get(mock.URL() + "/x")
+		assert.NoError(t, err)
+		resp.Body.Close()
+		p.AssertCallCount(c, "GET", "/x", 1)
+		c.Close()
+		assert.NoError(t, c.Err())
+	}
+	assert.Empty(t, mock.Mocks())
+}
+
+func TestPartitionAndTenantNamespaces(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	c := NewChecker("X-Tenant=acme")
+	defer c.Close()
+	mock.Partition(c).Mock("/x", "test")
+	mock.Tenant("X-Tenant", "acme").Mock("/x", "tenant")
+
+	req, _ := http.NewRequest("GET", mock.URL()+"/x", nil)
+	req.Header.Set("X-Tenant", "acme")
+	resp, err := http.DefaultClient.Do(req)
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "tenant", string(body))
+	}
+	resp, err = mock.ClientFor(c).Get(mock.URL() + "/x")
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "test", string(body))
+	}
+}
@@ -0,0 +1,69 @@
+package gohtmock
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// isPathPattern reports if path uses the wildcards of compilePathPattern.
+// Other paths match exactly, so a trailing slash does not match subpaths.
+func isPathPattern(path string) bool {
+	return strings.Contains(path, "{") || strings.Contains(path, "*")
+}
+
+// splitMethod splits a pattern like "POST /users/{id}" into its method and
+// path, as net/http.ServeMux patterns are written. method is "" without one.
+func splitMethod(pattern string) (method, path string) {
+	m, p, ok := strings.Cut(pattern, " ")
+	if !ok || !strings.HasPrefix(p, "/") || m == "" || strings.ToUpper(m) != m {
+		return "", pattern
+	}
+	return m, p
+}
+
+var wildcardName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// compilePathPattern compiles a path pattern in the style of
+// net/http.ServeMux, where a segment {name} matches one segment, {name...}
+// as the last segment the rest of the path, {$} as the last segment only
+// the path ending in a slash and a trailing slash any path below. A * matches
+// one segment, or the rest of the path as the last segment. Captured
+// segments are named after their wildcard, see PathParams.
+func compilePathPattern(path string) (*regexp.Regexp, error) {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	var b strings.Builder
+	b.WriteString("^")
+	for i, seg := range segments {
+		last := i == len(segments)-1
+		switch {
+		case last && seg == "":
+			// a trailing slash matches the subtree below it
+			b.WriteString("/.*")
+		case last && seg == "{$}":
+			b.WriteString("/")
+		case last && seg == "*":
+			b.WriteString("/.*")
+		case seg == "*":
+			b.WriteString("/[^/]+")
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "...}") && last:
+			name := strings.TrimSuffix(strings.TrimPrefix(seg, "{"), "...}")
+			if !wildcardName.MatchString(name) {
+				return nil, fmt.Errorf("bad wildcard %s", seg)
+			}
+			b.WriteString("/(?P<" + name + ">.*)")
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+			name := strings.TrimSuffix(strings.TrimPrefix(seg, "{"), "}")
+			if !wildcardName.MatchString(name) {
+				return nil, fmt.Errorf("bad wildcard %s", seg)
+			}
+			b.WriteString("/(?P<" + name + ">[^/]+)")
+		case strings.ContainsAny(seg, "{}"):
+			return nil, fmt.Errorf("wildcard %s must be a whole segment", seg)
+		default:
+			b.WriteString("/" + regexp.QuoteMeta(seg))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,71 @@
+package gohtmock
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompilePathPattern(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		matches map[string]bool
+	}{
+		{"/users/{id}", map[string]bool{"/users/1": true, "/users/": false, "/users/1/orders": false}},
+		{"/users/{id}/orders", map[string]bool{"/users/1/orders": true, "/users/1": false}},
+		{"/files/{path...}", map[string]bool{"/files/a/b.txt": true, "/files/": true, "/file": false}},
+		{"/files/*", map[string]bool{"/files/a/b.txt": true, "/files": false}},
+		{"/a/*/c", map[string]bool{"/a/b/c": true, "/a/b/b/c": false}},
+		{"/dir/{$}", map[string]bool{"/dir/": true, "/dir/x": false}},
+		{"/{org}/repos/", map[string]bool{"/acme/repos/": true, "/acme/repos/x/y": true, "/acme/repos": false}},
+		{"/v1.0/{id}", map[string]bool{"/v1.0/x": true, "/v1x0/x": false}},
+	} {
+		re, err := compilePathPattern(tc.pattern)
+		if !assert.NoError(t, err, tc.pattern) {
+			continue
+		}
+		for path, match := range tc.matches {
+			assert.Equal(t, match, re.MatchString(path), "%s %s", tc.pattern, path)
+		}
+	}
+}
+
+func TestPathPatterns(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.MockFunc("/users/{id}/orders/{order}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s:%s", PathParams(r)["id"], PathParams(r)["order"])
+	})
+	files := mock.Mock("/files/*", "file")
+	mock.Mock("DELETE /users/{id}", "").WithStatus(http.StatusNoContent)
+	mock.Mock("/", "root")
+
+	do := func(method, path string) (int, string) {
+		req, err := http.NewRequest(method, mock.URL()+path, nil)
+		assert.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(b)
+	}
+
+	_, body := do("GET", "/users/7/orders/42")
+	assert.Equal(t, "7:42", body)
+	_, body = do("GET", "/files/a/b.txt")
+	assert.Equal(t, "file", body)
+	status, _ := do("DELETE", "/users/7")
+	assert.Equal(t, http.StatusNoContent, status)
+	status, _ = do("GET", "/users/7")
+	assert.Equal(t, http.StatusNotFound, status)
+	// exact paths stay exact
+	status, _ = do("GET", "/other")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	assert.Equal(t, 1, files.CallCount())
+	mock.AssertCallCount(t, "GET", "/files/a/b.txt", 1)
+	assert.Panics(t, func() { mock.Mock("/users/{id", "") })
+}
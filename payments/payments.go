@@ -0,0 +1,506 @@
+// Package payments emulates a card payment sandbox on top of a
+// gohtmock.Mock: cards are tokenized, tokens are charged with idempotency
+// keys and charges are confirmed through signed webhooks. The flow is a
+// gohtmock Scenario, so charging before any card is tokenized is rejected
+// and FlowState tells how far a test got.
+package payments
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fortnoxab/gohtmock"
+	"github.com/fortnoxab/gohtmock/internal/deliver"
+)
+
+const (
+	TokensPath  = "/v1/tokens"
+	ChargesPath = "/v1/charges"
+	// IdempotencyHeader makes retried charges return the first response
+	// instead of charging twice.
+	IdempotencyHeader = "Idempotency-Key"
+	// SignatureHeader carries the webhook signature, see Sign.
+	SignatureHeader = "Payment-Signature"
+	// ScenarioName is the name of the flow scenario on the Mock.
+	ScenarioName = "payments"
+)
+
+// The states of the flow scenario.
+const (
+	StateNoCard    = "no-card"
+	StateTokenized = "tokenized"
+	StateCharged   = "charged"
+	StateConfirmed = "confirmed"
+)
+
+// Test cards. Any other number passing the Luhn check is accepted.
+const (
+	CardSuccess           = "4242424242424242"
+	CardDeclined          = "4000000000000002"
+	CardInsufficientFunds = "4000000000009995"
+)
+
+// Charge statuses.
+const (
+	ChargePending   = "pending"
+	ChargeSucceeded = "succeeded"
+	ChargeFailed    = "failed"
+)
+
+func init() {
+	gohtmock.RegisterPreset("payments", func() gohtmock.Preset { return New() })
+}
+
+var _ gohtmock.Preset = (*Sandbox)(nil)
+
+type Option func(*Sandbox)
+
+// WithWebhook posts charge events to url, signed with secret.
+func WithWebhook(url, secret string) Option {
+	return func(s *Sandbox) {
+		s.webhook = url
+		s.secret = secret
+	}
+}
+
+// WithDecline makes charges of the card number fail with code, for example
+// "expired_card".
+func WithDecline(number, code string) Option {
+	return func(s *Sandbox) {
+		s.declines[number] = code
+	}
+}
+
+// WithAutoConfirm confirms successful charges right after they are created
+// instead of waiting for Confirm.
+func WithAutoConfirm() Option {
+	return func(s *Sandbox) {
+		s.autoConfirm = true
+	}
+}
+
+// Card is the body of a tokenize request.
+type Card struct {
+	Number   string `json:"number"`
+	ExpMonth int    `json:"exp_month"`
+	ExpYear  int    `json:"exp_year"`
+	CVC      string `json:"cvc"`
+}
+
+// Token is the response of a tokenize request.
+type Token struct {
+	ID    string `json:"id"`
+	Last4 string `json:"last4"`
+	Brand string `json:"brand"`
+}
+
+// ChargeRequest is the body of a charge request.
+type ChargeRequest struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Token    string `json:"token"`
+}
+
+// Charge is the response of a charge request and the data of events.
+type Charge struct {
+	ID          string `json:"id"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	Status      string `json:"status"`
+	Last4       string `json:"last4"`
+	FailureCode string `json:"failure_code,omitempty"`
+}
+
+// Event is the body of webhooks, of type charge.succeeded or charge.failed.
+type Event struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data Charge `json:"data"`
+}
+
+// Error is the body of error responses.
+type Error struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type Sandbox struct {
+	webhook     string
+	secret      string
+	autoConfirm bool
+	declines    map[string]string
+	tokens      map[string]Card
+	usedTokens  map[string]bool
+	charges     []*Charge
+	events      int
+	scenario    *gohtmock.Scenario
+	chargesMock interface{ Retries() int }
+	// deliveries are the webhook posts of WithAutoConfirm
+	deliveries deliver.Queue
+	client     *http.Client
+	sync.Mutex
+}
+
+func New(opts ...Option) *Sandbox {
+	s := &Sandbox{
+		declines: map[string]string{
+			CardDeclined:          "card_declined",
+			CardInsufficientFunds: "insufficient_funds",
+		},
+		tokens:     make(map[string]Card),
+		usedTokens: make(map[string]bool),
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Install registers the tokens and charges endpoints and the flow scenario
+// on m.
+func (s *Sandbox) Install(m *gohtmock.Mock) error {
+	s.scenario = m.Scenario(ScenarioName)
+	s.scenario.SetState(StateNoCard)
+	// registered first, so it answers charges until a card is tokenized
+	s.scenario.Given(StateNoCard).When(http.MethodPost, ChargesPath).
+		Then(http.StatusBadRequest, `{"error":{"code":"no_token","message":"no card has been tokenized"}}`)
+	m.MockFunc(TokensPath, s.serveTokenize).SetMethod(http.MethodPost)
+	s.chargesMock = m.Mount(ChargesPath, http.HandlerFunc(s.serveCharges)).DedupeBy(IdempotencyHeader)
+	return nil
+}
+
+// Decline makes charges of the card number fail with code.
+func (s *Sandbox) Decline(number, code string) {
+	s.Lock()
+	s.declines[number] = code
+	s.Unlock()
+}
+
+// FlowState returns the state of the flow scenario, one of StateNoCard,
+// StateTokenized, StateCharged and StateConfirmed.
+func (s *Sandbox) FlowState() string {
+	return s.scenario.State()
+}
+
+// Charges returns copies of the created charges, declined ones included.
+func (s *Sandbox) Charges() []Charge {
+	s.Lock()
+	defer s.Unlock()
+	charges := make([]Charge, len(s.charges))
+	for i, c := range s.charges {
+		charges[i] = *c
+	}
+	return charges
+}
+
+// IdempotentRetries returns the number of charge requests answered with the
+// response of an earlier request with the same idempotency key.
+func (s *Sandbox) IdempotentRetries() int {
+	return s.chargesMock.Retries()
+}
+
+// Confirm succeeds the pending charge with id and posts a charge.succeeded
+// event to the webhook.
+func (s *Sandbox) Confirm(id string) error {
+	return s.settle(id, ChargeSucceeded, "")
+}
+
+// FailCharge fails the pending charge with id with code and posts a
+// charge.failed event to the webhook.
+func (s *Sandbox) FailCharge(id, code string) error {
+	return s.settle(id, ChargeFailed, code)
+}
+
+// AssertCharged asserts that a charge of amount in currency succeeded or is
+// pending.
+func (s *Sandbox) AssertCharged(tb testing.TB, amount int64, currency string) {
+	var charged []string
+	for _, c := range s.Charges() {
+		if c.Status == ChargeFailed {
+			continue
+		}
+		if c.Amount == amount && strings.EqualFold(c.Currency, currency) {
+			return
+		}
+		charged = append(charged, fmt.Sprintf("%d %s", c.Amount, c.Currency))
+	}
+	tb.Errorf("payments: no charge of %d %s, charged %v", amount, currency, charged)
+}
+
+// AssertChargeCount asserts that n charges were created, declined ones
+// included. Idempotent retries do not create charges.
+func (s *Sandbox) AssertChargeCount(tb testing.TB, n int) {
+	if created := len(s.Charges()); created != n {
+		tb.Errorf("payments: %d charges created, expected %d", created, n)
+	}
+}
+
+// AssertFlowCompleted asserts that a charge was confirmed.
+func (s *Sandbox) AssertFlowCompleted(tb testing.TB) {
+	if state := s.FlowState(); state != StateConfirmed {
+		tb.Errorf("payments: flow stopped in state %s", state)
+	}
+}
+
+// AssertWebhooksDelivered waits for the webhook posts of WithAutoConfirm
+// and asserts that all of them succeeded.
+func (s *Sandbox) AssertWebhooksDelivered(tb testing.TB) {
+	s.deliveries.Wait()
+	for _, err := range s.deliveries.Errors() {
+		tb.Errorf("payments: %s", err)
+	}
+}
+
+// Wait blocks until the webhook posts of WithAutoConfirm started so far
+// have been made.
+func (s *Sandbox) Wait() {
+	s.deliveries.Wait()
+}
+
+// Close stops WithAutoConfirm from confirming further charges and waits
+// for the webhook posts in flight.
+func (s *Sandbox) Close() {
+	s.deliveries.Close()
+}
+
+func (s *Sandbox) serveTokenize(w http.ResponseWriter, r *http.Request) {
+	var card Card
+	if err := json.NewDecoder(r.Body).Decode(&card); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if !luhn(card.Number) {
+		writeError(w, http.StatusBadRequest, "invalid_number", "the card number is invalid")
+		return
+	}
+	if card.ExpYear < time.Now().Year() || card.ExpMonth < 1 || card.ExpMonth > 12 {
+		writeError(w, http.StatusBadRequest, "invalid_expiry", "the card expiry is invalid")
+		return
+	}
+	s.Lock()
+	id := fmt.Sprintf("tok_%d", len(s.tokens)+1)
+	s.tokens[id] = card
+	s.Unlock()
+	if s.scenario.State() == StateNoCard {
+		s.scenario.SetState(StateTokenized)
+	}
+	writeJSON(w, http.StatusOK, Token{ID: id, Last4: card.Number[len(card.Number)-4:], Brand: brand(card.Number)})
+}
+
+func (s *Sandbox) serveCharges(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/" && r.Method == http.MethodPost:
+		s.serveCreateCharge(w, r)
+	case r.URL.Path != "/" && r.Method == http.MethodGet:
+		id := strings.TrimPrefix(r.URL.Path, "/")
+		s.Lock()
+		c := s.charge(id)
+		var charge Charge
+		if c != nil {
+			charge = *c
+		}
+		s.Unlock()
+		if c == nil {
+			writeError(w, http.StatusNotFound, "resource_missing", "no charge "+id)
+			return
+		}
+		writeJSON(w, http.StatusOK, charge)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", r.Method+" is not allowed")
+	}
+}
+
+func (s *Sandbox) serveCreateCharge(w http.ResponseWriter, r *http.Request) {
+	var req ChargeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if req.Amount <= 0 || req.Currency == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "amount and currency are required")
+		return
+	}
+	s.Lock()
+	card, ok := s.tokens[req.Token]
+	if !ok || s.usedTokens[req.Token] {
+		s.Unlock()
+		writeError(w, http.StatusBadRequest, "invalid_token", "the token is unknown or already used")
+		return
+	}
+	s.usedTokens[req.Token] = true
+	charge := &Charge{
+		ID:       fmt.Sprintf("ch_%d", len(s.charges)+1),
+		Amount:   req.Amount,
+		Currency: strings.ToLower(req.Currency),
+		Status:   ChargePending,
+		Last4:    card.Number[len(card.Number)-4:],
+	}
+	code, declined := s.declines[card.Number]
+	if declined {
+		charge.Status = ChargeFailed
+		charge.FailureCode = code
+	}
+	s.charges = append(s.charges, charge)
+	created := *charge
+	autoConfirm := s.autoConfirm
+	s.Unlock()
+
+	if declined {
+		writeError(w, http.StatusPaymentRequired, code, "the card was declined")
+		return
+	}
+	s.scenario.SetState(StateCharged)
+	writeJSON(w, http.StatusCreated, created)
+	if autoConfirm {
+		// after the response, like a real provider
+		s.deliveries.Go(func() error { return s.Confirm(created.ID) })
+	}
+}
+
+// charge returns the charge with id, nil if none. s must be locked.
+func (s *Sandbox) charge(id string) *Charge {
+	for _, c := range s.charges {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *Sandbox) settle(id, status, code string) error {
+	s.Lock()
+	c := s.charge(id)
+	if c == nil || c.Status != ChargePending {
+		s.Unlock()
+		return fmt.Errorf("payments: no pending charge %s", id)
+	}
+	c.Status = status
+	c.FailureCode = code
+	s.events++
+	event := Event{ID: fmt.Sprintf("evt_%d", s.events), Type: "charge." + status, Data: *c}
+	url, secret := s.webhook, s.secret
+	s.Unlock()
+	if status == ChargeSucceeded {
+		s.scenario.SetState(StateConfirmed)
+	}
+
+	if url == "" {
+		return nil
+	}
+	body, _ := json.Marshal(event)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(secret, time.Now(), body))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("payments: posting %s to %s: %w", event.Type, url, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("payments: posting %s to %s: %s", event.Type, url, resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the SignatureHeader value of a webhook body sent at t,
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of secret over t.body>".
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + signature(secret, ts, body)
+}
+
+// VerifySignature checks a SignatureHeader value against body, rejecting
+// signatures older than tolerance.
+func VerifySignature(secret, header string, body []byte, tolerance time.Duration) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(part, "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sig = v
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return errors.New("payments: malformed signature header")
+	}
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, ts, body))) {
+		return errors.New("payments: signature mismatch")
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > tolerance {
+		return fmt.Errorf("payments: signature is %s old", age.Truncate(time.Second))
+	}
+	return nil
+}
+
+func signature(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func luhn(number string) bool {
+	if len(number) < 12 {
+		return false
+	}
+	sum := 0
+	for i := range number {
+		d := int(number[len(number)-1-i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+func brand(number string) string {
+	switch {
+	case strings.HasPrefix(number, "4"):
+		return "visa"
+	case number[0] == '5' && number[1] >= '1' && number[1] <= '5':
+		return "mastercard"
+	case strings.HasPrefix(number, "34"), strings.HasPrefix(number, "37"):
+		return "amex"
+	}
+	return "unknown"
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	var e Error
+	e.Error.Code = code
+	e.Error.Message = message
+	writeJSON(w, status, e)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,128 @@
+package payments
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fortnoxab/gohtmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func post(t *testing.T, url string, v any, idempotencyKey string) (*http.Response, []byte) {
+	b, _ := json.Marshal(v)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(b))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set(IdempotencyHeader, idempotencyKey)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(resp.Body)
+	return resp, buf.Bytes()
+}
+
+func tokenize(t *testing.T, url, number string) string {
+	resp, body := post(t, url+TokensPath, Card{Number: number, ExpMonth: 12, ExpYear: time.Now().Year() + 1, CVC: "123"}, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	var token Token
+	assert.NoError(t, json.Unmarshal(body, &token))
+	return token.ID
+}
+
+func TestChargeFlow(t *testing.T) {
+	hooks := gohtmock.New()
+	defer hooks.Close()
+	webhook := hooks.Mock("/webhook", "").SetMethod("POST")
+
+	mock := gohtmock.New()
+	defer mock.Close()
+	sandbox := New(WithWebhook(hooks.URL()+"/webhook", "whsec"))
+	assert.NoError(t, mock.Install(sandbox))
+
+	resp, body := post(t, mock.URL()+ChargesPath, ChargeRequest{Amount: 1000, Currency: "SEK", Token: "tok_1"}, "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, string(body), "no_token")
+	assert.Equal(t, StateNoCard, sandbox.FlowState())
+
+	resp, _ = post(t, mock.URL()+TokensPath, Card{Number: "4242424242424241", ExpMonth: 1, ExpYear: 2099}, "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	token := tokenize(t, mock.URL(), CardSuccess)
+	assert.Equal(t, StateTokenized, sandbox.FlowState())
+
+	charge := ChargeRequest{Amount: 1000, Currency: "SEK", Token: token}
+	resp, first := post(t, mock.URL()+ChargesPath, charge, "order-1")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, string(first))
+	resp, retried := post(t, mock.URL()+ChargesPath, charge, "order-1")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, string(first), string(retried))
+	assert.Equal(t, 1, sandbox.IdempotentRetries())
+	sandbox.AssertChargeCount(t, 1)
+	assert.Equal(t, StateCharged, sandbox.FlowState())
+
+	var created Charge
+	assert.NoError(t, json.Unmarshal(first, &created))
+	assert.Equal(t, Charge{ID: "ch_1", Amount: 1000, Currency: "sek", Status: ChargePending, Last4: "4242"}, created)
+
+	assert.NoError(t, sandbox.Confirm("ch_1"))
+	assert.Error(t, sandbox.Confirm("ch_1"))
+	sandbox.AssertFlowCompleted(t)
+	sandbox.AssertCharged(t, 1000, "SEK")
+
+	resp, err := http.Get(mock.URL() + ChargesPath + "/ch_1")
+	assert.NoError(t, err)
+	var polled Charge
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&polled))
+	resp.Body.Close()
+	assert.Equal(t, ChargeSucceeded, polled.Status)
+
+	if assert.Len(t, webhook.Requests(), 1) {
+		rr := webhook.Requests()[0]
+		assert.NoError(t, VerifySignature("whsec", rr.Header.Get(SignatureHeader), rr.Body, time.Minute))
+		assert.Error(t, VerifySignature("other", rr.Header.Get(SignatureHeader), rr.Body, time.Minute))
+		var event Event
+		assert.NoError(t, json.Unmarshal(rr.Body, &event))
+		assert.Equal(t, "charge.succeeded", event.Type)
+		assert.Equal(t, "ch_1", event.Data.ID)
+	}
+}
+
+func TestDeclines(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	sandbox := New(WithDecline("5555555555554444", "expired_card"), WithAutoConfirm())
+	assert.NoError(t, mock.Install(sandbox))
+
+	for number, code := range map[string]string{CardDeclined: "card_declined", CardInsufficientFunds: "insufficient_funds", "5555555555554444": "expired_card"} {
+		resp, body := post(t, mock.URL()+ChargesPath, ChargeRequest{Amount: 500, Currency: "EUR", Token: tokenize(t, mock.URL(), number)}, "")
+		assert.Equal(t, http.StatusPaymentRequired, resp.StatusCode)
+		var e Error
+		assert.NoError(t, json.Unmarshal(body, &e))
+		assert.Equal(t, code, e.Error.Code)
+	}
+	assert.Equal(t, StateTokenized, sandbox.FlowState())
+	sandbox.AssertChargeCount(t, 3)
+	assert.Error(t, gohtmock.Check(func(tb testing.TB) { sandbox.AssertCharged(tb, 500, "EUR") }))
+
+	resp, _ := post(t, mock.URL()+ChargesPath, ChargeRequest{Amount: 700, Currency: "EUR", Token: tokenize(t, mock.URL(), CardSuccess)}, "")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	sandbox.Wait()
+	assert.Equal(t, StateConfirmed, sandbox.FlowState())
+	sandbox.AssertWebhooksDelivered(t)
+}
+
+func TestRegisteredPreset(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	p, err := mock.InstallPreset("payments")
+	assert.NoError(t, err)
+	assert.Equal(t, StateNoCard, p.(*Sandbox).FlowState())
+}
@@ -0,0 +1,75 @@
+package gohtmock
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Preset is a service emulation, e.g. an object store or an OAuth server,
+// that registers its mocks on a Mock. Since presets use the ordinary mock
+// API, all assertions work on their endpoints as well.
+type Preset interface {
+	Install(m *Mock) error
+}
+
+var presets = struct {
+	factories map[string]func() Preset
+	sync.Mutex
+}{factories: make(map[string]func() Preset)}
+
+// RegisterPreset makes a preset available by name to NewPreset. It is meant
+// to be called from init and panics if name is already registered.
+func RegisterPreset(name string, factory func() Preset) {
+	presets.Lock()
+	defer presets.Unlock()
+	if factory == nil {
+		panic("gohtmock: RegisterPreset factory is nil")
+	}
+	if _, ok := presets.factories[name]; ok {
+		panic("gohtmock: RegisterPreset called twice for " + name)
+	}
+	presets.factories[name] = factory
+}
+
+// NewPreset creates a new instance of the preset registered as name.
+func NewPreset(name string) (Preset, error) {
+	presets.Lock()
+	factory, ok := presets.factories[name]
+	presets.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("gohtmock: unknown preset %q", name)
+	}
+	return factory(), nil
+}
+
+// Presets returns the sorted names of all registered presets.
+func Presets() []string {
+	presets.Lock()
+	defer presets.Unlock()
+	names := make([]string, 0, len(presets.factories))
+	for name := range presets.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Install installs the presets in order and stops at the first error.
+func (m *Mock) Install(presets ...Preset) error {
+	for _, p := range presets {
+		if err := p.Install(m); err != nil {
+			return fmt.Errorf("gohtmock: installing %T: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// InstallPreset installs the preset registered as name.
+func (m *Mock) InstallPreset(name string) (Preset, error) {
+	p, err := NewPreset(name)
+	if err != nil {
+		return nil, err
+	}
+	return p, m.Install(p)
+}
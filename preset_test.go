@@ -0,0 +1,53 @@
+package gohtmock
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type pingPreset struct {
+	err error
+}
+
+func (p *pingPreset) Install(m *Mock) error {
+	if p.err != nil {
+		return p.err
+	}
+	m.Mock("/ping", "pong")
+	return nil
+}
+
+func init() {
+	RegisterPreset("test-ping", func() Preset { return &pingPreset{} })
+}
+
+func TestInstallPreset(t *testing.T) {
+	assert.Contains(t, Presets(), "test-ping")
+	assert.Panics(t, func() {
+		RegisterPreset("test-ping", func() Preset { return &pingPreset{} })
+	})
+
+	mock := New()
+	defer mock.Close()
+	p, err := mock.InstallPreset("test-ping")
+	assert.NoError(t, err)
+	assert.IsType(t, &pingPreset{}, p)
+
+	_, err = http.Get(mock.URL() + "/ping")
+	assert.NoError(t, err)
+	mock.AssertCallCount(t, "GET", "/ping", 1)
+
+	_, err = mock.InstallPreset("missing")
+	assert.Error(t, err)
+}
+
+func TestInstallError(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	failing := errors.New("failing")
+	err := mock.Install(&pingPreset{}, &pingPreset{err: failing})
+	assert.ErrorIs(t, err, failing)
+}
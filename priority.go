@@ -0,0 +1,50 @@
+package gohtmock
+
+import "sort"
+
+// Priority makes mr be tried before the mocks of lower priority when
+// several could answer a request. Among mocks of equal priority, by default
+// 0, those with a Filter or matchers such as MatchHeader are tried before
+// the others, and otherwise the order of registration decides. n may be
+// negative to make mr a fallback.
+func (mr *mockResponse) Priority(n int) *mockResponse {
+	m := mr.httpMock
+	m.Lock()
+	defer m.Unlock()
+	// priority is only changed with m locked, so that matching can read it
+	// without locking mr
+	mr.Lock()
+	mr.priority = n
+	mr.Unlock()
+	return mr
+}
+
+// byPrecedence returns mocks in the order they are tried: by descending
+// priority, filtered mocks before unfiltered ones of the same priority and
+// otherwise in registration order. mocks is returned as is if it is in that
+// order already, else sorted into a new slice. m must be locked.
+func byPrecedence(mocks []*mockResponse) []*mockResponse {
+	filtered := make(map[*mockResponse]bool, len(mocks))
+	ordered := true
+	for i, mr := range mocks {
+		filtered[mr] = mr.filtered()
+		if i > 0 && precedes(mr, mocks[i-1], filtered) {
+			ordered = false
+		}
+	}
+	if ordered {
+		return mocks
+	}
+	sorted := append([]*mockResponse(nil), mocks...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return precedes(sorted[i], sorted[j], filtered)
+	})
+	return sorted
+}
+
+func precedes(a, b *mockResponse, filtered map[*mockResponse]bool) bool {
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	return filtered[a] && !filtered[b]
+}
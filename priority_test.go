@@ -0,0 +1,77 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriority(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/users", "fallback").Priority(-1)
+	mock.Mock("/users", "default")
+	mock.Mock("/users", "admin").Filter(func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "admin"
+	}).Priority(1)
+	mock.Mock("/users", "registered last, same priority").Filter(func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "admin"
+	}).Priority(1)
+
+	get := func(auth string) string {
+		r, _ := http.NewRequest("GET", mock.URL()+"/users", nil)
+		r.Header.Set("Authorization", auth)
+		resp, err := http.DefaultClient.Do(r)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return string(body)
+	}
+	assert.Equal(t, "admin", get("admin"))
+	assert.Equal(t, "default", get("user"))
+
+	mock.Remove("GET", "/users")
+	mock.Mock("/users", "late").Priority(-1)
+	mock.Mock("/users", "later")
+	assert.Equal(t, "later", get("user"))
+}
+
+func TestFilteredBeforeUnfiltered(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/users", "default")
+	mock.Mock("/users", "admin").MatchHeader("Authorization", "admin")
+	mock.Mock("/users", "preferred").Priority(1)
+
+	get := func(auth string) string {
+		r, _ := http.NewRequest("GET", mock.URL()+"/users", nil)
+		r.Header.Set("Authorization", auth)
+		resp, err := http.DefaultClient.Do(r)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return string(body)
+	}
+	assert.Equal(t, "preferred", get("admin"))
+
+	mock.Remove("GET", "/users")
+	mock.Mock("/users", "default")
+	mock.Mock("/users", "admin").MatchHeader("Authorization", "admin")
+	assert.Equal(t, "admin", get("admin"))
+	assert.Equal(t, "default", get("user"))
+}
+
+func TestMocksKeepRegistrationOrder(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	first := mock.Mock("/users", "first")
+	second := mock.Mock("/users", "second").Priority(1)
+	third := mock.Mock("/users", "third")
+	assert.Equal(t, []*mockResponse{first, second, third}, mock.Mocks())
+}
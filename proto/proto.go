@@ -0,0 +1,74 @@
+// Package proto provides a gohtmock.Codec for protobuf bodies.
+package proto
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/fortnoxab/gohtmock"
+	"google.golang.org/protobuf/encoding/prototext"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// ContentType is the content type of protobuf bodies.
+const ContentType = "application/x-protobuf"
+
+// Codec encodes and decodes protobuf messages, for use with MockEncoded,
+// MatchBodyEncoded and HandleEncoded. Values must be proto.Message; request
+// bodies are compared with proto.Equal.
+var Codec gohtmock.EqualCodec = codec{}
+
+type codec struct{}
+
+func (codec) ContentType() string { return ContentType }
+
+func (codec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(protobuf.Message)
+	if !ok {
+		return nil, fmt.Errorf("proto: %T is not a proto.Message", v)
+	}
+	return protobuf.Marshal(msg)
+}
+
+func (codec) Unmarshal(data []byte, v any) error {
+	msg, ok := v.(protobuf.Message)
+	if !ok {
+		// HandleEncoded decodes into a pointer to a message pointer
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Ptr && rv.Elem().Kind() == reflect.Ptr {
+			if rv.Elem().IsNil() {
+				rv.Elem().Set(reflect.New(rv.Elem().Type().Elem()))
+			}
+			msg, ok = rv.Elem().Interface().(protobuf.Message)
+		}
+	}
+	if !ok {
+		return fmt.Errorf("proto: can not decode into %T, it is not a proto.Message", v)
+	}
+	return protobuf.Unmarshal(data, msg)
+}
+
+func (codec) Equal(expected any, body []byte) bool {
+	want, ok := expected.(protobuf.Message)
+	if !ok {
+		return false
+	}
+	got := want.ProtoReflect().New().Interface()
+	if err := protobuf.Unmarshal(body, got); err != nil {
+		return false
+	}
+	return protobuf.Equal(want, got)
+}
+
+func (codec) Describe(expected any, body []byte) (string, string) {
+	want, ok := expected.(protobuf.Message)
+	if !ok {
+		return fmt.Sprintf("%v", expected), fmt.Sprintf("%q", body)
+	}
+	text := prototext.MarshalOptions{Multiline: true}
+	got := want.ProtoReflect().New().Interface()
+	if err := protobuf.Unmarshal(body, got); err != nil {
+		return text.Format(want), fmt.Sprintf("%q", body)
+	}
+	return text.Format(want), text.Format(got)
+}
@@ -0,0 +1,60 @@
+package proto
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/fortnoxab/gohtmock"
+	"github.com/stretchr/testify/assert"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCodec(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	mock.MockEncoded("/user", Codec, wrapperspb.String("john")).
+		SetMethod("POST").
+		MatchBodyEncoded(Codec, wrapperspb.Int64(1))
+	mock.MockEncoded("/user", Codec, wrapperspb.String("jane")).
+		SetMethod("POST").
+		MatchBodyEncoded(Codec, wrapperspb.Int64(2))
+
+	for id, name := range map[int64]string{1: "john", 2: "jane"} {
+		req, _ := protobuf.Marshal(wrapperspb.Int64(id))
+		resp, err := http.Post(mock.URL()+"/user", ContentType, bytes.NewReader(req))
+		assert.NoError(t, err)
+		b, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, ContentType, resp.Header.Get("Content-Type"))
+		got := &wrapperspb.StringValue{}
+		assert.NoError(t, protobuf.Unmarshal(b, got))
+		assert.Equal(t, name, got.Value)
+	}
+
+	req, _ := protobuf.Marshal(wrapperspb.Int64(3))
+	resp, err := http.Post(mock.URL()+"/user", ContentType, bytes.NewReader(req))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	mock.AssertMocksCalled(t)
+}
+
+func TestHandleEncoded(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	gohtmock.HandleEncoded(mock, Codec, "POST", "/double", func(in *wrapperspb.Int64Value) (*wrapperspb.Int64Value, int, error) {
+		return wrapperspb.Int64(in.Value * 2), http.StatusOK, nil
+	})
+
+	req, _ := protobuf.Marshal(wrapperspb.Int64(21))
+	resp, err := http.Post(mock.URL()+"/double", ContentType, bytes.NewReader(req))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	got := &wrapperspb.Int64Value{}
+	assert.NoError(t, protobuf.Unmarshal(b, got))
+	assert.Equal(t, int64(42), got.Value)
+}
@@ -0,0 +1,28 @@
+package gohtmock
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// MatchQuery makes mr only answer requests whose query parameter key has
+// value among its values. Several calls must all match.
+func (mr *mockResponse) MatchQuery(key, value string) *mockResponse {
+	return mr.MatchQueryParams(url.Values{key: {value}})
+}
+
+// MatchQueryParams makes mr only answer requests carrying all values of
+// params. Other query parameters are ignored.
+func (mr *mockResponse) MatchQueryParams(params url.Values) *mockResponse {
+	return mr.matchRequest("query "+params.Encode(), func(r *http.Request) bool {
+		got := r.URL.Query()
+		for k, values := range params {
+			for _, v := range values {
+				if !containsString(got[k], v) {
+					return false
+				}
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,36 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchQuery(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/users", "anna").MatchQuery("id", "1").MatchQuery("expand", "roles")
+	mock.Mock("/users", "tagged").MatchQueryParams(url.Values{"tag": {"a", "b"}})
+
+	for query, expected := range map[string]string{
+		"?id=1&expand=roles":      "anna",
+		"?expand=roles&id=2&id=1": "anna",
+		"?id=1":                   "/users not found",
+		"?tag=b&tag=a&sort=name":  "tagged",
+		"?tag=a":                  "/users not found",
+		"":                        "/users not found",
+	} {
+		resp, err := http.Get(mock.URL() + "/users" + query)
+		if assert.NoError(t, err) {
+			body, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Equal(t, expected, string(body), query)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", mock.URL()+"/users?id=1", nil)
+	assert.Contains(t, mock.Explain(r), "query expand=roles does not match")
+}
@@ -0,0 +1,182 @@
+package gohtmock
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// RawHeader is a request header as it was sent, before net/http
+// canonicalized its name.
+type RawHeader struct {
+	Name  string
+	Value string
+}
+
+// maxRawWindow is how many of the most recently received bytes of a
+// connection WithRawHeaders keeps to find header blocks in.
+const maxRawWindow = 64 << 10
+
+type rawConnKey struct{}
+
+// WithRawHeaders keeps the exact byte casing of received header names,
+// available as RecordedRequest.RawHeaders and to MatchRawHeader. It only
+// works for plain HTTP/1.x servers.
+func WithRawHeaders() Option {
+	return func(m *Mock) {
+		m.rawHeaders = true
+	}
+}
+
+// SetRawHeader sets a response header with name written exactly as given
+// instead of canonicalized, replacing earlier values of the same name.
+func (mr *mockResponse) SetRawHeader(name, value string) *mockResponse {
+	mr.Lock()
+	mr.headers[name] = []string{value}
+	mr.Unlock()
+	return mr
+}
+
+// MatchRawHeader makes mr only answer requests with a header whose name is
+// exactly name, byte casing included, and whose value is value. The Mock
+// must be created WithRawHeaders.
+func (mr *mockResponse) MatchRawHeader(name, value string) *mockResponse {
+	mr.Lock()
+	mr.rawHeaderMatchers = append(mr.rawHeaderMatchers, RawHeader{Name: name, Value: value})
+	mr.Unlock()
+	return mr
+}
+
+// matchesRawHeaders reports if r satisfies the MatchRawHeader calls of mr.
+func (mr *mockResponse) matchesRawHeaders(r *http.Request) bool {
+	mr.Lock()
+	matchers := mr.rawHeaderMatchers
+	mr.Unlock()
+	if len(matchers) == 0 {
+		return true
+	}
+	raw := rawHeadersOf(r)
+outer:
+	for _, m := range matchers {
+		for _, h := range raw {
+			if h.Name == m.Name && h.Value == m.Value {
+				continue outer
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// rawHeaderDiff returns the raw headers MatchRawHeader expects next to the
+// ones r has.
+func (mr *mockResponse) rawHeaderDiff(r *http.Request) Diff {
+	mr.Lock()
+	matchers := mr.rawHeaderMatchers
+	mr.Unlock()
+	return Diff{Subject: "raw headers", Expected: formatRawHeaders(matchers), Actual: formatRawHeaders(rawHeadersOf(r))}
+}
+
+func formatRawHeaders(headers []RawHeader) string {
+	lines := make([]string, len(headers))
+	for i, h := range headers {
+		lines[i] = h.Name + ": " + h.Value
+	}
+	return strings.Join(lines, "\n")
+}
+
+type rawHeadersKey struct{}
+
+// withRawHeaders returns r with the raw headers read from its connection,
+// if the connection is recorded.
+func withRawHeaders(r *http.Request) *http.Request {
+	c, ok := r.Context().Value(rawConnKey{}).(*rawConn)
+	if !ok {
+		return r
+	}
+	headers := c.headersOf(r)
+	return r.WithContext(context.WithValue(r.Context(), rawHeadersKey{}, headers))
+}
+
+func rawHeadersOf(r *http.Request) []RawHeader {
+	headers, _ := r.Context().Value(rawHeadersKey{}).([]RawHeader)
+	return headers
+}
+
+type rawListener struct {
+	net.Listener
+}
+
+func (l *rawListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &rawConn{Conn: c}, nil
+}
+
+// rawConn keeps the most recently received bytes of a connection.
+type rawConn struct {
+	net.Conn
+	window []byte
+	sync.Mutex
+}
+
+// NetConn returns the wrapped connection.
+func (c *rawConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+func (c *rawConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.Lock()
+	c.window = append(c.window, b[:n]...)
+	if len(c.window) > maxRawWindow {
+		c.window = append([]byte(nil), c.window[len(c.window)-maxRawWindow:]...)
+	}
+	c.Unlock()
+	return n, err
+}
+
+// headersOf finds the header block of r, the last one received with its
+// request line, and parses it.
+func (c *rawConn) headersOf(r *http.Request) []RawHeader {
+	requestLine := []byte(r.Method + " " + r.RequestURI + " HTTP/")
+	c.Lock()
+	defer c.Unlock()
+	start := bytes.LastIndex(c.window, requestLine)
+	if start < 0 {
+		return nil
+	}
+	block := c.window[start:]
+	if end := bytes.Index(block, []byte("\r\n\r\n")); end >= 0 {
+		block = block[:end]
+	}
+	lines := bytes.Split(block, []byte("\r\n"))
+	var headers []RawHeader
+	for _, line := range lines[1:] {
+		i := bytes.IndexByte(line, ':')
+		if i <= 0 {
+			continue
+		}
+		headers = append(headers, RawHeader{
+			Name:  string(line[:i]),
+			Value: string(bytes.TrimSpace(line[i+1:])),
+		})
+	}
+	return headers
+}
+
+// recordRawHeaders starts recording the connections of the server of m.
+func (m *Mock) recordRawHeaders() {
+	m.server.Listener = &rawListener{Listener: m.server.Listener}
+	m.server.Config.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		if rc, ok := c.(*rawConn); ok {
+			return context.WithValue(ctx, rawConnKey{}, rc)
+		}
+		return ctx
+	}
+}
@@ -0,0 +1,59 @@
+package gohtmock
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawHeaders(t *testing.T) {
+	mock := New(WithRawHeaders())
+	defer mock.Close()
+	legacy := mock.Mock("/legacy", "ok").MatchRawHeader("x-legacy-token", "secret").SetRawHeader("x-LEGACY-reply", "yes")
+	mock.Mock("/legacy", "canonical")
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(mock.URL(), "http://"))
+	assert.NoError(t, err)
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+	send := func(token string) (*http.Response, string) {
+		fmt.Fprintf(conn, "GET /legacy HTTP/1.1\r\nHost: mock\r\n%s: secret\r\n\r\n", token)
+		resp, err := http.ReadResponse(br, nil)
+		assert.NoError(t, err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return resp, string(body)
+	}
+
+	resp, body := send("x-legacy-token")
+	assert.Equal(t, "ok", body)
+	assert.Equal(t, []string{"yes"}, resp.Header["X-Legacy-Reply"])
+	_, body = send("X-Legacy-Token")
+	assert.Equal(t, "canonical", body)
+	_, body = send("x-legacy-token")
+	assert.Equal(t, "ok", body)
+
+	reqs := legacy.Requests()
+	assert.Len(t, reqs, 2)
+	assert.Contains(t, reqs[0].RawHeaders, RawHeader{Name: "x-legacy-token", Value: "secret"})
+	assert.Equal(t, "secret", reqs[0].Header.Get("X-Legacy-Token"))
+}
+
+func TestRawResponseHeaderCasing(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/casing", "").SetRawHeader("x-lower-case", "1")
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(mock.URL(), "http://"))
+	assert.NoError(t, err)
+	defer conn.Close()
+	fmt.Fprint(conn, "GET /casing HTTP/1.1\r\nHost: mock\r\nConnection: close\r\n\r\n")
+	raw, _ := ioutil.ReadAll(conn)
+	assert.Contains(t, string(raw), "\r\nx-lower-case: 1\r\n")
+}
@@ -0,0 +1,232 @@
+package gohtmock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RecordedRequest is a copy of a request received by a mock.
+type RecordedRequest struct {
+	Method string
+	URL    *url.URL
+	Host   string
+	// RemoteAddr is the address of the client connection
+	RemoteAddr string
+	// TLS is set when the request was sent over TLS, see WithTLS
+	TLS    bool
+	Header http.Header
+	Body   []byte
+	// Truncated is set when Body only holds the first bytes of the body,
+	// see WithMaxRecordedBody.
+	Truncated bool
+	Time      time.Time
+	// RawHeaders are the headers as sent, see WithRawHeaders
+	RawHeaders []RawHeader
+	// RedirectedFrom is the request whose redirect the client followed
+	// to send this one, nil if none
+	RedirectedFrom *RecordedRequest
+}
+
+// record copies r and replaces its body with a fresh reader of the same
+// content. If maxBody > 0 at most maxBody bytes are kept and the rest of the
+// body is streamed to the reader of r.Body instead of buffered.
+func record(r *http.Request, maxBody int) (*RecordedRequest, error) {
+	rr := &RecordedRequest{
+		Method:     r.Method,
+		URL:        cloneURL(r.URL),
+		Host:       r.Host,
+		RemoteAddr: r.RemoteAddr,
+		TLS:        r.TLS != nil,
+		Header:     r.Header.Clone(),
+		Time:       time.Now(),
+		RawHeaders: rawHeadersOf(r),
+	}
+	var err error
+	rr.Body, rr.Truncated, err = bufferBody(r, maxBody)
+	return rr, err
+}
+
+// bufferBody reads the body of r and replaces it with a replayBody, see
+// record for maxBody. Bodies longer than maxBody are not buffered and can
+// only be read once.
+func bufferBody(r *http.Request, maxBody int) (body []byte, truncated bool, err error) {
+	if r.Body == nil {
+		return nil, false, nil
+	}
+	if maxBody <= 0 {
+		body, err := ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		r.Body = newReplayBody(body)
+		return body, false, err
+	}
+
+	head, err := ioutil.ReadAll(io.LimitReader(r.Body, int64(maxBody)+1))
+	if len(head) <= maxBody {
+		r.Body.Close()
+		r.Body = newReplayBody(head)
+		return head, false, err
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+	return head[:maxBody], true, err
+}
+
+// replayBody is a buffered request body that rewind starts over, so that
+// every filter, body matcher and responder can read the whole body.
+type replayBody struct {
+	*bytes.Reader
+}
+
+func newReplayBody(body []byte) replayBody {
+	return replayBody{bytes.NewReader(body)}
+}
+
+func (replayBody) Close() error {
+	return nil
+}
+
+// rewind makes the body of r readable from the start again if it is
+// buffered.
+func rewind(r *http.Request) {
+	if b, ok := r.Body.(replayBody); ok {
+		b.Seek(0, io.SeekStart)
+	}
+}
+
+func cloneURL(u *url.URL) *url.URL {
+	cp := *u
+	if u.User != nil {
+		user := *u.User
+		cp.User = &user
+	}
+	return &cp
+}
+
+// clone returns a deep copy of rr, so that changing it leaves the history
+// of the mock alone.
+func (rr *RecordedRequest) clone() *RecordedRequest {
+	cp := *rr
+	cp.URL = cloneURL(rr.URL)
+	cp.Header = rr.Header.Clone()
+	if rr.Body != nil {
+		cp.Body = append([]byte{}, rr.Body...)
+	}
+	if rr.RawHeaders != nil {
+		cp.RawHeaders = append([]RawHeader{}, rr.RawHeaders...)
+	}
+	if rr.RedirectedFrom != nil {
+		cp.RedirectedFrom = rr.RedirectedFrom.clone()
+	}
+	return &cp
+}
+
+// DecodeJSON unmarshals the body into v.
+func (rr *RecordedRequest) DecodeJSON(v any) error {
+	if err := json.Unmarshal(rr.Body, v); err != nil {
+		return fmt.Errorf("decoding body of %s %s: %w", rr.Method, rr.URL.Path, err)
+	}
+	return nil
+}
+
+// Query returns the parsed query string.
+func (rr *RecordedRequest) Query() url.Values {
+	return rr.URL.Query()
+}
+
+// FormValues parses an application/x-www-form-urlencoded or
+// multipart/form-data body. Files in multipart bodies are skipped.
+func (rr *RecordedRequest) FormValues() (url.Values, error) {
+	mediaType, params, err := mime.ParseMediaType(rr.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("parsing content type of %s %s: %w", rr.Method, rr.URL.Path, err)
+	}
+
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		return url.ParseQuery(string(rr.Body))
+	case strings.HasPrefix(mediaType, "multipart/"):
+		values := url.Values{}
+		reader := multipart.NewReader(bytes.NewReader(rr.Body), params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return values, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("reading multipart body of %s %s: %w", rr.Method, rr.URL.Path, err)
+			}
+			if part.FileName() != "" {
+				continue
+			}
+			b, err := ioutil.ReadAll(part)
+			if err != nil {
+				return nil, err
+			}
+			values.Add(part.FormName(), string(b))
+		}
+	}
+	return nil, fmt.Errorf("%s %s has no form body but %s", rr.Method, rr.URL.Path, mediaType)
+}
+
+// Requests returns copies of the requests answered by mr in order. With
+// WithMaxHistory only the most recent ones are kept.
+func (mr *mockResponse) Requests() []*RecordedRequest {
+	mr.Lock()
+	defer mr.Unlock()
+	var requests []*RecordedRequest
+	for _, rr := range mr.requests {
+		requests = append(requests, rr.clone())
+	}
+	return requests
+}
+
+// Requests returns copies of the method requests to path answered by any
+// mock, oldest first. method may be ANY. Requests no mock answered are
+// left out, see UnmatchedRequests.
+func (m *Mock) Requests(method, path string) []*RecordedRequest {
+	m.Lock()
+	mocks := m.mockResponses
+	m.Unlock()
+	var requests []*RecordedRequest
+	for _, mr := range mocks {
+		for _, rr := range mr.Requests() {
+			if (method == "ANY" || rr.Method == method) && rr.URL.Path == path {
+				requests = append(requests, rr)
+			}
+		}
+	}
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].Time.Before(requests[j].Time)
+	})
+	return requests
+}
+
+// DroppedRequests returns how many of the oldest requests were dropped from
+// Requests because of WithMaxHistory.
+func (mr *mockResponse) DroppedRequests() int {
+	mr.Lock()
+	defer mr.Unlock()
+	return mr.droppedRequests
+}
+
+// addRequest keeps rr, dropping the oldest request beyond maxHistory. mr must be locked.
+func (mr *mockResponse) addRequest(rr *RecordedRequest, maxHistory int) {
+	mr.requests = append(mr.requests, rr)
+	if maxHistory > 0 && len(mr.requests) > maxHistory {
+		dropped := len(mr.requests) - maxHistory
+		mr.requests = append(mr.requests[:0:0], mr.requests[dropped:]...)
+		mr.droppedRequests += dropped
+	}
+}
@@ -0,0 +1,180 @@
+package gohtmock
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordedRequestDecoding(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	jsonMock := mock.Mock("/json", "ok").SetMethod("POST")
+	formMock := mock.Mock("/form", "ok").SetMethod("POST")
+
+	_, err := http.Post(mock.URL()+"/json?page=2&sort=name", "application/json", strings.NewReader(`{"name":"foo"}`))
+	assert.NoError(t, err)
+	_, err = http.PostForm(mock.URL()+"/form", url.Values{"a": {"1", "2"}})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	assert.NoError(t, mw.WriteField("b", "3"))
+	fw, err := mw.CreateFormFile("file", "file.txt")
+	assert.NoError(t, err)
+	_, _ = fw.Write([]byte("content"))
+	assert.NoError(t, mw.Close())
+	_, err = http.Post(mock.URL()+"/form", mw.FormDataContentType(), &buf)
+	assert.NoError(t, err)
+
+	reqs := jsonMock.Requests()
+	assert.Len(t, reqs, 1)
+	var body struct{ Name string }
+	assert.NoError(t, reqs[0].DecodeJSON(&body))
+	assert.Equal(t, "foo", body.Name)
+	assert.Equal(t, url.Values{"page": {"2"}, "sort": {"name"}}, reqs[0].Query())
+	assert.Equal(t, "application/json", reqs[0].Header.Get("Content-Type"))
+	_, err = reqs[0].FormValues()
+	assert.Error(t, err)
+
+	reqs = formMock.Requests()
+	assert.Len(t, reqs, 2)
+	values, err := reqs[0].FormValues()
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"a": {"1", "2"}}, values)
+	values, err = reqs[1].FormValues()
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"b": {"3"}}, values)
+	assert.Error(t, reqs[1].DecodeJSON(&body))
+}
+
+func TestRecordedBodyStillReadable(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	var seen string
+	mock.MockFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		seen = string(b)
+	}).SetMethod("PUT")
+
+	req, _ := http.NewRequest("PUT", mock.URL()+"/echo", strings.NewReader("payload"))
+	_, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "payload", seen)
+}
+
+func TestRecordingCaps(t *testing.T) {
+	mock := New(WithMaxRecordedBody(4), WithMaxHistory(2))
+	defer mock.Close()
+	var seen []string
+	mr := mock.MockFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		seen = append(seen, string(b))
+	}).SetMethod("POST")
+
+	for _, body := range []string{"abc", "abcdefgh", "0123456789"} {
+		_, err := http.Post(mock.URL()+"/upload", "text/plain", strings.NewReader(body))
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"abc", "abcdefgh", "0123456789"}, seen)
+	reqs := mr.Requests()
+	if assert.Len(t, reqs, 2) {
+		assert.Equal(t, "abcd", string(reqs[0].Body))
+		assert.True(t, reqs[0].Truncated)
+		assert.Equal(t, "0123", string(reqs[1].Body))
+		assert.True(t, strings.HasSuffix(reqs[1].Curl(), "# body truncated"))
+	}
+	assert.Equal(t, 1, mr.DroppedRequests())
+	mock.AssertCallCount(t, "POST", "/upload", 3)
+}
+
+func TestBodyReadByFilters(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"recording":         nil,
+		"without recording": {WithoutRecording()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mock := New(opts...)
+			defer mock.Close()
+			bodyIs := func(expected string) func(*http.Request) bool {
+				return func(r *http.Request) bool {
+					body, _ := ioutil.ReadAll(r.Body)
+					return string(body) == expected
+				}
+			}
+			mock.MockFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				fmt.Fprintf(w, "first got %s", body)
+			}).SetMethod("POST").Filter(bodyIs("one"))
+			mock.MockFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				fmt.Fprintf(w, "second got %s", body)
+			}).SetMethod("POST").MatchBodyString("two").Filter(bodyIs("two"))
+
+			for body, expected := range map[string]string{"one": "first got one", "two": "second got two"} {
+				resp, err := http.Post(mock.URL()+"/orders", "text/plain", strings.NewReader(body))
+				if assert.NoError(t, err) {
+					got, _ := ioutil.ReadAll(resp.Body)
+					resp.Body.Close()
+					assert.Equal(t, expected, string(got))
+				}
+			}
+		})
+	}
+}
+
+func TestMockRequests(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("POST /users/{id}", "first").Times(1)
+	mock.Mock("/users/{id}", "any").SetMethod("ANY")
+
+	for _, body := range []string{"a", "b"} {
+		resp, err := http.Post(mock.URL()+"/users/1?notify=true", "text/plain", strings.NewReader(body))
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	resp, err := http.Get(mock.URL() + "/users/1")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	posts := mock.Requests("POST", "/users/1")
+	if assert.Len(t, posts, 2) {
+		assert.Equal(t, "a", string(posts[0].Body))
+		assert.Equal(t, "b", string(posts[1].Body))
+		assert.Equal(t, "true", posts[0].URL.Query().Get("notify"))
+		assert.Equal(t, "text/plain", posts[1].Header.Get("Content-Type"))
+	}
+	assert.Len(t, mock.Requests("ANY", "/users/1"), 3)
+	assert.Empty(t, mock.Requests("GET", "/users/2"))
+}
+
+func TestRequestsAreCopies(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mr := mock.Mock("POST /users", "ok")
+
+	resp, err := http.Post(mock.URL()+"/users?notify=true", "text/plain", strings.NewReader("body"))
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	rr := mr.Requests()[0]
+	rr.Header.Set("Content-Type", "changed")
+	rr.Body[0] = 'B'
+	rr.URL.RawQuery = ""
+	for _, requests := range [][]*RecordedRequest{mr.Requests(), mock.Requests("POST", "/users")} {
+		if assert.Len(t, requests, 1) {
+			assert.Equal(t, "text/plain", requests[0].Header.Get("Content-Type"))
+			assert.Equal(t, "body", string(requests[0].Body))
+			assert.Equal(t, "notify=true", requests[0].URL.RawQuery)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package gohtmock
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// redirect is a redirect answered by a mock.
+type redirect struct {
+	// from and to are request URIs, to is absolute if it leaves the mock
+	from string
+	to   string
+	// chain is the method and URI of every request of the redirect chain
+	// up to and including from, targets the location each was sent to
+	chain    []string
+	targets  []string
+	request  *RecordedRequest
+	mr       *mockResponse
+	followed bool
+	// loop describes the loop this redirect closes, "" if none
+	loop string
+}
+
+// StopRedirectLoops makes the mock answer a request following a redirect
+// that closed a loop, see AssertNoRedirectLoops, with 508 Loop Detected
+// instead of the mocked response. Without it looping clients are only
+// stopped by their own redirect limit.
+func (m *Mock) StopRedirectLoops() {
+	m.Lock()
+	m.stopRedirectLoops = true
+	m.Unlock()
+}
+
+// followRedirect marks the latest pending redirect to the URI of r as
+// followed and returns it, nil if r does not follow a redirect. A request
+// with a Referer only follows redirects sent from there. If the redirect
+// closed a loop and loops are stopped it returns the loop, "" otherwise.
+func (m *Mock) followRedirect(r *http.Request, recorded *RecordedRequest) (*redirect, string) {
+	if m.withoutAssertions {
+		return nil, ""
+	}
+	uri := r.URL.RequestURI()
+	referer := ""
+	if u, err := url.Parse(r.Referer()); err == nil && r.Referer() != "" {
+		referer = u.RequestURI()
+	}
+	m.Lock()
+	defer m.Unlock()
+	for i := len(m.redirects) - 1; i >= 0; i-- {
+		rd := m.redirects[i]
+		if rd.followed || rd.to != uri || referer != "" && referer != rd.from {
+			continue
+		}
+		rd.followed = true
+		if recorded != nil {
+			recorded.RedirectedFrom = rd.request
+		}
+		if rd.loop != "" && m.stopRedirectLoops {
+			return rd, rd.loop
+		}
+		return rd, ""
+	}
+	return nil, ""
+}
+
+// noteRedirect keeps the redirect to location answered by mr to r, which
+// followed via, nil if none. A redirect repeating a hop of its chain, the
+// same request redirected to the same location, closes a loop.
+func (m *Mock) noteRedirect(r *http.Request, via *redirect, recorded *RecordedRequest, mr *mockResponse, location string) {
+	if m.withoutAssertions {
+		return
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return
+	}
+	target := (&url.URL{Path: r.URL.Path, RawQuery: r.URL.RawQuery}).ResolveReference(ref)
+	to := target.RequestURI()
+	if target.Host != "" && target.Host != r.Host {
+		to = target.String()
+	}
+	from := r.URL.RequestURI()
+	hop := r.Method + " " + from
+	rd := &redirect{from: from, to: to, request: recorded, mr: mr}
+	if via != nil {
+		rd.chain = append(rd.chain, via.chain...)
+		rd.targets = append(rd.targets, via.targets...)
+		rd.loop = via.loop
+	}
+	repeated := false
+	for i := range rd.chain {
+		if rd.chain[i] == hop && rd.targets[i] == to {
+			repeated = true
+		}
+	}
+	rd.chain = append(rd.chain, hop)
+	rd.targets = append(rd.targets, to)
+	if repeated && rd.loop == "" {
+		rd.loop = "redirect loop: " + strings.Join(rd.chain, " -> ")
+		if !mr.fail("%s%s", rd.loop, mr.ownedBy()) {
+			log.Print("gohtmock: ", rd.loop)
+		}
+		m.Lock()
+		m.redirectLoops = append(m.redirectLoops, rd.loop)
+		m.Unlock()
+	}
+	m.Lock()
+	m.redirects = append(m.redirects, rd)
+	m.Unlock()
+}
+
+// serveRedirectLoop answers a request following a redirect that closed a
+// loop with 508 Loop Detected, see StopRedirectLoops.
+func serveRedirectLoop(w http.ResponseWriter, loop string) {
+	w.WriteHeader(http.StatusLoopDetected)
+	fmt.Fprint(w, "gohtmock: ", loop)
+}
+
+// AssertRedirectFollowed asserts that a mock answered a request to from
+// with a redirect to to and that the client followed it. from and to are
+// paths, or request URIs to also compare the query.
+func (m *Mock) AssertRedirectFollowed(tb testing.TB, from, to string) {
+	if m.assertionsDisabled(tb) {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+	var issued []string
+	for _, rd := range m.redirects {
+		if uriMatches(rd.from, from) && uriMatches(rd.to, to) {
+			if rd.followed {
+				return
+			}
+			tb.Errorf("redirect from %s to %s was not followed", rd.from, rd.to)
+			return
+		}
+		issued = append(issued, rd.from+" -> "+rd.to)
+	}
+	if len(issued) == 0 {
+		tb.Errorf("no redirect from %s to %s, no redirects were sent", from, to)
+		return
+	}
+	tb.Errorf("no redirect from %s to %s, redirects sent: %s", from, to, strings.Join(issued, ", "))
+}
+
+// AssertNoRedirectLoops fails tb for every redirect loop detected, that is
+// every redirect chain in which the same request was redirected to the same
+// location twice.
+func (m *Mock) AssertNoRedirectLoops(tb testing.TB) {
+	if m.assertionsDisabled(tb) {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+	for _, loop := range m.redirectLoops {
+		tb.Errorf("%s", loop)
+	}
+}
+
+// uriMatches reports if uri is want, or has the path want if want has no
+// query.
+func uriMatches(uri, want string) bool {
+	if uri == want {
+		return true
+	}
+	if strings.Contains(want, "?") {
+		return false
+	}
+	path, _, _ := strings.Cut(uri, "?")
+	return path == want
+}
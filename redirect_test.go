@@ -0,0 +1,112 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertRedirectFollowed(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/old", "").WithStatus(http.StatusMovedPermanently).SetHeader("Location", "/new?page=1")
+	newPage := mock.Mock("/new", "here")
+	mock.Mock("/external", "").WithStatus(http.StatusFound).SetHeader("Location", "https://example.com/")
+
+	resp, err := http.Get(mock.URL() + "/old")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err = client.Get(mock.URL() + "/external")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	mock.AssertRedirectFollowed(t, "/old", "/new")
+	mock.AssertRedirectFollowed(t, "/old", "/new?page=1")
+	mock.AssertNoRedirectLoops(t)
+	if assert.Len(t, newPage.Requests(), 1) {
+		from := newPage.Requests()[0].RedirectedFrom
+		if assert.NotNil(t, from) {
+			assert.Equal(t, "/old", from.URL.Path)
+		}
+	}
+
+	rt := newRecordingT("TestAssertRedirectFollowed")
+	mock.AssertRedirectFollowed(rt, "/external", "https://example.com/")
+	mock.AssertRedirectFollowed(rt, "/old", "/other")
+	assert.Equal(t, []string{
+		"redirect from /external to https://example.com/ was not followed",
+		"no redirect from /old to /other, redirects sent: /old -> /new?page=1, /external -> https://example.com/",
+	}, rt.Errors())
+}
+
+func TestRedirectLoop(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	rt := newRecordingT("TestRedirectLoop")
+	mock.For(rt).Mock("/a", "").WithStatus(http.StatusFound).SetHeader("Location", "/b")
+	mock.For(rt).Mock("/b", "").WithStatus(http.StatusFound).SetHeader("Location", "/a")
+
+	_, err := http.Get(mock.URL() + "/a")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "stopped after 10 redirects")
+	}
+	assert.Equal(t, []string{"redirect loop: GET /a -> GET /b -> GET /a (mocked by TestRedirectLoop)"}, rt.Errors())
+
+	rt = newRecordingT("TestRedirectLoop")
+	mock.AssertNoRedirectLoops(rt)
+	assert.Equal(t, []string{"redirect loop: GET /a -> GET /b -> GET /a"}, rt.Errors())
+}
+
+func TestStopRedirectLoops(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.StopRedirectLoops()
+	mock.Mock("/a", "").WithStatus(http.StatusFound).SetHeader("Location", "/b")
+	mock.Mock("/b", "").WithStatus(http.StatusFound).SetHeader("Location", "/a")
+
+	resp, err := http.Get(mock.URL() + "/a")
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusLoopDetected, resp.StatusCode)
+		assert.Equal(t, "gohtmock: redirect loop: GET /a -> GET /b -> GET /a", string(body))
+	}
+	assert.Error(t, Check(mock.AssertNoRedirectLoops))
+}
+
+func TestLoginRedirectIsNoLoop(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.StopRedirectLoops()
+	mock.Mock("/app", "home").Filter(func(r *http.Request) bool {
+		_, err := r.Cookie("session")
+		return err == nil
+	})
+	mock.Mock("/app", "").WithStatus(http.StatusFound).SetHeader("Location", "/login")
+	mock.Mock("/login", "").WithStatus(http.StatusFound).SetHeader("Location", "/app").SetHeader("Set-Cookie", "session=1; Path=/")
+
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+	resp, err := client.Get(mock.URL() + "/app")
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "home", string(body))
+	}
+	mock.AssertNoRedirectLoops(t)
+	mock.AssertRedirectFollowed(t, "/login", "/app")
+
+	// a later visit to /app is a new request, not one following the redirect
+	resp, err = client.Get(mock.URL() + "/app")
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+	mock.AssertNoRedirectLoops(t)
+}
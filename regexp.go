@@ -0,0 +1,49 @@
+package gohtmock
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// MockRegexp registers a mock answering GET requests to all paths fully
+// matching pattern, such as `/orders/\d+/items`, with resp. The groups of
+// pattern are available through PathParams. Calls are still counted per
+// concrete path for AssertCallCount, and MatchedPaths tells which paths the
+// mock answered. It panics if pattern does not compile.
+func (m *Mock) MockRegexp(pattern, resp string) *mockResponse {
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		panic(fmt.Sprintf("gohtmock: MockRegexp: %s", err))
+	}
+	mr := m.newMockResponse("", resp)
+	mr.path = pattern
+	mr.pathPattern = re
+	m.add(mr)
+	return mr
+}
+
+// MatchedPaths returns how many times mr answered each concrete path. It is
+// empty for mocks with exact paths.
+func (mr *mockResponse) MatchedPaths() map[string]int {
+	mr.Lock()
+	defer mr.Unlock()
+	paths := make(map[string]int, len(mr.matchedPaths))
+	for p, n := range mr.matchedPaths {
+		paths[p] = n
+	}
+	return paths
+}
+
+// notePath counts a call of mr to the path of r if mr has a path pattern.
+func (mr *mockResponse) notePath(r *http.Request) {
+	mr.Lock()
+	defer mr.Unlock()
+	if mr.pathPattern == nil {
+		return
+	}
+	if mr.matchedPaths == nil {
+		mr.matchedPaths = make(map[string]int)
+	}
+	mr.matchedPaths[r.URL.Path]++
+}
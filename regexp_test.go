@@ -0,0 +1,41 @@
+package gohtmock
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockRegexp(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mr := mock.MockRegexp(`/orders/(?P<id>\d+)/items`, `{"order": "{{ .Params.id }}"}`).Template()
+
+	for _, path := range []string{"/orders/1/items", "/orders/1/items", "/orders/22/items", "/orders/x/items", "/orders/1/items/3"} {
+		resp, err := http.Get(mock.URL() + path)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, map[string]int{"/orders/1/items": 2, "/orders/22/items": 1}, mr.MatchedPaths())
+	mock.AssertCallCount(t, "GET", "/orders/1/items", 2)
+	mock.AssertCallCount(t, "GET", "/orders/22/items", 1)
+	assert.Equal(t, 3, mr.CallCount())
+	assert.Len(t, mock.UnmatchedRequests(), 2)
+
+	assert.Panics(t, func() { mock.MockRegexp(`/orders/(`, "") })
+}
+
+func TestMockRegexpParams(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.MockRegexp(`/orders/(?P<id>\d+)`, "").SetHeaderFunc("Location", func(r *http.Request) string {
+		return fmt.Sprintf("/v2/orders/%s", PathParams(r)["id"])
+	})
+
+	resp, err := http.Get(mock.URL() + "/orders/5")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "/v2/orders/5", resp.Header.Get("Location"))
+}
@@ -0,0 +1,273 @@
+// Package registry emulates the pull side of the Docker registry HTTP API v2
+// on top of a gohtmock.Mock: the bearer token dance, manifests and blobs,
+// including ranged blob GETs.
+package registry
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fortnoxab/gohtmock"
+)
+
+const (
+	MediaTypeManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest  = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex     = "application/vnd.oci.image.index.v1+json"
+)
+
+const service = "gohtmock-registry"
+
+func init() {
+	gohtmock.RegisterPreset("docker-registry", func() gohtmock.Preset { return New() })
+}
+
+var _ gohtmock.Preset = (*Registry)(nil)
+
+type Option func(*Registry)
+
+// WithTokenAuth enables the bearer token flow. Clients are challenged on /v2/
+// and must fetch a token from /token. If username is not empty the token
+// endpoint requires those basic auth credentials.
+func WithTokenAuth(username, password string) Option {
+	return func(r *Registry) {
+		r.auth = true
+		r.username = username
+		r.password = password
+	}
+}
+
+type manifest struct {
+	mediaType string
+	digest    string
+	content   []byte
+}
+
+type Registry struct {
+	mock       *gohtmock.Mock
+	auth       bool
+	username   string
+	password   string
+	tokens     map[string]bool
+	manifests  map[string]manifest
+	blobs      map[string][]byte
+	registered map[string]bool
+	sync.Mutex
+}
+
+func New(opts ...Option) *Registry {
+	r := &Registry{
+		tokens:     make(map[string]bool),
+		manifests:  make(map[string]manifest),
+		blobs:      make(map[string][]byte),
+		registered: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Install registers the base and token endpoints and everything added so far on m.
+func (r *Registry) Install(m *gohtmock.Mock) error {
+	r.Lock()
+	defer r.Unlock()
+	if r.mock != nil {
+		return fmt.Errorf("registry: already installed")
+	}
+	r.mock = m
+	r.register("/v2/", r.serveBase)
+	if r.auth {
+		r.register("/token", r.serveToken)
+	}
+	for path := range r.manifests {
+		r.registerManifest(path)
+	}
+	for path, blob := range r.blobs {
+		r.registerBlob(path, blob)
+	}
+	return nil
+}
+
+// AddBlob stores content in repository and returns its digest.
+func (r *Registry) AddBlob(repository string, content []byte) string {
+	digest := Digest(content)
+	path := "/v2/" + repository + "/blobs/" + digest
+
+	r.Lock()
+	defer r.Unlock()
+	r.blobs[path] = content
+	r.registerBlob(path, content)
+	return digest
+}
+
+// AddManifest stores a manifest in repository under reference, normally a
+// tag. It is also served by its digest, which is returned.
+func (r *Registry) AddManifest(repository, reference, mediaType string, content []byte) string {
+	mf := manifest{mediaType: mediaType, digest: Digest(content), content: content}
+	base := "/v2/" + repository + "/manifests/"
+
+	r.Lock()
+	defer r.Unlock()
+	for _, ref := range []string{reference, mf.digest} {
+		if ref == "" {
+			continue
+		}
+		r.manifests[base+ref] = mf
+		r.registerManifest(base + ref)
+	}
+	return mf.digest
+}
+
+// AddImage stores config and layers as blobs and a schema 2 manifest
+// referencing them under repository:tag. It returns the manifest digest.
+func (r *Registry) AddImage(repository, tag string, config []byte, layers ...[]byte) string {
+	type descriptor struct {
+		MediaType string `json:"mediaType"`
+		Size      int    `json:"size"`
+		Digest    string `json:"digest"`
+	}
+	mf := struct {
+		SchemaVersion int          `json:"schemaVersion"`
+		MediaType     string       `json:"mediaType"`
+		Config        descriptor   `json:"config"`
+		Layers        []descriptor `json:"layers"`
+	}{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeManifest,
+		Config: descriptor{
+			MediaType: "application/vnd.docker.container.image.v1+json",
+			Size:      len(config),
+			Digest:    r.AddBlob(repository, config),
+		},
+		Layers: []descriptor{},
+	}
+	for _, layer := range layers {
+		mf.Layers = append(mf.Layers, descriptor{
+			MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip",
+			Size:      len(layer),
+			Digest:    r.AddBlob(repository, layer),
+		})
+	}
+	content, _ := json.Marshal(mf)
+	return r.AddManifest(repository, tag, MediaTypeManifest, content)
+}
+
+func (r *Registry) register(path string, fn http.HandlerFunc) {
+	if r.mock == nil || r.registered[path] {
+		return
+	}
+	r.registered[path] = true
+	r.mock.MockFunc(path, fn)
+	r.mock.MockFunc(path, fn).SetMethod(http.MethodHead)
+}
+
+// registerManifest serves the manifest stored for path, looked up for every
+// request since a tag may be moved to another manifest.
+func (r *Registry) registerManifest(path string) {
+	r.register(path, func(w http.ResponseWriter, req *http.Request) {
+		if !r.authorized(w, req) {
+			return
+		}
+		r.Lock()
+		mf := r.manifests[path]
+		r.Unlock()
+		w.Header().Set("Content-Type", mf.mediaType)
+		w.Header().Set("Docker-Content-Digest", mf.digest)
+		http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(mf.content))
+	})
+}
+
+func (r *Registry) registerBlob(path string, content []byte) {
+	digest := Digest(content)
+	r.register(path, func(w http.ResponseWriter, req *http.Request) {
+		if !r.authorized(w, req) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Docker-Content-Digest", digest)
+		http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(content))
+	})
+}
+
+func (r *Registry) serveBase(w http.ResponseWriter, req *http.Request) {
+	if !r.authorized(w, req) {
+		return
+	}
+	w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+	fmt.Fprint(w, "{}")
+}
+
+func (r *Registry) serveToken(w http.ResponseWriter, req *http.Request) {
+	if r.username != "" {
+		username, password, ok := req.BasicAuth()
+		if !ok || username != r.username || password != r.password {
+			writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid credentials")
+			return
+		}
+	}
+
+	r.Lock()
+	token := fmt.Sprintf("gohtmock-token-%d", len(r.tokens)+1)
+	r.tokens[token] = true
+	r.Unlock()
+
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"token":        token,
+		"access_token": token,
+		"expires_in":   300,
+		"issued_at":    time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+func (r *Registry) authorized(w http.ResponseWriter, req *http.Request) bool {
+	if !r.auth {
+		return true
+	}
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	r.Lock()
+	ok := r.tokens[token]
+	r.Unlock()
+	if ok {
+		return true
+	}
+
+	challenge := fmt.Sprintf(`Bearer realm="%s/token",service="%s"`, r.mock.URL(), service)
+	if scope := scopeFor(req.URL.Path); scope != "" {
+		challenge += fmt.Sprintf(`,scope="%s"`, scope)
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "authentication required")
+	return false
+}
+
+func scopeFor(path string) string {
+	path = strings.TrimPrefix(path, "/v2/")
+	for _, sep := range []string{"/manifests/", "/blobs/"} {
+		if i := strings.LastIndex(path, sep); i > 0 {
+			return "repository:" + path[:i] + ":pull"
+		}
+	}
+	return ""
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"errors": []map[string]string{{"code": code, "message": message}},
+	})
+}
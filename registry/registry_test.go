@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/fortnoxab/gohtmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPullWithToken(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	reg := New(WithTokenAuth("user", "pass"))
+	assert.NoError(t, reg.Install(mock))
+	digest := reg.AddImage("library/app", "latest", []byte(`{"os":"linux"}`), []byte("layer-content"))
+
+	resp, err := http.Get(mock.URL() + "/v2/")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("WWW-Authenticate"), `realm="`+mock.URL()+`/token"`)
+
+	req, _ := http.NewRequest("GET", mock.URL()+"/token?scope=repository:library/app:pull", nil)
+	req.SetBasicAuth("user", "pass")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	token := struct{ Token string }{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&token))
+	assert.NotEmpty(t, token.Token)
+
+	get := func(path, rng string) *http.Response {
+		req, _ := http.NewRequest("GET", mock.URL()+path, nil)
+		req.Header.Set("Authorization", "Bearer "+token.Token)
+		if rng != "" {
+			req.Header.Set("Range", rng)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	resp = get("/v2/library/app/manifests/latest", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, MediaTypeManifest, resp.Header.Get("Content-Type"))
+	assert.Equal(t, digest, resp.Header.Get("Docker-Content-Digest"))
+	mf := struct{ Layers []struct{ Digest string } }{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&mf))
+	assert.Len(t, mf.Layers, 1)
+
+	resp = get("/v2/library/app/blobs/"+mf.Layers[0].Digest, "bytes=6-12")
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(body))
+
+	mock.AssertCallCount(t, "GET", "/v2/library/app/manifests/latest", 1)
+}
+
+func TestTokenRequiresCredentials(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	reg := New(WithTokenAuth("user", "pass"))
+	assert.NoError(t, reg.Install(mock))
+
+	resp, err := http.Get(mock.URL() + "/token")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestAnonymousHead(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	reg := New()
+	digest := reg.AddManifest("app", "v1", MediaTypeOCIManifest, []byte(`{"schemaVersion":2}`))
+	assert.NoError(t, reg.Install(mock))
+
+	resp, err := http.Head(mock.URL() + "/v2/app/manifests/" + digest)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, digest, resp.Header.Get("Docker-Content-Digest"))
+	assert.Equal(t, int64(19), resp.ContentLength)
+}
+
+func TestRetagServesCurrentManifest(t *testing.T) {
+	mock := gohtmock.New()
+	defer mock.Close()
+	reg := New()
+	assert.NoError(t, reg.Install(mock))
+	reg.AddManifest("app", "latest", MediaTypeOCIManifest, []byte(`{"schemaVersion":1}`))
+	digest := reg.AddManifest("app", "latest", MediaTypeOCIManifest, []byte(`{"schemaVersion":2}`))
+
+	resp, err := http.Get(mock.URL() + "/v2/app/manifests/latest")
+	assert.NoError(t, err)
+	assert.Equal(t, digest, resp.Header.Get("Docker-Content-Digest"))
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, `{"schemaVersion":2}`, string(body))
+}
@@ -0,0 +1,153 @@
+package gohtmock
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// requestMatcher is a condition on requests, desc names it in Explain.
+type requestMatcher struct {
+	desc string
+	fn   func(*http.Request) bool
+}
+
+func (mr *mockResponse) matchRequest(desc string, fn func(*http.Request) bool) *mockResponse {
+	mr.Lock()
+	mr.requestMatchers = append(mr.requestMatchers, requestMatcher{desc: desc, fn: fn})
+	mr.Unlock()
+	return mr
+}
+
+// failedMatcher returns the description of the first request matcher of mr
+// that rejects r, "" if all match.
+func (mr *mockResponse) failedMatcher(r *http.Request) string {
+	mr.Lock()
+	matchers := mr.requestMatchers
+	mr.Unlock()
+	for _, m := range matchers {
+		if !m.fn(r) {
+			return m.desc
+		}
+	}
+	return ""
+}
+
+// MatchRemoteAddr makes mr only answer requests from connections whose
+// remote IP is in cidr, such as "10.0.0.0/8", or is cidr if it is a single
+// IP. It panics if cidr is neither.
+func (mr *mockResponse) MatchRemoteAddr(cidr string) *mockResponse {
+	network, err := parseCIDR(cidr)
+	if err != nil {
+		panic(fmt.Sprintf("gohtmock: MatchRemoteAddr: %s", err))
+	}
+	return mr.matchRequest("remote address "+cidr, func(r *http.Request) bool {
+		ip := net.ParseIP(hostOf(r.RemoteAddr))
+		return ip != nil && network.Contains(ip)
+	})
+}
+
+// MatchForwardedFor makes mr only answer requests whose forwarding chain,
+// see ForwardedFor, is ips with the client first.
+func (mr *mockResponse) MatchForwardedFor(ips ...string) *mockResponse {
+	return mr.matchRequest("forwarded for "+strings.Join(ips, ", "), func(r *http.Request) bool {
+		chain := ForwardedFor(r.Header)
+		if len(chain) != len(ips) {
+			return false
+		}
+		for i := range chain {
+			if chain[i] != ips[i] {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// ForwardedFor returns the addresses a request was forwarded for, client
+// first, from X-Forwarded-For or, if that is missing, the for parameters of
+// Forwarded. Ports and IPv6 brackets are removed.
+func ForwardedFor(h http.Header) []string {
+	var chain []string
+	if values := h.Values("X-Forwarded-For"); len(values) > 0 {
+		for _, v := range values {
+			for _, ip := range strings.Split(v, ",") {
+				if ip = strings.TrimSpace(ip); ip != "" {
+					chain = append(chain, hostOf(ip))
+				}
+			}
+		}
+		return chain
+	}
+	for _, v := range h.Values("Forwarded") {
+		for _, element := range strings.Split(v, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					chain = append(chain, hostOf(strings.Trim(value, `"`)))
+				}
+			}
+		}
+	}
+	return chain
+}
+
+// ForwardedFor returns the forwarding chain of the request, see the
+// function ForwardedFor.
+func (rr *RecordedRequest) ForwardedFor() []string {
+	return ForwardedFor(rr.Header)
+}
+
+// Forwarded returns a request middleware for UseRequest that makes requests
+// look as if client sent them through proxies: client and all proxies but
+// the last are appended to X-Forwarded-For and Forwarded, and RemoteAddr is
+// set to the last proxy, or the client if there are none.
+func Forwarded(client string, proxies ...string) func(*http.Request) *http.Request {
+	chain := append([]string{client}, proxies...)
+	return func(r *http.Request) *http.Request {
+		r = r.Clone(r.Context())
+		hops := chain[:len(chain)-1]
+		if len(hops) > 0 {
+			r.Header.Add("X-Forwarded-For", strings.Join(hops, ", "))
+			elements := make([]string, len(hops))
+			for i, hop := range hops {
+				elements[i] = "for=" + forwardedNode(hop)
+			}
+			r.Header.Add("Forwarded", strings.Join(elements, ", "))
+		}
+		r.RemoteAddr = net.JoinHostPort(chain[len(chain)-1], "0")
+		return r
+	}
+}
+
+// forwardedNode quotes IPv6 addresses as Forwarded requires.
+func forwardedNode(ip string) string {
+	if strings.Contains(ip, ":") {
+		return `"[` + ip + `]"`
+	}
+	return ip
+}
+
+// hostOf strips the port and IPv6 brackets from addr.
+func hostOf(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.Trim(addr, "[]")
+}
+
+func parseCIDR(s string) (*net.IPNet, error) {
+	if _, network, err := net.ParseCIDR(s); err == nil {
+		return network, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("%q is neither an IP nor a CIDR", s)
+	}
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
@@ -0,0 +1,51 @@
+package gohtmock
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForwardedFor(t *testing.T) {
+	h := http.Header{}
+	h.Add("Forwarded", `for=192.0.2.60;proto=http;by=203.0.113.43, for="[2001:db8:cafe::17]:4711"`)
+	assert.Equal(t, []string{"192.0.2.60", "2001:db8:cafe::17"}, ForwardedFor(h))
+
+	h.Add("X-Forwarded-For", "203.0.113.195, 70.41.3.18")
+	h.Add("X-Forwarded-For", "150.172.238.178")
+	assert.Equal(t, []string{"203.0.113.195", "70.41.3.18", "150.172.238.178"}, ForwardedFor(h))
+}
+
+func TestMatchRemoteAddr(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.UseRequest(Forwarded("203.0.113.7", "10.0.0.1", "10.0.0.2"))
+	internal := mock.Mock("/internal", "ok").MatchRemoteAddr("10.0.0.0/8").MatchForwardedFor("203.0.113.7", "10.0.0.1")
+	mock.Mock("/local", "ok").MatchRemoteAddr("127.0.0.1")
+
+	resp, err := http.Get(mock.URL() + "/internal")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = http.Get(mock.URL() + "/local")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	rr := internal.Requests()[0]
+	assert.Equal(t, "10.0.0.2:0", rr.RemoteAddr)
+	assert.Equal(t, []string{"203.0.113.7", "10.0.0.1"}, rr.ForwardedFor())
+	assert.Equal(t, `for=203.0.113.7, for=10.0.0.1`, rr.Header.Get("Forwarded"))
+
+	req, _ := http.NewRequest("GET", mock.URL()+"/local", nil)
+	assert.Contains(t, mock.Explain(req), "remote address 127.0.0.1 does not match")
+}
+
+func TestMatchRemoteAddrPanics(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	assert.PanicsWithValue(t, `gohtmock: MatchRemoteAddr: "local" is neither an IP nor a CIDR`, func() {
+		mock.Mock("/", "").MatchRemoteAddr("local")
+	})
+}
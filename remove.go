@@ -0,0 +1,35 @@
+package gohtmock
+
+// Remove unregisters mr. Requests it would have answered fall through to the
+// mocks registered after it, and it is no longer part of AssertMocksCalled.
+func (mr *mockResponse) Remove() {
+	m := mr.httpMock
+	m.Lock()
+	defer m.Unlock()
+	m.removeWhere(func(other *mockResponse) bool { return other == mr })
+}
+
+// Remove unregisters all mocks for method and path, e.g. to withdraw or
+// override a mock registered by a shared fixture. It returns the number of
+// mocks removed.
+func (m *Mock) Remove(method, path string) int {
+	m.Lock()
+	defer m.Unlock()
+	return m.removeWhere(func(mr *mockResponse) bool {
+		return mr.method == method && mr.path == path
+	})
+}
+
+// removeWhere unregisters the mocks matched by fn. m must be locked.
+func (m *Mock) removeWhere(fn func(*mockResponse) bool) int {
+	// a new slice, since candidates may have handed out the old one
+	kept := make([]*mockResponse, 0, len(m.mockResponses))
+	for _, mr := range m.mockResponses {
+		if !fn(mr) {
+			kept = append(kept, mr)
+		}
+	}
+	removed := len(m.mockResponses) - len(kept)
+	m.mockResponses = kept
+	return removed
+}
@@ -0,0 +1,43 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemove(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	shared := mock.Mock("/users", `["shared"]`)
+	mock.Mock("/users", `["override"]`)
+	mock.Mock("/unused", "{}")
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(mock.URL() + path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		return resp.StatusCode, string(b)
+	}
+
+	_, body := get("/users")
+	assert.Equal(t, `["shared"]`, body)
+
+	shared.Remove()
+	_, body = get("/users")
+	assert.Equal(t, `["override"]`, body)
+
+	assert.Equal(t, 1, mock.Remove("GET", "/users"))
+	assert.Equal(t, 0, mock.Remove("GET", "/users"))
+	status, _ := get("/users")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	mock.Remove("GET", "/unused")
+	rt := newRecordingT("TestRemove")
+	mock.AssertMocksCalled(rt)
+	assert.Empty(t, rt.Errors())
+}
@@ -0,0 +1,203 @@
+package gohtmock
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ReplayResult tells how the current mocks treat one replayed request.
+type ReplayResult struct {
+	Request *RecordedRequest
+	Matched bool
+	// Mock is where the matching mock was registered.
+	Mock string
+	// Explanation is the Explain output for unmatched requests.
+	Explanation string
+	// Truncated is set for requests whose body was cut when they were
+	// logged. They are not matched, since filters and body matchers would
+	// only see part of the body.
+	Truncated bool
+}
+
+// Replay feeds the requests of a log written by LogRequestsTo or of a HAR
+// file through the matcher and reports which mock each would hit. Nothing
+// is counted or recorded; Times limits are applied as if the replayed
+// requests were the only calls made. Requests logged with a truncated body
+// are not matched but flagged as Truncated.
+func (m *Mock) Replay(path string) ([]ReplayResult, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	reqs, err := parseReplay(b)
+	if err != nil {
+		return nil, fmt.Errorf("gohtmock: reading %s: %w", path, err)
+	}
+
+	requests := make([]*http.Request, len(reqs))
+	for i, rr := range reqs {
+		requests[i] = m.applyRequestMiddleware(rr.request())
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	calls := make(map[*mockResponse]int)
+	results := make([]ReplayResult, 0, len(reqs))
+	for i, rr := range reqs {
+		r := requests[i]
+		partition, _ := m.partitionFor(r)
+		result := ReplayResult{Request: rr}
+		if rr.Truncated {
+			result.Truncated = true
+			result.Explanation = fmt.Sprintf("%s %s: the body was truncated when it was logged", rr.Method, rr.URL.RequestURI())
+			results = append(results, result)
+			continue
+		}
+		for _, mr := range m.candidates(partition) {
+			r.Body = ioutil.NopCloser(bytes.NewReader(rr.Body))
+			mr.Lock()
+			depleted := mr.times > 0 && calls[mr] >= mr.times
+			mr.Unlock()
+			if !depleted && mr.matches(r.Method, r.URL.Path) && mr.checkFilter(r) {
+				calls[mr]++
+				result.Matched = true
+				result.Mock = mr.registeredAt
+				break
+			}
+		}
+		if !result.Matched {
+			result.Explanation = m.explain(r, rr.Body, calls)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// AssertReplayMatches fails tb for every request in the log or HAR file at
+// path that no current mock would answer or that was logged truncated.
+func (m *Mock) AssertReplayMatches(tb testing.TB, path string) {
+	results, err := m.Replay(path)
+	if err != nil {
+		tb.Errorf("%s", err)
+		return
+	}
+	for _, result := range results {
+		switch {
+		case result.Truncated:
+			tb.Errorf("replayed request can not be matched: %s", result.Explanation)
+		case !result.Matched:
+			tb.Errorf("replayed request is not mocked anymore: %s", result.Explanation)
+		}
+	}
+}
+
+func (rr *RecordedRequest) request() *http.Request {
+	r := &http.Request{
+		Method:     rr.Method,
+		URL:        cloneURL(rr.URL),
+		Host:       rr.Host,
+		Header:     rr.Header.Clone(),
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Body:       ioutil.NopCloser(bytes.NewReader(rr.Body)),
+	}
+	if r.Header == nil {
+		r.Header = http.Header{}
+	}
+	return r
+}
+
+func parseReplay(b []byte) ([]*RecordedRequest, error) {
+	var har harFile
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) && json.Unmarshal(b, &har) == nil && har.Log != nil {
+		return har.requests()
+	}
+
+	var reqs []*RecordedRequest
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(make([]byte, 64<<10), 64<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var entry LogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, err
+		}
+		u, err := url.ParseRequestURI(entry.URL)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, &RecordedRequest{
+			Method:    entry.Method,
+			URL:       u,
+			Host:      entry.Host,
+			Header:    entry.RequestHeader,
+			Body:      entry.RequestBody,
+			Truncated: entry.RequestTruncated,
+			Time:      entry.Time,
+		})
+	}
+	return reqs, scanner.Err()
+}
+
+type harFile struct {
+	Log *struct {
+		Entries []struct {
+			StartedDateTime time.Time `json:"startedDateTime"`
+			Request         struct {
+				Method  string `json:"method"`
+				URL     string `json:"url"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+				PostData *struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+func (h harFile) requests() ([]*RecordedRequest, error) {
+	var reqs []*RecordedRequest
+	for _, e := range h.Log.Entries {
+		u, err := url.Parse(e.Request.URL)
+		if err != nil {
+			return nil, err
+		}
+		rr := &RecordedRequest{
+			Method: e.Request.Method,
+			URL:    &url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: u.RawQuery},
+			Host:   u.Host,
+			Header: http.Header{},
+			Time:   e.StartedDateTime,
+		}
+		for _, h := range e.Request.Headers {
+			// HTTP/2 pseudo headers such as :authority are not real headers
+			if !strings.HasPrefix(h.Name, ":") {
+				rr.Header.Add(h.Name, h.Value)
+			}
+		}
+		if e.Request.PostData != nil {
+			rr.Body = []byte(e.Request.PostData.Text)
+			if rr.Header.Get("Content-Type") == "" && e.Request.PostData.MimeType != "" {
+				rr.Header.Set("Content-Type", e.Request.PostData.MimeType)
+			}
+		}
+		reqs = append(reqs, rr)
+	}
+	return reqs, nil
+}
@@ -0,0 +1,94 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayHAR(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/users", "[]")
+	mock.Mock("/users", "created").SetMethod("POST").Filter(func(r *http.Request) bool {
+		b, _ := ioutil.ReadAll(r.Body)
+		return strings.Contains(string(b), "foo")
+	})
+
+	results, err := mock.Replay("testdata/replay/traffic.har")
+	assert.NoError(t, err)
+	if assert.Len(t, results, 3) {
+		assert.True(t, results[0].Matched)
+		assert.Equal(t, "application/json", results[0].Request.Header.Get("Accept"))
+		assert.True(t, results[1].Matched)
+		assert.False(t, results[2].Matched)
+		assert.Contains(t, results[2].Explanation, "DELETE /users/1 matches no mock")
+	}
+
+	rt := newRecordingT("TestReplay")
+	mock.AssertReplayMatches(rt, "testdata/replay/traffic.har")
+	assert.Len(t, rt.Errors(), 1)
+
+	// replaying is not calling
+	newT := &testing.T{}
+	mock.AssertCallCount(newT, "GET", "/users", 0)
+	assert.True(t, newT.Failed())
+}
+
+func TestReplayRequestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.ndjson")
+	old := New()
+	assert.NoError(t, old.LogRequestsTo(path))
+	old.Mock("/a", "ok")
+	for i := 0; i < 2; i++ {
+		_, err := http.Get(old.URL() + "/a")
+		assert.NoError(t, err)
+	}
+	old.Close()
+
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/a", "ok").Once()
+	results, err := mock.Replay(path)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.True(t, results[0].Matched)
+		assert.False(t, results[1].Matched)
+		assert.Contains(t, results[1].Explanation, "GET /a matches no mock")
+	}
+}
+
+func TestReplayFlagsTruncatedBodies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.ndjson")
+	old := New()
+	assert.NoError(t, old.LogRequestsTo(path, MaxLogBody(4)))
+	old.Mock("/users", "created").SetMethod("POST")
+	old.Mock("/users", `[{"id":1},{"id":2}]`)
+	_, err := http.Post(old.URL()+"/users", "application/json", strings.NewReader(`{"name":"foo"}`))
+	assert.NoError(t, err)
+	_, err = http.Get(old.URL() + "/users")
+	assert.NoError(t, err)
+	old.Close()
+
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/users", "created").SetMethod("POST").MatchBodyString(`{"na`)
+	mock.Mock("/users", "[]")
+	results, err := mock.Replay(path)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.True(t, results[0].Truncated)
+		assert.False(t, results[0].Matched)
+		// only the response body of the second request was truncated
+		assert.False(t, results[1].Truncated)
+		assert.True(t, results[1].Matched)
+	}
+
+	rt := newRecordingT("TestReplayFlagsTruncatedBodies")
+	mock.AssertReplayMatches(rt, path)
+	assert.Equal(t, []string{"replayed request can not be matched: POST /users: the body was truncated when it was logged"}, rt.Errors())
+}
@@ -0,0 +1,36 @@
+package gohtmock
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Reporter receives the failures of assertions. testing.TB implements it,
+// as do the test doubles of most other frameworks, for example GinkgoT().
+type Reporter interface {
+	Errorf(format string, args ...any)
+}
+
+// ReporterFunc is a Reporter calling itself with each failure message, for
+// example gomega's Fail or a testify suite's Fail method.
+type ReporterFunc func(msg string)
+
+func (f ReporterFunc) Errorf(format string, args ...any) {
+	f(fmt.Sprintf(format, args...))
+}
+
+// AsTB adapts r to the testing.TB the assertions and For take, so failures
+// flow to r. It returns a Checker reporting to r. Cleanup, Failed and Name
+// are forwarded if r implements them, otherwise cleanups run when the
+// Checker is closed and the name is "gohtmock".
+func AsTB(r Reporter) testing.TB {
+	if tb, ok := r.(testing.TB); ok {
+		return tb
+	}
+	c := NewChecker("gohtmock")
+	if n, ok := r.(interface{ Name() string }); ok {
+		c.name = n.Name()
+	}
+	c.report = r
+	return c
+}
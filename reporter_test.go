@@ -0,0 +1,29 @@
+package gohtmock
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsTB(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/uncalled", "{}")
+
+	var failures []string
+	tb := AsTB(ReporterFunc(func(msg string) {
+		failures = append(failures, msg)
+	}))
+	mock.AssertMocksCalled(tb)
+	mock.AssertCallCount(tb, "GET", "/uncalled", 1)
+	assert.True(t, tb.Failed())
+	assert.Equal(t, "gohtmock", tb.Name())
+	assert.Equal(t, []string{
+		"GET /uncalled mocked but never called.",
+		"mocked but never called path: /uncalled method: GET",
+	}, failures)
+
+	assert.IsType(t, &Checker{}, tb)
+	assert.Equal(t, t, AsTB(t))
+}
@@ -0,0 +1,174 @@
+package gohtmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogEntry is one line of the NDJSON file written by LogRequestsTo.
+type LogEntry struct {
+	Time          time.Time   `json:"time"`
+	Method        string      `json:"method"`
+	URL           string      `json:"url"`
+	Host          string      `json:"host"`
+	RequestHeader http.Header `json:"requestHeader"`
+	// RequestBody and ResponseBody are base64 encoded in the JSON so that
+	// binary bodies are logged as they were.
+	RequestBody    []byte      `json:"requestBody,omitempty"`
+	Status         int         `json:"status"`
+	ResponseHeader http.Header `json:"responseHeader"`
+	ResponseBody   []byte      `json:"responseBody,omitempty"`
+	// Truncated is set when a body was cut at the body limit,
+	// RequestTruncated when that body was the request body.
+	Truncated        bool  `json:"truncated,omitempty"`
+	RequestTruncated bool  `json:"requestTruncated,omitempty"`
+	Size             int64 `json:"size"`
+	// Mock is where the answering mock was registered, empty if none matched.
+	Mock       string  `json:"mock,omitempty"`
+	DurationMs float64 `json:"durationMs"`
+}
+
+type RequestLogOption func(*requestLog)
+
+// MaxLogSize rotates the log once it would grow beyond bytes. The current
+// file is renamed to path.1, path.1 to path.2 and so on.
+func MaxLogSize(bytes int64) RequestLogOption {
+	return func(l *requestLog) {
+		l.maxSize = bytes
+	}
+}
+
+// MaxLogFiles is the number of rotated files kept besides the current one, default 3.
+func MaxLogFiles(n int) RequestLogOption {
+	return func(l *requestLog) {
+		l.maxFiles = n
+	}
+}
+
+// MaxLogBody limits how many bytes of each request and response body are
+// logged, default 64 KiB. Negative means no limit.
+func MaxLogBody(bytes int) RequestLogOption {
+	return func(l *requestLog) {
+		l.maxBody = bytes
+	}
+}
+
+type requestLog struct {
+	path     string
+	file     *os.File
+	size     int64
+	maxSize  int64
+	maxFiles int
+	maxBody  int
+	sync.Mutex
+}
+
+// LogRequestsTo appends every request and response as a LogEntry line to the
+// file at path. Lines are written as requests complete so the log survives
+// the test process dying on a fatal failure. The log is closed by Close.
+func (m *Mock) LogRequestsTo(path string, opts ...RequestLogOption) error {
+	l := &requestLog{path: path, maxFiles: 3, maxBody: 64 << 10}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if err := l.open(); err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if m.requestLog != nil {
+		m.requestLog.close()
+	}
+	m.requestLog = l
+	return nil
+}
+
+func (l *requestLog) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("gohtmock: opening request log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file = f
+	l.size = info.Size()
+	return nil
+}
+
+func (l *requestLog) close() {
+	l.Lock()
+	defer l.Unlock()
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+}
+
+func (l *requestLog) write(rr *RecordedRequest, mr *mockResponse, cw *responseCapture, d time.Duration) {
+	requestTruncated := rr.Truncated || l.maxBody >= 0 && len(rr.Body) > l.maxBody
+	entry := LogEntry{
+		Time:             rr.Time,
+		Method:           rr.Method,
+		URL:              rr.URL.RequestURI(),
+		Host:             rr.Host,
+		RequestHeader:    rr.Header,
+		RequestBody:      truncate(rr.Body, l.maxBody),
+		Status:           cw.statusCode(),
+		ResponseHeader:   cw.Header(),
+		ResponseBody:     cw.body,
+		Truncated:        cw.truncated || requestTruncated,
+		RequestTruncated: requestTruncated,
+		Size:             cw.size,
+		DurationMs:       float64(d) / float64(time.Millisecond),
+	}
+	if mr != nil {
+		entry.Mock = mr.registeredAt
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	l.Lock()
+	defer l.Unlock()
+	if l.file == nil {
+		return
+	}
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		l.rotate()
+	}
+	n, _ := l.file.Write(line)
+	l.size += int64(n)
+}
+
+// rotate shifts path.N-1 to path.N ... path to path.1 and starts a new file. l must be locked.
+func (l *requestLog) rotate() {
+	l.file.Close()
+	l.file = nil
+	os.Remove(fmt.Sprintf("%s.%d", l.path, l.maxFiles))
+	for i := l.maxFiles - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+	}
+	if l.maxFiles > 0 {
+		os.Rename(l.path, l.path+".1")
+	} else {
+		os.Remove(l.path)
+	}
+	_ = l.open()
+}
+
+func truncate(b []byte, max int) []byte {
+	if max >= 0 && len(b) > max {
+		return b[:max]
+	}
+	return b
+}
@@ -0,0 +1,95 @@
+package gohtmock
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readLog(t *testing.T, path string) []LogEntry {
+	f, err := os.Open(path)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	defer f.Close()
+	var entries []LogEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e LogEntry
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestLogRequestsTo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.ndjson")
+	mock := New()
+	assert.NoError(t, mock.LogRequestsTo(path, MaxLogBody(4)))
+	mock.Mock("/users", `{"id":1}`).SetMethod("POST").WithStatus(http.StatusCreated)
+
+	_, err := http.Post(mock.URL()+"/users?x=1", "application/json", strings.NewReader(`{"name":"foo"}`))
+	assert.NoError(t, err)
+	_, err = http.Get(mock.URL() + "/missing")
+	assert.NoError(t, err)
+	mock.Close()
+
+	entries := readLog(t, path)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "POST", entries[0].Method)
+		assert.Equal(t, "/users?x=1", entries[0].URL)
+		assert.Equal(t, `{"na`, string(entries[0].RequestBody))
+		assert.Equal(t, http.StatusCreated, entries[0].Status)
+		assert.Equal(t, `{"id`, string(entries[0].ResponseBody))
+		assert.Equal(t, int64(8), entries[0].Size)
+		assert.True(t, entries[0].Truncated)
+		assert.Contains(t, entries[0].Mock, "requestlog_test.go:")
+
+		assert.Equal(t, http.StatusNotFound, entries[1].Status)
+		assert.Empty(t, entries[1].Mock)
+	}
+}
+
+func TestLogRequestsToRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.ndjson")
+	mock := New()
+	defer mock.Close()
+	assert.NoError(t, mock.LogRequestsTo(path, MaxLogSize(1), MaxLogFiles(2)))
+	mock.Mock("/a", "ok")
+
+	for i := 0; i < 4; i++ {
+		_, err := http.Get(mock.URL() + "/a")
+		assert.NoError(t, err)
+	}
+
+	assert.Len(t, readLog(t, path), 1)
+	assert.Len(t, readLog(t, path+".1"), 1)
+	assert.Len(t, readLog(t, path+".2"), 1)
+	_, err := os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLogRequestsToBinaryBodies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.ndjson")
+	mock := New()
+	assert.NoError(t, mock.LogRequestsTo(path))
+	mock.Mock("/blob", "\xff\xfe").SetMethod("PUT")
+
+	req, _ := http.NewRequest("PUT", mock.URL()+"/blob", strings.NewReader("\x00\x80\xff"))
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	mock.Close()
+
+	entries := readLog(t, path)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, []byte("\x00\x80\xff"), entries[0].RequestBody)
+		assert.Equal(t, []byte("\xff\xfe"), entries[0].ResponseBody)
+	}
+}
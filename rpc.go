@@ -0,0 +1,285 @@
+package gohtmock
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// RPCCode is a gRPC status code.
+type RPCCode int
+
+const (
+	RPCOK RPCCode = iota
+	RPCCanceled
+	RPCUnknown
+	RPCInvalidArgument
+	RPCDeadlineExceeded
+	RPCNotFound
+	RPCAlreadyExists
+	RPCPermissionDenied
+	RPCResourceExhausted
+	RPCFailedPrecondition
+	RPCAborted
+	RPCOutOfRange
+	RPCUnimplemented
+	RPCInternal
+	RPCUnavailable
+	RPCDataLoss
+	RPCUnauthenticated
+)
+
+var rpcCodes = []struct {
+	name   string
+	status int
+}{
+	{"ok", http.StatusOK},
+	{"canceled", 499},
+	{"unknown", http.StatusInternalServerError},
+	{"invalid_argument", http.StatusBadRequest},
+	{"deadline_exceeded", http.StatusGatewayTimeout},
+	{"not_found", http.StatusNotFound},
+	{"already_exists", http.StatusConflict},
+	{"permission_denied", http.StatusForbidden},
+	{"resource_exhausted", http.StatusTooManyRequests},
+	{"failed_precondition", http.StatusBadRequest},
+	{"aborted", http.StatusConflict},
+	{"out_of_range", http.StatusBadRequest},
+	{"unimplemented", http.StatusNotImplemented},
+	{"internal", http.StatusInternalServerError},
+	{"unavailable", http.StatusServiceUnavailable},
+	{"data_loss", http.StatusInternalServerError},
+	{"unauthenticated", http.StatusUnauthorized},
+}
+
+// String returns the Connect name of c, such as "not_found".
+func (c RPCCode) String() string {
+	if c < 0 || int(c) >= len(rpcCodes) {
+		return fmt.Sprintf("code_%d", int(c))
+	}
+	return rpcCodes[c].name
+}
+
+func (c RPCCode) httpStatus() int {
+	if c < 0 || int(c) >= len(rpcCodes) {
+		return http.StatusInternalServerError
+	}
+	return rpcCodes[c].status
+}
+
+// RPCError is returned by the function of MockRPC to fail the call with a
+// status code. Other errors fail it with RPCUnknown.
+type RPCError struct {
+	Code    RPCCode
+	Message string
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// rpcProtocol is the wire format of an RPC, told by its content type.
+type rpcProtocol int
+
+const (
+	rpcUnsupported rpcProtocol = iota
+	rpcConnectUnary
+	rpcConnectStream
+	rpcGRPCWeb
+	rpcGRPCWebText
+)
+
+const (
+	flagCompressed = 0x01
+	flagEndStream  = 0x02
+	flagTrailer    = 0x80
+)
+
+func rpcProtocolOf(r *http.Request) rpcProtocol {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "application/grpc-web-text" || strings.HasPrefix(mediaType, "application/grpc-web-text+"):
+		return rpcGRPCWebText
+	case mediaType == "application/grpc-web" || strings.HasPrefix(mediaType, "application/grpc-web+"):
+		return rpcGRPCWeb
+	case strings.HasPrefix(mediaType, "application/connect+"):
+		return rpcConnectStream
+	case mediaType == "application/proto" || mediaType == "application/json":
+		return rpcConnectUnary
+	}
+	return rpcUnsupported
+}
+
+// MockRPC answers the unary RPC procedure, such as "/acme.v1.UserService/GetUser",
+// over gRPC-Web, binary or base64 text, and the Connect protocol, unary or
+// enveloped. fn receives the request message and returns the response
+// message, both encoded with the codec of the request content type, as
+// protobuf or JSON. Errors are sent as the RPC status, in the body trailer
+// frame for gRPC-Web. Register several mocks for the same procedure with
+// MatchMessage to answer different messages differently.
+func (m *Mock) MockRPC(procedure string, fn func(msg []byte) ([]byte, error)) *mockResponse {
+	if !strings.HasPrefix(procedure, "/") {
+		procedure = "/" + procedure
+	}
+	return m.MockFunc(procedure, func(w http.ResponseWriter, r *http.Request) {
+		protocol := rpcProtocolOf(r)
+		if protocol == rpcUnsupported {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			fmt.Fprintf(w, "gohtmock: unsupported RPC content type %q", r.Header.Get("Content-Type"))
+			return
+		}
+		msg, err := readRPCMessage(r, protocol)
+		var resp []byte
+		if err == nil {
+			resp, err = fn(msg)
+		} else {
+			err = &RPCError{Code: RPCInvalidArgument, Message: err.Error()}
+		}
+		writeRPC(w, r, protocol, resp, err)
+	}).SetMethod(http.MethodPost)
+}
+
+// MatchMessage makes mr only answer RPCs whose request message satisfies
+// fn. It can be called several times, all must match.
+func (mr *mockResponse) MatchMessage(fn func(msg []byte) bool) *mockResponse {
+	mr.Lock()
+	mr.messageMatchers = append(mr.messageMatchers, fn)
+	mr.Unlock()
+	return mr
+}
+
+// matchesMessage reports if the RPC message of r satisfies the MatchMessage
+// calls of mr.
+func (mr *mockResponse) matchesMessage(r *http.Request) bool {
+	mr.Lock()
+	matchers := mr.messageMatchers
+	mr.Unlock()
+	if len(matchers) == 0 {
+		return true
+	}
+	msg, err := readRPCMessage(r, rpcProtocolOf(r))
+	if err != nil {
+		return false
+	}
+	for _, fn := range matchers {
+		if !fn(msg) {
+			return false
+		}
+	}
+	return true
+}
+
+// readRPCMessage returns the first message of the body of r and leaves the
+// body in place to be read again.
+func readRPCMessage(r *http.Request, protocol rpcProtocol) ([]byte, error) {
+	if r.Body == nil {
+		return nil, errors.New("request has no body")
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	switch protocol {
+	case rpcConnectUnary:
+		return body, nil
+	case rpcGRPCWebText:
+		if body, err = base64.StdEncoding.DecodeString(string(body)); err != nil {
+			return nil, fmt.Errorf("decoding grpc-web-text body: %w", err)
+		}
+	case rpcUnsupported:
+		return nil, errors.New("unsupported RPC content type")
+	}
+	if len(body) < 5 {
+		return nil, errors.New("truncated message frame")
+	}
+	flags, size := body[0], binary.BigEndian.Uint32(body[1:5])
+	if flags&flagCompressed != 0 {
+		return nil, errors.New("compressed messages are not supported")
+	}
+	if uint64(len(body)-5) < uint64(size) {
+		return nil, fmt.Errorf("message frame of %d bytes has only %d", size, len(body)-5)
+	}
+	return body[5 : 5+size], nil
+}
+
+func frame(flags byte, data []byte) []byte {
+	b := make([]byte, 5, 5+len(data))
+	b[0] = flags
+	binary.BigEndian.PutUint32(b[1:], uint32(len(data)))
+	return append(b, data...)
+}
+
+// writeRPC writes msg, or err as the status of the RPC, in protocol.
+func writeRPC(w http.ResponseWriter, r *http.Request, protocol rpcProtocol, msg []byte, err error) {
+	var rpcErr *RPCError
+	if err != nil && !errors.As(err, &rpcErr) {
+		rpcErr = &RPCError{Code: RPCUnknown, Message: err.Error()}
+	}
+	w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+
+	switch protocol {
+	case rpcConnectUnary:
+		if rpcErr != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(rpcErr.Code.httpStatus())
+			_ = json.NewEncoder(w).Encode(connectError{Code: rpcErr.Code.String(), Message: rpcErr.Message})
+			return
+		}
+		_, _ = w.Write(msg)
+	case rpcConnectStream:
+		var end struct {
+			Error *connectError `json:"error,omitempty"`
+		}
+		var body []byte
+		if rpcErr == nil {
+			body = frame(0, msg)
+		} else {
+			end.Error = &connectError{Code: rpcErr.Code.String(), Message: rpcErr.Message}
+		}
+		trailer, _ := json.Marshal(end)
+		_, _ = w.Write(append(body, frame(flagEndStream, trailer)...))
+	case rpcGRPCWeb, rpcGRPCWebText:
+		status := RPCOK
+		var message string
+		var body []byte
+		if rpcErr == nil {
+			body = frame(0, msg)
+		} else {
+			status, message = rpcErr.Code, rpcErr.Message
+		}
+		trailer := fmt.Sprintf("grpc-status: %d\r\ngrpc-message: %s\r\n", status, percentEncode(message))
+		body = append(body, frame(flagTrailer, []byte(trailer))...)
+		if protocol == rpcGRPCWebText {
+			body = []byte(base64.StdEncoding.EncodeToString(body))
+		}
+		_, _ = w.Write(body)
+	}
+}
+
+type connectError struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// percentEncode encodes a grpc-message as required by the gRPC spec.
+func percentEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c > 0x7e || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
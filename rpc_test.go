@@ -0,0 +1,73 @@
+package gohtmock
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func postRPC(t *testing.T, url, contentType string, body []byte) (*http.Response, []byte) {
+	resp, err := http.Post(url, contentType, bytes.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return resp, b
+}
+
+func TestMockRPC(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	const procedure = "/acme.v1.UserService/GetUser"
+	mock.MockRPC(procedure, func(msg []byte) ([]byte, error) {
+		return append([]byte("user "), msg...), nil
+	}).MatchMessage(func(msg []byte) bool { return string(msg) != "missing" })
+	mock.MockRPC(procedure, func(msg []byte) ([]byte, error) {
+		return nil, &RPCError{Code: RPCNotFound, Message: "no such user: 100%"}
+	}).MatchMessage(func(msg []byte) bool { return string(msg) == "missing" })
+	url := mock.URL() + procedure
+
+	resp, body := postRPC(t, url, "application/grpc-web+proto", frame(0, []byte("1")))
+	assert.Equal(t, "application/grpc-web+proto", resp.Header.Get("Content-Type"))
+	assert.Equal(t, string(frame(0, []byte("user 1")))+string(frame(flagTrailer, []byte("grpc-status: 0\r\ngrpc-message: \r\n"))), string(body))
+
+	_, body = postRPC(t, url, "application/grpc-web-text", []byte(base64.StdEncoding.EncodeToString(frame(0, []byte("missing")))))
+	decoded, err := base64.StdEncoding.DecodeString(string(body))
+	assert.NoError(t, err)
+	assert.Equal(t, string(frame(flagTrailer, []byte("grpc-status: 5\r\ngrpc-message: no such user: 100%25\r\n"))), string(decoded))
+
+	resp, body = postRPC(t, url, "application/proto", []byte("2"))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "user 2", string(body))
+
+	resp, body = postRPC(t, url, "application/json", []byte("missing"))
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.JSONEq(t, `{"code":"not_found","message":"no such user: 100%"}`, string(body))
+
+	_, body = postRPC(t, url, "application/connect+proto", frame(0, []byte("3")))
+	assert.Equal(t, string(frame(0, []byte("user 3")))+string(frame(flagEndStream, []byte("{}"))), string(body))
+
+	_, body = postRPC(t, url, "application/connect+json", frame(0, []byte("missing")))
+	assert.Equal(t, string(frame(flagEndStream, []byte(`{"error":{"code":"not_found","message":"no such user: 100%"}}`))), string(body))
+	mock.AssertMocksCalled(t)
+}
+
+func TestMockRPCInvalidFrame(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.MockRPC("acme.v1.UserService/GetUser", func(msg []byte) ([]byte, error) {
+		return msg, nil
+	})
+
+	_, body := postRPC(t, mock.URL()+"/acme.v1.UserService/GetUser", "application/grpc-web", []byte{0, 0, 0, 0, 9, 1})
+	assert.True(t, strings.Contains(string(body), "grpc-status: 3\r\n"), string(body))
+	assert.True(t, strings.Contains(string(body), "message frame of 9 bytes has only 1"), string(body))
+
+	resp, _ := postRPC(t, mock.URL()+"/acme.v1.UserService/GetUser", "text/plain", []byte("1"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+}
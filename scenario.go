@@ -0,0 +1,117 @@
+package gohtmock
+
+import "sync"
+
+// Scenario is a named state machine shared by stateful mocks. Mocks
+// registered through its steps only answer while the scenario is in the
+// step's Given state and may move it to another state when they do.
+type Scenario struct {
+	name  string
+	mock  *Mock
+	state string
+	sync.Mutex
+}
+
+// Step builds one stateful mock of a Scenario.
+type Step struct {
+	scenario *Scenario
+	given    string
+	method   string
+	path     string
+	mr       *mockResponse
+}
+
+// Scenario returns the scenario called name, creating it on first use. A
+// new scenario starts in the state of its first Given unless SetState is
+// called before.
+func (m *Mock) Scenario(name string) *Scenario {
+	m.Lock()
+	defer m.Unlock()
+	if m.scenarios == nil {
+		m.scenarios = make(map[string]*Scenario)
+	}
+	s, ok := m.scenarios[name]
+	if !ok {
+		s = &Scenario{name: name, mock: m}
+		m.scenarios[name] = s
+	}
+	return s
+}
+
+func (s *Scenario) Name() string {
+	return s.name
+}
+
+// State returns the current state of s.
+func (s *Scenario) State() string {
+	s.Lock()
+	defer s.Unlock()
+	return s.state
+}
+
+// SetState moves s to state.
+func (s *Scenario) SetState(state string) {
+	s.Lock()
+	s.state = state
+	s.Unlock()
+}
+
+// Given starts a step answering while s is in state.
+func (s *Scenario) Given(state string) *Step {
+	s.Lock()
+	if s.state == "" {
+		s.state = state
+	}
+	s.Unlock()
+	return &Step{scenario: s, given: state}
+}
+
+// When sets the request the step answers.
+func (st *Step) When(method, path string) *Step {
+	st.method = method
+	st.path = path
+	return st
+}
+
+// Then registers the step as a mock answering with status and body.
+func (st *Step) Then(status int, body string) *Step {
+	m := st.scenario.mock
+	mr := m.newMockResponse(st.path, body)
+	if st.method != "" {
+		mr.method = st.method
+	}
+	mr.status = status
+	mr.scenario = st.scenario
+	mr.givenState = st.given
+	m.add(mr)
+	st.mr = mr
+	return st
+}
+
+// MoveTo makes the step move the scenario to state when it answers.
+func (st *Step) MoveTo(state string) *Step {
+	st.mr.Lock()
+	st.mr.nextState = state
+	st.mr.Unlock()
+	return st
+}
+
+// Mock returns the mock registered by Then, for further configuration.
+func (st *Step) Mock() *mockResponse {
+	return st.mr
+}
+
+// inState reports whether the scenario of mr, if any, is in its Given state.
+func (mr *mockResponse) inState() bool {
+	return mr.scenario == nil || mr.scenario.State() == mr.givenState
+}
+
+// advance moves the scenario of mr to its MoveTo state, if any.
+func (mr *mockResponse) advance() {
+	mr.Lock()
+	next := mr.nextState
+	mr.Unlock()
+	if mr.scenario != nil && next != "" {
+		mr.scenario.SetState(next)
+	}
+}
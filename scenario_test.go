@@ -0,0 +1,50 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScenario(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.DetectAmbiguousFilters(t)
+	checkout := mock.Scenario("checkout")
+	checkout.Given("cart-empty").When("GET", "/cart").Then(http.StatusOK, `{"items":[]}`)
+	checkout.Given("cart-empty").When("POST", "/cart/items").Then(http.StatusCreated, `{"id":1}`).MoveTo("has-items")
+	checkout.Given("has-items").When("GET", "/cart").Then(http.StatusOK, `{"items":[1]}`)
+	checkout.Given("has-items").When("POST", "/cart/checkout").Then(http.StatusAccepted, "").MoveTo("cart-empty").
+		Mock().SetHeader("Location", "/orders/1")
+	assert.Same(t, checkout, mock.Scenario("checkout"))
+	assert.Equal(t, "cart-empty", checkout.State())
+
+	do := func(method, path string) (*http.Response, string) {
+		req, _ := http.NewRequest(method, mock.URL()+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	_, body := do("GET", "/cart")
+	assert.Equal(t, `{"items":[]}`, body)
+	resp, _ := do("POST", "/cart/checkout")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, _ = do("POST", "/cart/items")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "has-items", checkout.State())
+	_, body = do("GET", "/cart")
+	assert.Equal(t, `{"items":[1]}`, body)
+	resp, _ = do("POST", "/cart/checkout")
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "/orders/1", resp.Header.Get("Location"))
+	assert.Equal(t, "cart-empty", checkout.State())
+
+	req, _ := http.NewRequest("POST", mock.URL()+"/cart/checkout", nil)
+	assert.True(t, strings.Contains(mock.Explain(req), "scenario checkout is in state cart-empty, not has-items"))
+}
@@ -0,0 +1,217 @@
+package gohtmock
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const sigV4Algorithm = "AWS4-HMAC-SHA256"
+
+// RequireSigV4 makes mr verify the AWS Signature Version 4 of the
+// Authorization header of every request, signed for region and service.
+// secretKey returns the secret access key of an access key ID, false if it
+// is unknown. Requests that fail verification are answered with 403 and the
+// failure, including the canonical request and string to sign the mock
+// expected, is reported to the owning test or logged. They do not count as
+// calls of mr.
+func (mr *mockResponse) RequireSigV4(region, service string, secretKey func(accessKeyID string) (string, bool)) *mockResponse {
+	return mr.Guard(func(w http.ResponseWriter, r *http.Request) bool {
+		err := verifySigV4(r, region, service, secretKey)
+		if err == nil {
+			return true
+		}
+		msg := fmt.Sprintf("%s %s: %s%s", r.Method, r.URL.Path, err, mr.ownedBy())
+		if !mr.fail("%s", msg) {
+			log.Print("gohtmock: ", msg)
+		}
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, "gohtmock: ", msg)
+		return false
+	})
+}
+
+type sigV4Authorization struct {
+	accessKeyID   string
+	date          string
+	region        string
+	service       string
+	signedHeaders []string
+	signature     string
+}
+
+func parseSigV4Authorization(header string) (*sigV4Authorization, error) {
+	if !strings.HasPrefix(header, sigV4Algorithm+" ") {
+		return nil, fmt.Errorf("authorization %q does not use %s", header, sigV4Algorithm)
+	}
+	auth := &sigV4Authorization{}
+	for _, part := range strings.Split(strings.TrimPrefix(header, sigV4Algorithm+" "), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "Credential":
+			scope := strings.Split(value, "/")
+			if len(scope) != 5 || scope[4] != "aws4_request" {
+				return nil, fmt.Errorf("malformed credential %q", value)
+			}
+			auth.accessKeyID, auth.date, auth.region, auth.service = scope[0], scope[1], scope[2], scope[3]
+		case "SignedHeaders":
+			auth.signedHeaders = strings.Split(value, ";")
+		case "Signature":
+			auth.signature = value
+		}
+	}
+	switch {
+	case auth.accessKeyID == "":
+		return nil, fmt.Errorf("authorization %q has no Credential", header)
+	case auth.signedHeaders == nil:
+		return nil, fmt.Errorf("authorization %q has no SignedHeaders", header)
+	case auth.signature == "":
+		return nil, fmt.Errorf("authorization %q has no Signature", header)
+	}
+	return auth, nil
+}
+
+func verifySigV4(r *http.Request, region, service string, secretKey func(string) (string, bool)) error {
+	auth, err := parseSigV4Authorization(r.Header.Get("Authorization"))
+	if err != nil {
+		return err
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	switch {
+	case auth.region != region:
+		return fmt.Errorf("credential scope has region %q, want %q", auth.region, region)
+	case auth.service != service:
+		return fmt.Errorf("credential scope has service %q, want %q", auth.service, service)
+	case amzDate == "":
+		return fmt.Errorf("missing X-Amz-Date header")
+	case !strings.HasPrefix(amzDate, auth.date):
+		return fmt.Errorf("credential scope date %s does not match X-Amz-Date %s", auth.date, amzDate)
+	case !containsString(auth.signedHeaders, "host"):
+		return fmt.Errorf("SignedHeaders %s does not include host", strings.Join(auth.signedHeaders, ";"))
+	}
+	secret, ok := secretKey(auth.accessKeyID)
+	if !ok {
+		return fmt.Errorf("unknown access key ID %s", auth.accessKeyID)
+	}
+
+	canonical, err := canonicalRequest(r, service, auth.signedHeaders)
+	if err != nil {
+		return err
+	}
+	scope := strings.Join([]string{auth.date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, sha256Hex([]byte(canonical))}, "\n")
+	key := hmacSHA256([]byte("AWS4"+secret), auth.date)
+	for _, s := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	want := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	if !hmac.Equal([]byte(want), []byte(auth.signature)) {
+		return fmt.Errorf("signature %s does not match, expected %s for canonical request:\n%s\nand string to sign:\n%s",
+			auth.signature, want, canonical, stringToSign)
+	}
+	return nil
+}
+
+// canonicalRequest builds the canonical request of r as specified for
+// Signature Version 4. The body is left in place to be read again.
+func canonicalRequest(r *http.Request, service string, signedHeaders []string) (string, error) {
+	path := r.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if service != "s3" {
+		// all services but S3 encode the already encoded path once more
+		path = sigV4Escape(path, false)
+	}
+
+	// parameters are sorted by encoded key, then value, which sorting the
+	// joined key=value strings gets wrong for keys prefixing one another
+	var pairs [][2]string
+	for key, values := range r.URL.Query() {
+		for _, v := range values {
+			pairs = append(pairs, [2]string{sigV4Escape(key, true), sigV4Escape(v, true)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	params := make([]string, len(pairs))
+	for i, p := range pairs {
+		params[i] = p[0] + "=" + p[1]
+	}
+
+	var headers strings.Builder
+	for _, name := range signedHeaders {
+		var values []string
+		if name == "host" {
+			values = []string{r.Host}
+		} else {
+			values = r.Header.Values(name)
+		}
+		for i, v := range values {
+			values[i] = strings.Join(strings.Fields(v), " ")
+		}
+		fmt.Fprintf(&headers, "%s:%s\n", name, strings.Join(values, ","))
+	}
+
+	payload := r.Header.Get("X-Amz-Content-Sha256")
+	if payload == "" {
+		var body []byte
+		if r.Body != nil {
+			var err error
+			body, err = ioutil.ReadAll(r.Body)
+			r.Body.Close()
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			if err != nil {
+				return "", fmt.Errorf("reading body: %w", err)
+			}
+		}
+		payload = sha256Hex(body)
+	}
+
+	return strings.Join([]string{
+		r.Method,
+		path,
+		strings.Join(params, "&"),
+		headers.String(),
+		strings.Join(signedHeaders, ";"),
+		payload,
+	}, "\n"), nil
+}
+
+// sigV4Escape percent-encodes every byte but the unreserved characters and,
+// unless encodeSlash, '/'.
+func sigV4Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' && !encodeSlash {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
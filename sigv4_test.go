@@ -0,0 +1,68 @@
+package gohtmock
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the requests and signatures are from the AWS Signature Version 4 test suite
+func TestRequireSigV4(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	rt := newRecordingT("TestRequireSigV4")
+	secrets := func(id string) (string, bool) {
+		return "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", id == "AKIDEXAMPLE"
+	}
+	mock.For(rt).Mock("/", "ok").RequireSigV4("us-east-1", "service", secrets)
+
+	do := func(query, signature string) int {
+		req, _ := http.NewRequest("GET", mock.URL()+"/"+query, nil)
+		req.Host = "example.amazonaws.com"
+		req.Header.Set("X-Amz-Date", "20150830T123600Z")
+		req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature="+signature)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, do("", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"))
+	assert.Equal(t, http.StatusOK, do("?Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"))
+	assert.Empty(t, rt.Errors())
+
+	assert.Equal(t, http.StatusForbidden, do("?Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"))
+	if assert.Len(t, rt.Errors(), 1) {
+		msg := rt.Errors()[0]
+		assert.True(t, strings.HasPrefix(msg, "GET /: signature b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500 does not match"), msg)
+		assert.Contains(t, msg, "canonical request:\nGET\n/\nParam1=value1\nhost:example.amazonaws.com\nx-amz-date:20150830T123600Z\n\nhost;x-amz-date\ne3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855\n")
+		assert.True(t, strings.HasSuffix(msg, "(mocked by TestRequireSigV4)"), msg)
+	}
+}
+
+func TestVerifySigV4Errors(t *testing.T) {
+	secrets := func(id string) (string, bool) { return "secret", id == "AKID" }
+	for auth, msg := range map[string]string{
+		"":                                 "authorization \"\" does not use AWS4-HMAC-SHA256",
+		"AWS4-HMAC-SHA256 Credential=AKID": "malformed credential \"AKID\"",
+		"AWS4-HMAC-SHA256 Credential=AKID/20150830/eu-west-1/s3/aws4_request, SignedHeaders=host, Signature=x":       "credential scope has region \"eu-west-1\", want \"us-east-1\"",
+		"AWS4-HMAC-SHA256 Credential=AKID/20150830/us-east-1/sqs/aws4_request, SignedHeaders=host, Signature=x":      "credential scope has service \"sqs\", want \"s3\"",
+		"AWS4-HMAC-SHA256 Credential=AKID/20150831/us-east-1/s3/aws4_request, SignedHeaders=host, Signature=x":       "credential scope date 20150831 does not match X-Amz-Date 20150830T123600Z",
+		"AWS4-HMAC-SHA256 Credential=AKID/20150830/us-east-1/s3/aws4_request, SignedHeaders=x-amz-date, Signature=x": "SignedHeaders x-amz-date does not include host",
+		"AWS4-HMAC-SHA256 Credential=OTHER/20150830/us-east-1/s3/aws4_request, SignedHeaders=host, Signature=x":      "unknown access key ID OTHER",
+	} {
+		r, _ := http.NewRequest("GET", "http://example.com/", nil)
+		r.Header.Set("X-Amz-Date", "20150830T123600Z")
+		r.Header.Set("Authorization", auth)
+		assert.EqualError(t, verifySigV4(r, "us-east-1", "s3", secrets), msg, auth)
+	}
+}
+
+func TestCanonicalRequestSortsByKey(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://example.com/?a-b=1&b=2&a=2&b=1", nil)
+	canonical, err := canonicalRequest(r, "service", []string{"host"})
+	assert.NoError(t, err)
+	assert.Equal(t, "a=2&a-b=1&b=1&b=2", strings.Split(canonical, "\n")[2])
+}
@@ -0,0 +1,91 @@
+package gohtmock
+
+import (
+	"sort"
+	"strings"
+)
+
+// UnmatchedRequest is a method and path requested without a mock answering,
+// see UnmatchedRequests.
+type UnmatchedRequest struct {
+	Method string
+	Path   string
+	Count  int
+	// Examples are the first few of the requests, if recorded
+	Examples []*RecordedRequest
+	// NearMisses describe the mocks registered for Path that did not
+	// answer, and why
+	NearMisses []string
+}
+
+// CallCount returns how many requests mr has answered.
+func (mr *mockResponse) CallCount() int {
+	mr.Lock()
+	defer mr.Unlock()
+	return mr.calls
+}
+
+// Asserted reports if AssertCallCount was called for the method and path of
+// mr, in the partition of mr if it has one.
+func (mr *mockResponse) Asserted() bool {
+	m := mr.httpMock
+	m.Lock()
+	defer m.Unlock()
+	c := &m.counters
+	for _, p := range m.partitions {
+		if mr.partition != "" && p.id == mr.partition {
+			c = &p.counters
+		}
+	}
+	return c.assertCallCountCalled[callKey(mr.method, mr.host, mr.path)]
+}
+
+// Method returns the method mr answers, ANY for all.
+func (mr *mockResponse) Method() string {
+	mr.Lock()
+	defer mr.Unlock()
+	return mr.method
+}
+
+// Path returns the path mr was registered with.
+func (mr *mockResponse) Path() string {
+	mr.Lock()
+	defer mr.Unlock()
+	return mr.path
+}
+
+// Mocks returns the registered mocks in the order they were registered, for
+// verification logic of one's own.
+func (m *Mock) Mocks() []*mockResponse {
+	m.Lock()
+	defer m.Unlock()
+	return append([]*mockResponse(nil), m.mockResponses...)
+}
+
+// UnmatchedRequests returns the requests AssertNoMissingMocks would fail
+// for, ordered by method and path.
+func (m *Mock) UnmatchedRequests() []UnmatchedRequest {
+	m.Lock()
+	defer m.Unlock()
+	unmatched := make([]UnmatchedRequest, 0, len(m.unmockedRequests))
+	for key, n := range m.unmockedRequests {
+		u := UnmatchedRequest{
+			Path:       key,
+			Count:      n,
+			Examples:   append([]*RecordedRequest(nil), m.unmatched[key]...),
+			NearMisses: append([]string(nil), m.nearMisses[key]...),
+		}
+		// the key is method+path and paths start with a slash
+		if i := strings.Index(key, "/"); i >= 0 {
+			u.Method, u.Path = key[:i], key[i:]
+		}
+		unmatched = append(unmatched, u)
+	}
+	sort.Slice(unmatched, func(i, j int) bool {
+		if unmatched[i].Method != unmatched[j].Method {
+			return unmatched[i].Method < unmatched[j].Method
+		}
+		return unmatched[i].Path < unmatched[j].Path
+	})
+	return unmatched
+}
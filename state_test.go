@@ -0,0 +1,41 @@
+package gohtmock
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateAccessors(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	users := mock.Mock("/users", "[]")
+	orders := mock.Mock("/orders", "[]").SetMethod("POST")
+
+	for _, path := range []string{"/users", "/users", "/missing", "/missing", "/gone"} {
+		resp, err := http.Get(mock.URL() + path)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, []*mockResponse{users, orders}, mock.Mocks())
+	assert.Equal(t, 2, users.CallCount())
+	assert.Equal(t, 0, orders.CallCount())
+	assert.Equal(t, "POST", orders.Method())
+	assert.Equal(t, "/orders", orders.Path())
+
+	assert.False(t, users.Asserted())
+	mock.AssertCallCount(t, "GET", "/users", 2)
+	assert.True(t, users.Asserted())
+
+	unmatched := mock.UnmatchedRequests()
+	if assert.Len(t, unmatched, 2) {
+		assert.Equal(t, "GET", unmatched[0].Method)
+		assert.Equal(t, "/gone", unmatched[0].Path)
+		assert.Equal(t, 1, unmatched[0].Count)
+		assert.Equal(t, "/missing", unmatched[1].Path)
+		assert.Equal(t, 2, unmatched[1].Count)
+		assert.Len(t, unmatched[1].Examples, 2)
+	}
+}
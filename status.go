@@ -0,0 +1,126 @@
+package gohtmock
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// SentStatus is the status line of a response sent by a mock.
+type SentStatus struct {
+	Code   int
+	Reason string
+}
+
+// WithReason makes mr send reason as the reason phrase of its status line
+// instead of the standard one, for example "599 Network Connect Timeout".
+// The response is written to the raw connection, which is closed
+// afterwards. Without a connection to hijack, such as through httpmock, the
+// standard reason phrase is sent.
+func (mr *mockResponse) WithReason(reason string) *mockResponse {
+	mr.Lock()
+	mr.reason = reason
+	mr.Unlock()
+	return mr
+}
+
+// SentStatuses returns the status of every response sent by mr, including
+// extension codes such as 299 or 499 and custom reason phrases.
+func (mr *mockResponse) SentStatuses() []SentStatus {
+	mr.Lock()
+	defer mr.Unlock()
+	return append([]SentStatus(nil), mr.sentStatuses...)
+}
+
+// AssertSentStatus asserts that mr sent at least one response with code and,
+// unless reason is empty, with reason as its reason phrase.
+func (mr *mockResponse) AssertSentStatus(tb testing.TB, code int, reason string) {
+	if mr.httpMock.assertionsDisabled(tb) {
+		return
+	}
+	sent := mr.SentStatuses()
+	for _, s := range sent {
+		if s.Code == code && (reason == "" || s.Reason == reason) {
+			return
+		}
+	}
+	tb.Errorf("%s %s never sent status %d %s, sent %v", mr.method, mr.path, code, reason, sent)
+}
+
+// AssertStatusCount asserts that the mocks for method and path sent status
+// expected times, e.g. to check how many errors a retrying client consumed
+// before it succeeded.
+func (m *Mock) AssertStatusCount(tb testing.TB, method, path string, status, expected int) {
+	m.assertStatusCount(tb, &m.counters, method, path, status, expected)
+}
+
+func (m *Mock) assertStatusCount(tb testing.TB, c *counters, method, path string, status, expected int) {
+	if m.assertionsDisabled(tb) {
+		return
+	}
+	m.Lock()
+	counts := c.statusCount[assertKey(method, path)]
+	cnt := counts[status]
+	sent := formatStatusCounts(counts)
+	m.Unlock()
+	if cnt != expected {
+		tb.Errorf("%s %s sent status %d %d times, expected %d, sent %s", method, path, status, cnt, expected, sent)
+	}
+}
+
+// countStatus counts a response with status sent by mr for key. m must not
+// be locked.
+func (m *Mock) countStatus(c *counters, mr *mockResponse, key string, status int) {
+	m.Lock()
+	defer m.Unlock()
+	if c.statusCount[key] == nil {
+		c.statusCount[key] = make(map[int]int)
+	}
+	c.statusCount[key][status]++
+	mr.noteCounted(c, key, status)
+}
+
+// formatStatusCounts formats counts as "200 x1, 503 x2", ordered by status.
+func formatStatusCounts(counts map[int]int) string {
+	if len(counts) == 0 {
+		return "nothing"
+	}
+	statuses := make([]int, 0, len(counts))
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	parts := make([]string, len(statuses))
+	for i, status := range statuses {
+		parts[i] = fmt.Sprintf("%d x%d", status, counts[status])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (mr *mockResponse) noteSentStatus(s SentStatus) {
+	mr.Lock()
+	mr.sentStatuses = append(mr.sentStatuses, s)
+	mr.Unlock()
+}
+
+// statusNoter is implemented by the response writers of this package that
+// need to know the status of responses written with writeRaw.
+type statusNoter interface {
+	noteStatus(status int, reason string)
+}
+
+// noteRawStatus tells the writers wrapped by w the status of a raw response.
+func noteRawStatus(w http.ResponseWriter, status int, reason string) {
+	for {
+		if n, ok := w.(statusNoter); ok {
+			n.noteStatus(status, reason)
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = u.Unwrap()
+	}
+}
@@ -0,0 +1,79 @@
+package gohtmock
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtensionStatus(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	ok := mock.Mock("/warning", "{}").WithStatus(299)
+	timeout := mock.Mock("/timeout", "gateway gave up").WithStatus(599).WithReason("Network Connect Timeout")
+
+	resp, err := http.Get(mock.URL() + "/warning")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 299, resp.StatusCode)
+
+	resp, err = http.Get(mock.URL() + "/timeout")
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, 599, resp.StatusCode)
+	assert.Equal(t, "599 Network Connect Timeout", resp.Status)
+	assert.Equal(t, "gateway gave up", string(body))
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	assert.Equal(t, []SentStatus{{Code: 299}}, ok.SentStatuses())
+	assert.Equal(t, []SentStatus{{Code: 599, Reason: "Network Connect Timeout"}}, timeout.SentStatuses())
+	timeout.AssertSentStatus(t, 599, "Network Connect Timeout")
+	ok.AssertSentStatus(t, 299, "")
+	rt := newRecordingT("TestExtensionStatus")
+	timeout.AssertSentStatus(rt, 599, "Whatever")
+	assert.Len(t, rt.Errors(), 1)
+}
+
+func TestRawStatusLine(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/teapot", "").WithStatus(418).WithReason("Short And Stout")
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(mock.URL(), "http://"))
+	assert.NoError(t, err)
+	defer conn.Close()
+	fmt.Fprint(conn, "GET /teapot HTTP/1.1\r\nHost: mock\r\n\r\n")
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1 418 Short And Stout\r\n", line)
+}
+
+func TestAssertStatusCount(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/x", "").WithStatus(http.StatusServiceUnavailable).Times(2)
+	mock.Mock("/x", "{}")
+
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(mock.URL() + "/x")
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	mock.AssertStatusCount(t, "GET", "/x", 503, 2)
+	mock.AssertStatusCount(t, "GET", "/x", 200, 1)
+
+	rt := newRecordingT("TestAssertStatusCount")
+	mock.AssertStatusCount(rt, "GET", "/x", 503, 3)
+	mock.AssertStatusCount(rt, "GET", "/y", 200, 1)
+	assert.Equal(t, []string{
+		"GET /x sent status 503 2 times, expected 3, sent 200 x1, 503 x2",
+		"GET /y sent status 200 0 times, expected 1, sent nothing",
+	}, rt.Errors())
+}
@@ -0,0 +1,39 @@
+package gohtmock
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"testing"
+)
+
+// Strict makes every request no mock answers fail tb at once, with the
+// method, path, headers and body of the request, rather than only being
+// answered 404 and found by AssertNoMissingMocks. It ends when tb finishes.
+func (m *Mock) Strict(tb testing.TB) {
+	m.Lock()
+	m.strict = tb
+	m.Unlock()
+	tb.Cleanup(func() {
+		m.Lock()
+		if m.strict == tb {
+			m.strict = nil
+		}
+		m.Unlock()
+	})
+}
+
+// failStrict fails the test of Strict, if any, for the unmatched r. m must
+// be locked.
+func (m *Mock) failStrict(r *http.Request) {
+	if m.strict == nil {
+		return
+	}
+	rewind(r)
+	dump, err := httputil.DumpRequest(r, true)
+	if err != nil {
+		m.strict.Errorf("unmatched request %s %s, dumping it: %s", r.Method, r.URL.RequestURI(), err)
+		return
+	}
+	m.strict.Errorf("unmatched request %s %s:\n%s", r.Method, r.URL.RequestURI(), strings.ReplaceAll(string(dump), "\r\n", "\n"))
+}
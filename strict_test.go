@@ -0,0 +1,35 @@
+package gohtmock
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrict(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/users", "[]")
+	rt := newRecordingT("TestStrict")
+	mock.Strict(rt)
+
+	resp, err := http.Get(mock.URL() + "/users")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Empty(t, rt.Errors())
+
+	req, _ := http.NewRequest("POST", mock.URL()+"/users?notify=1", strings.NewReader(`{"name":"anna"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	if errs := rt.Errors(); assert.Len(t, errs, 1) {
+		assert.True(t, strings.HasPrefix(errs[0], "unmatched request POST /users?notify=1:\nPOST /users?notify=1 HTTP/1.1\n"), errs[0])
+		assert.Contains(t, errs[0], "Content-Type: application/json\n")
+		assert.True(t, strings.HasSuffix(errs[0], "\n\n"+`{"name":"anna"}`), errs[0])
+	}
+}
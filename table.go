@@ -0,0 +1,45 @@
+package gohtmock
+
+import "fmt"
+
+// Def describes one mock in a table passed to MockTable.
+type Def struct {
+	// Name is the key of the mock in the map returned by MockTable.
+	// It defaults to "METHOD path".
+	Name    string
+	Method  string
+	Path    string
+	Status  int
+	Body    string
+	Headers map[string]string
+	// Times limits how many requests the mock answers, 0 means unlimited.
+	Times int
+}
+
+// MockTable registers all defs in order and returns the mocks by name.
+// It panics if two defs end up with the same name.
+func (m *Mock) MockTable(defs []Def) map[string]*mockResponse {
+	mocks := make(map[string]*mockResponse, len(defs))
+	for _, def := range defs {
+		mr := m.newMockResponse(def.Path, def.Body)
+		if def.Method != "" {
+			mr.method = def.Method
+		}
+		for k, v := range def.Headers {
+			mr.headers.Set(k, v)
+		}
+		mr.status = def.Status
+		mr.times = def.Times
+
+		name := def.Name
+		if name == "" {
+			name = mr.method + " " + mr.path
+		}
+		if _, ok := mocks[name]; ok {
+			panic(fmt.Sprintf("gohtmock: MockTable has more than one mock named %q", name))
+		}
+		mocks[name] = mr
+		m.add(mr)
+	}
+	return mocks
+}
@@ -0,0 +1,85 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockTable(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mocks := mock.MockTable([]Def{
+		{Name: "first", Path: "/users", Body: "first", Times: 1},
+		{Name: "rest", Path: "/users", Status: http.StatusTooManyRequests, Body: "slow down"},
+		{Method: "POST", Path: "/users", Status: http.StatusCreated, Headers: map[string]string{"Location": "/users/1"}},
+	})
+	assert.Len(t, mocks, 3)
+	assert.Contains(t, mocks, "POST /users")
+
+	for _, expected := range []struct {
+		status int
+		body   string
+	}{{200, "first"}, {429, "slow down"}, {429, "slow down"}} {
+		resp, err := http.Get(mock.URL() + "/users")
+		assert.NoError(t, err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		assert.Equal(t, expected.status, resp.StatusCode)
+		assert.Equal(t, expected.body, string(body))
+	}
+
+	resp, err := http.Post(mock.URL()+"/users", "application/json", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/users/1", resp.Header.Get("Location"))
+
+	mock.AssertCallCount(t, "GET", "/users", 3)
+	mock.AssertCallCount(t, "POST", "/users", 1)
+}
+
+func TestMockTableDuplicateName(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	assert.Panics(t, func() {
+		mock.MockTable([]Def{{Path: "/a"}, {Path: "/a"}})
+	})
+}
+
+func TestOnce(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/once", "ok").Once()
+
+	resp, err := http.Get(mock.URL() + "/once")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = http.Get(mock.URL() + "/once")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestTimesConcurrent(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/limited", "ok").Times(3)
+
+	var wg sync.WaitGroup
+	var served int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Get(mock.URL() + "/limited")
+			if assert.NoError(t, err) && resp.StatusCode == http.StatusOK {
+				atomic.AddInt32(&served, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), served)
+	mock.AssertCallCount(t, "GET", "/limited", 3)
+}
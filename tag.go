@@ -0,0 +1,136 @@
+package gohtmock
+
+import (
+	"testing"
+	"time"
+)
+
+// Selector selects mocks for assertions and resets, see ByTag.
+type Selector func(mr *mockResponse) bool
+
+// ByTag selects the mocks tagged with tag.
+func ByTag(tag string) Selector {
+	return func(mr *mockResponse) bool {
+		return mr.hasTag(tag)
+	}
+}
+
+// Tag adds tags to mr, grouping it with other mocks for ByTag.
+func (mr *mockResponse) Tag(tags ...string) *mockResponse {
+	mr.Lock()
+	mr.tags = append(mr.tags, tags...)
+	mr.Unlock()
+	return mr
+}
+
+func (mr *mockResponse) hasTag(tag string) bool {
+	mr.Lock()
+	defer mr.Unlock()
+	return containsString(mr.tags, tag)
+}
+
+// selected returns the mocks matched by all selectors. m must be locked.
+func (m *Mock) selected(selectors []Selector) []*mockResponse {
+	var mocks []*mockResponse
+outer:
+	for _, mr := range m.mockResponses {
+		for _, s := range selectors {
+			if !s(mr) {
+				continue outer
+			}
+		}
+		mocks = append(mocks, mr)
+	}
+	return mocks
+}
+
+// AssertAllCalled asserts that every mock matched by the selectors has been
+// called at least once.
+func (m *Mock) AssertAllCalled(tb testing.TB, selectors ...Selector) {
+	if m.assertionsDisabled(tb) {
+		return
+	}
+	m.Lock()
+	mocks := m.selected(selectors)
+	m.Unlock()
+	for _, mr := range mocks {
+		mr.Lock()
+		calls := mr.calls
+		mr.Unlock()
+		if calls == 0 {
+			tb.Errorf("%s %s mocked but never called.%s", mr.method, mr.path, mr.ownedBy())
+		}
+	}
+}
+
+// ResetByTag forgets the calls and recorded requests of the mocks tagged
+// with tag, as if they had just been registered. Their calls and statuses
+// no longer count for AssertCallCount and AssertStatusCount either.
+func (m *Mock) ResetByTag(tag string) {
+	m.Lock()
+	mocks := m.selected([]Selector{ByTag(tag)})
+	for _, mr := range mocks {
+		mr.uncount()
+	}
+	m.Unlock()
+	for _, mr := range mocks {
+		mr.reset()
+	}
+}
+
+// countedKey is a call (status 0) or a response with status counted in c
+// under key.
+type countedKey struct {
+	c      *counters
+	key    string
+	status int
+}
+
+// noteCounted remembers that mr added a call (status 0) or a response with
+// status to c under key. m must be locked.
+func (mr *mockResponse) noteCounted(c *counters, key string, status int) {
+	mr.Lock()
+	defer mr.Unlock()
+	if mr.counted == nil {
+		mr.counted = make(map[countedKey]int)
+	}
+	mr.counted[countedKey{c, key, status}]++
+}
+
+// uncount takes the calls and statuses of mr back from the counters. m must
+// be locked.
+func (mr *mockResponse) uncount() {
+	mr.Lock()
+	defer mr.Unlock()
+	for k, n := range mr.counted {
+		if k.status == 0 {
+			if k.c.callCount[k.key] -= n; k.c.callCount[k.key] <= 0 {
+				delete(k.c.callCount, k.key)
+			}
+			continue
+		}
+		counts := k.c.statusCount[k.key]
+		if counts[k.status] -= n; counts[k.status] <= 0 {
+			delete(counts, k.status)
+		}
+	}
+	mr.counted = nil
+}
+
+func (mr *mockResponse) reset() {
+	mr.Lock()
+	defer mr.Unlock()
+	mr.calls = 0
+	mr.matchedPaths = nil
+	mr.firstCallSeq = 0
+	mr.requests = nil
+	mr.droppedRequests = 0
+	mr.lastArrival = time.Time{}
+	mr.interArrivals = nil
+	mr.durations = nil
+	mr.retries = 0
+	mr.sentStatuses = nil
+	if mr.deduped != nil {
+		mr.deduped = make(map[string]*dedupedResponse)
+	}
+}
@@ -0,0 +1,57 @@
+package gohtmock
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTags(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	invoices := mock.Mock("/invoices", "[]").Tag("billing")
+	mock.Mock("/payments", "[]").Tag("billing", "payments").Once()
+	mock.Mock("/users", "[]")
+
+	for _, path := range []string{"/invoices", "/payments"} {
+		resp, err := http.Get(mock.URL() + path)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	mock.AssertAllCalled(t, ByTag("billing"))
+	mock.AssertAllCalled(t, ByTag("billing"), ByTag("payments"))
+
+	rt := newRecordingT("TestTags")
+	mock.AssertAllCalled(rt)
+	assert.Equal(t, []string{"GET /users mocked but never called."}, rt.Errors())
+
+	mock.ResetByTag("billing")
+	assert.Empty(t, invoices.Requests())
+	rt = newRecordingT("TestTags")
+	mock.AssertAllCalled(rt, ByTag("billing"))
+	assert.Len(t, rt.Errors(), 2)
+
+	resp, err := http.Get(mock.URL() + "/payments")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestResetByTagResetsCounts(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/invoices", "[]").Tag("billing").Filter(func(r *http.Request) bool { return r.URL.Query().Get("tagged") != "" })
+	mock.Mock("/invoices", "[]")
+
+	for _, query := range []string{"?tagged=1", "?tagged=1", ""} {
+		resp, err := http.Get(mock.URL() + "/invoices" + query)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	mock.AssertCallCount(t, "GET", "/invoices", 3)
+
+	mock.ResetByTag("billing")
+	mock.AssertCallCount(t, "GET", "/invoices", 1)
+	mock.AssertStatusCount(t, "GET", "/invoices", http.StatusOK, 1)
+}
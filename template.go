@@ -0,0 +1,67 @@
+package gohtmock
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"text/template"
+)
+
+// TemplateData is available to response bodies of mocks using Template.
+type TemplateData struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   string
+	// RequestID is the value of the first header echoed with EchoHeader
+	RequestID string
+	// Params are the groups captured by the path pattern, see PathParams
+	Params map[string]string
+}
+
+// Template makes the response body of mr a text/template executed with
+// TemplateData for every request, for example
+//
+//	{"id": "{{ .Query.Get "id" }}", "trace": "{{ .RequestID }}"}
+//
+// or, for a mock with a path pattern capturing id,
+//
+//	{"id": "{{ .Params.id }}"}
+func (mr *mockResponse) Template() *mockResponse {
+	mr.Lock()
+	mr.template = true
+	mr.Unlock()
+	return mr
+}
+
+// render executes body as the template of mr for r.
+func (mr *mockResponse) render(body []byte, r *http.Request) ([]byte, error) {
+	t, err := template.New(mr.path).Option("missingkey=zero").Parse(string(body))
+	if err != nil {
+		return nil, err
+	}
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	data := TemplateData{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header,
+		Body:   string(reqBody),
+		Params: PathParams(r),
+	}
+	mr.Lock()
+	if len(mr.echoHeaders) > 0 {
+		data.RequestID = r.Header.Get(mr.echoHeaders[0])
+	}
+	mr.Unlock()
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
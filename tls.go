@@ -0,0 +1,34 @@
+package gohtmock
+
+import "crypto/x509"
+
+// WithTLS serves the mock over HTTPS with a self-signed certificate, see
+// Certificate and Client. It can not be combined with WithRawHeaders.
+func WithTLS() Option {
+	return func(m *Mock) {
+		m.tls = true
+	}
+}
+
+// NewTLS is New with WithTLS.
+func NewTLS(opts ...Option) *Mock {
+	return New(append(opts, WithTLS())...)
+}
+
+// Certificate returns the certificate the mock serves HTTPS with, nil unless
+// it was created with WithTLS.
+func (m *Mock) Certificate() *x509.Certificate {
+	return m.server.Certificate()
+}
+
+// CertPool returns a pool trusting Certificate, for clients that build their
+// own tls.Config. It is nil unless the mock was created with WithTLS.
+func (m *Mock) CertPool() *x509.CertPool {
+	cert := m.server.Certificate()
+	if cert == nil {
+		return nil
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return pool
+}
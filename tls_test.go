@@ -0,0 +1,52 @@
+package gohtmock
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTLS(t *testing.T) {
+	mock := NewTLS()
+	defer mock.Close()
+	mock.Mock("/secure", "ok")
+
+	assert.True(t, strings.HasPrefix(mock.URL(), "https://"))
+	assert.NotNil(t, mock.Certificate())
+
+	resp, err := mock.Client().Get(mock.URL() + "/secure")
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "ok", string(body))
+	}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: mock.CertPool()}}}
+	resp, err = client.Get(mock.URL() + "/secure")
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+
+	_, err = http.Get(mock.URL() + "/secure")
+	assert.Error(t, err)
+
+	resp, err = mock.ClientFor(t).Get(mock.URL() + "/secure")
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+	mock.AssertCallCount(t, "GET", "/secure", 2)
+	mock.Partition(t).AssertCallCount(t, "GET", "/secure", 1)
+}
+
+func TestWithoutTLS(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+
+	assert.Nil(t, mock.Certificate())
+	assert.Nil(t, mock.CertPool())
+	assert.Panics(t, func() { New(WithTLS(), WithRawHeaders()) })
+}
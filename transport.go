@@ -0,0 +1,66 @@
+package gohtmock
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Client returns an *http.Client sending every request to the mock, see
+// Transport. With WithTLS it trusts the certificate of the mock.
+func (m *Mock) Client() *http.Client {
+	return &http.Client{Transport: m.Transport()}
+}
+
+// Transport returns an http.RoundTripper sending every request to the mock,
+// whatever host its URL names, so that code calling third-party APIs with a
+// fixed base URL can be pointed at the mock. The request keeps its Host
+// header, which MockURL mocks match on.
+func (m *Mock) Transport() http.RoundTripper {
+	target, _ := url.Parse(m.server.URL)
+	return &mockTransport{target: target, next: m.transport()}
+}
+
+// transport is the http.RoundTripper clients of the mock send requests with.
+func (m *Mock) transport() http.RoundTripper {
+	if m.tls {
+		return m.server.Client().Transport
+	}
+	return http.DefaultTransport
+}
+
+type mockTransport struct {
+	target *url.URL
+	next   http.RoundTripper
+}
+
+func (t *mockTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	out := r.Clone(r.Context())
+	if out.Host == "" {
+		out.Host = r.URL.Host
+	}
+	out.URL.Scheme = t.target.Scheme
+	out.URL.Host = t.target.Host
+	resp, err := t.next.RoundTrip(out)
+	if resp != nil {
+		resp.Request = r
+	}
+	return resp, err
+}
+
+type unmatchedKey struct{}
+
+// WithUnmatched returns a copy of ctx with which the mock sets *unmatched
+// when it serves a request that no mock or DefaultFor fallback answers. It
+// lets transports serving requests in-process, such as the one of package
+// httpmock, fail those requests instead of returning the 404.
+func WithUnmatched(ctx context.Context, unmatched *bool) context.Context {
+	return context.WithValue(ctx, unmatchedKey{}, unmatched)
+}
+
+// noteUnmatchedContext sets the flag of WithUnmatched in the context of r, if any.
+func noteUnmatchedContext(r *http.Request) {
+	if unmatched, ok := r.Context().Value(unmatchedKey{}).(*bool); ok {
+		*unmatched = true
+	}
+}
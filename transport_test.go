@@ -0,0 +1,43 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransport(t *testing.T) {
+	for name, mock := range map[string]*Mock{"http": New(), "https": NewTLS()} {
+		t.Run(name, func(t *testing.T) {
+			defer mock.Close()
+			mock.MockURL("https://api.stripe.com/v1/charges", "stripe")
+			mock.Mock("/v1/users", "any host")
+
+			client := mock.Client()
+			for url, expected := range map[string]string{
+				"https://api.stripe.com/v1/charges": "stripe",
+				"http://example.com/v1/users":       "any host",
+				mock.URL() + "/v1/users":            "any host",
+				"https://api.github.com/v1/charges": "/v1/charges not found",
+			} {
+				resp, err := client.Get(url)
+				if assert.NoError(t, err, url) {
+					body, _ := ioutil.ReadAll(resp.Body)
+					resp.Body.Close()
+					assert.Equal(t, expected, string(body), url)
+					assert.Equal(t, url, resp.Request.URL.String())
+				}
+			}
+			mock.AssertCallCount(t, "GET", "https://api.stripe.com/v1/charges", 1)
+
+			req, _ := http.NewRequest("GET", "https://api.stripe.com/v1/charges", nil)
+			resp, err := mock.Transport().RoundTrip(req)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdate(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mr := mock.Mock("/flag", `{"on":false}`)
+
+	get := func() (int, string) {
+		resp, err := http.Get(mock.URL() + "/flag")
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		return resp.StatusCode, string(b)
+	}
+
+	_, body := get()
+	assert.Equal(t, `{"on":false}`, body)
+
+	mr.Update(`{"on":true}`)
+	_, body = get()
+	assert.Equal(t, `{"on":true}`, body)
+
+	mr.UpdateResponder(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	status, _ := get()
+	assert.Equal(t, http.StatusTeapot, status)
+
+	mr.Update(`{}`)
+	status, body = get()
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{}`, body)
+
+	mock.AssertCallCount(t, "GET", "/flag", 4)
+}
+
+func TestUpdateConcurrent(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mr := mock.Mock("/flag", "{}")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			mr.UpdateResponder(func(w http.ResponseWriter, r *http.Request) {})
+			mr.Update("{}")
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		resp, err := http.Get(mock.URL() + "/flag")
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	<-done
+}
@@ -0,0 +1,92 @@
+package gohtmock
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MockURL registers a mock for an absolute URL such as
+// https://api.stripe.com/v1/charges. Besides the path the mock only answers
+// requests whose Host is the host of rawURL, which matters when one Mock
+// stands in for several hosts, and carrying the query parameters of rawURL,
+// if any. The port is only compared if rawURL has one. Calls are counted
+// per host: use the absolute URL as path in AssertCallCount.
+func (m *Mock) MockURL(rawURL, resp string) *mockResponse {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		panic(fmt.Sprintf("gohtmock: MockURL needs an absolute url, got %q", rawURL))
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	mr := m.newMockResponse(path, resp)
+	mr.host = u.Host
+	if u.RawQuery != "" {
+		mr.query = u.Query()
+	}
+	m.add(mr)
+	return mr
+}
+
+// matchesURL reports if the host and query of r satisfy mr.
+func (mr *mockResponse) matchesURL(r *http.Request) bool {
+	mr.Lock()
+	host, query := mr.host, mr.query
+	mr.Unlock()
+	if host != "" && !hostMatches(host, requestHost(r)) {
+		return false
+	}
+	if len(query) > 0 {
+		got := r.URL.Query()
+		for k, values := range query {
+			for _, v := range values {
+				if !containsString(got[k], v) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func requestHost(r *http.Request) string {
+	if r.Host != "" {
+		return r.Host
+	}
+	return r.URL.Host
+}
+
+// hostMatches compares hosts case insensitively, ignoring the port of got
+// unless want has one.
+func hostMatches(want, got string) bool {
+	if _, _, err := net.SplitHostPort(want); err != nil {
+		if h, _, err := net.SplitHostPort(got); err == nil {
+			got = h
+		}
+	}
+	return strings.EqualFold(want, got)
+}
+
+// callKey is the key of the call counters for a request to path answered
+// by a mock registered for host, "" if the mock is not host-aware.
+func callKey(method, host, path string) string {
+	if host == "" {
+		return method + path
+	}
+	return method + "//" + host + path
+}
+
+// assertKey is the call counter key for path as given to AssertCallCount,
+// which may be an absolute URL or start with //host as in callKey.
+func assertKey(method, path string) string {
+	if strings.Contains(path, "://") || strings.HasPrefix(path, "//") {
+		if u, err := url.Parse(path); err == nil {
+			return callKey(method, u.Host, u.Path)
+		}
+	}
+	return method + path
+}
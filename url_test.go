@@ -0,0 +1,41 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockURL(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	stripe := mock.MockURL("https://api.stripe.com/v1/charges", `"stripe"`)
+	mock.MockURL("https://api.example.com/v1/charges?currency=sek", `"example"`)
+	mock.Mock("/v1/charges", `"any host"`)
+
+	get := func(host, query string) string {
+		req, _ := http.NewRequest(http.MethodGet, mock.URL()+"/v1/charges"+query, nil)
+		req.Host = host
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	assert.Equal(t, `"stripe"`, get("API.stripe.com:443", ""))
+	assert.Equal(t, `"any host"`, get("api.example.com", ""))
+	assert.Equal(t, `"example"`, get("api.example.com", "?currency=sek&limit=1"))
+	assert.Equal(t, `"any host"`, get("localhost", ""))
+
+	assert.Equal(t, "API.stripe.com:443", stripe.Requests()[0].Host)
+	mock.AssertCallCount(t, "GET", "https://api.stripe.com/v1/charges", 1)
+	mock.AssertCallCount(t, "GET", "https://api.example.com/v1/charges", 1)
+	mock.AssertCallCount(t, "GET", "/v1/charges", 2)
+	mock.AssertCallCountAsserted(t)
+	mock.AssertMocksCalled(t)
+
+	assert.Panics(t, func() { mock.MockURL("/relative", "") })
+}
@@ -0,0 +1,53 @@
+package gohtmock
+
+import (
+	"net/http"
+	"strings"
+)
+
+type variants struct {
+	header string
+	bodies map[string]string
+}
+
+// VaryOn makes mr select its body by the value of the request header, for
+// example
+//
+//	mr.VaryOn("Accept-Language", map[string]string{"sv": svBody, "en": enBody})
+//
+// The response carries a Vary header naming it. List values are tried in
+// order, ignoring parameters such as q, and a value like sv-SE also selects
+// sv. Requests selecting no variant get the regular body.
+func (mr *mockResponse) VaryOn(header string, bodies map[string]string) *mockResponse {
+	mr.Lock()
+	mr.variants = append(mr.variants, variants{header: http.CanonicalHeaderKey(header), bodies: bodies})
+	mr.Unlock()
+	return mr
+}
+
+// variant adds the Vary headers of mr to w and returns the body selected by
+// r, if any. mr must be locked.
+func (mr *mockResponse) variant(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var body string
+	var found bool
+	for _, v := range mr.variants {
+		w.Header().Add("Vary", v.header)
+		if found {
+			continue
+		}
+		for _, value := range strings.Split(r.Header.Get(v.header), ",") {
+			value = strings.TrimSpace(strings.Split(value, ";")[0])
+			if b, ok := v.bodies[value]; ok {
+				body, found = b, true
+				break
+			}
+			if i := strings.Index(value, "-"); i > 0 {
+				if b, ok := v.bodies[value[:i]]; ok {
+					body, found = b, true
+					break
+				}
+			}
+		}
+	}
+	return body, found
+}
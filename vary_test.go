@@ -0,0 +1,40 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVaryOn(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	mock.Mock("/greeting", `"hello"`).VaryOn("accept-language", map[string]string{
+		"sv": `"hej"`,
+		"en": `"hello"`,
+		"de": `"hallo"`,
+	})
+
+	get := func(lang string) (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodGet, mock.URL()+"/greeting", nil)
+		req.Header.Set("Accept-Language", lang)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	resp, body := get("sv")
+	assert.Equal(t, `"hej"`, body)
+	assert.Equal(t, "Accept-Language", resp.Header.Get("Vary"))
+	_, body = get("fr, de;q=0.8, en;q=0.5")
+	assert.Equal(t, `"hallo"`, body)
+	_, body = get("sv-SE")
+	assert.Equal(t, `"hej"`, body)
+	resp, body = get("fi")
+	assert.Equal(t, `"hello"`, body)
+	assert.Equal(t, "Accept-Language", resp.Header.Get("Vary"))
+}
@@ -0,0 +1,362 @@
+package gohtmock
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+type wireMockFile struct {
+	Mappings []wireMockMapping `json:"mappings"`
+	wireMockMapping
+}
+
+type wireMockMapping struct {
+	Name     string           `json:"name"`
+	Priority int              `json:"priority"`
+	Request  *wireMockRequest `json:"request"`
+	Response wireMockResponse `json:"response"`
+}
+
+type wireMockRequest struct {
+	Method          string                      `json:"method"`
+	URL             string                      `json:"url"`
+	URLPath         string                      `json:"urlPath"`
+	URLPattern      string                      `json:"urlPattern"`
+	URLPathPattern  string                      `json:"urlPathPattern"`
+	QueryParameters map[string]*wireMockMatcher `json:"queryParameters"`
+	Headers         map[string]*wireMockMatcher `json:"headers"`
+	BodyPatterns    []*wireMockMatcher          `json:"bodyPatterns"`
+}
+
+type wireMockMatcher struct {
+	EqualTo         *string         `json:"equalTo"`
+	Contains        *string         `json:"contains"`
+	Matches         *string         `json:"matches"`
+	DoesNotMatch    *string         `json:"doesNotMatch"`
+	EqualToJSON     json.RawMessage `json:"equalToJson"`
+	Absent          bool            `json:"absent"`
+	CaseInsensitive bool            `json:"caseInsensitive"`
+	// unsupported are the keys of matcher kinds gohtmock does not know,
+	// such as matchesJsonPath or equalToXml
+	unsupported []string
+	// pattern is the anchored regexp of matches or doesNotMatch, set by compile
+	pattern *regexp.Regexp
+}
+
+var wireMockMatcherKeys = map[string]bool{
+	"equalTo": true, "contains": true, "matches": true, "doesNotMatch": true,
+	"equalToJson": true, "absent": true, "caseInsensitive": true,
+}
+
+func (wm *wireMockMatcher) UnmarshalJSON(b []byte) error {
+	type plain wireMockMatcher
+	if err := json.Unmarshal(b, (*plain)(wm)); err != nil {
+		return err
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return err
+	}
+	for k := range keys {
+		if !wireMockMatcherKeys[k] {
+			wm.unsupported = append(wm.unsupported, k)
+		}
+	}
+	sort.Strings(wm.unsupported)
+	return nil
+}
+
+type wireMockResponse struct {
+	Status                 int             `json:"status"`
+	Body                   string          `json:"body"`
+	JSONBody               json.RawMessage `json:"jsonBody"`
+	Base64Body             string          `json:"base64Body"`
+	BodyFileName           string          `json:"bodyFileName"`
+	Headers                map[string]any  `json:"headers"`
+	FixedDelayMilliseconds int             `json:"fixedDelayMilliseconds"`
+}
+
+// LoadWireMockStubs registers mocks for all WireMock JSON mappings in dir.
+// dir is either a WireMock root directory containing mappings and __files
+// or the mappings directory itself. Supported are url, urlPath, urlPattern
+// and urlPathPattern matching, query parameter, header and body patterns,
+// priorities, bodyFileName and fixed delays. The equalTo, contains, matches,
+// doesNotMatch, equalToJson and absent matchers are supported; mappings
+// using any other matcher are rejected with an error, in which case none of
+// the mappings are registered.
+func (m *Mock) LoadWireMockStubs(dir string) error {
+	mappingsDir := filepath.Join(dir, "mappings")
+	filesDir := filepath.Join(dir, "__files")
+	if _, err := os.Stat(mappingsDir); err != nil {
+		mappingsDir = dir
+		filesDir = filepath.Join(dir, "..", "__files")
+	}
+
+	paths, err := filepath.Glob(filepath.Join(mappingsDir, "*.json"))
+	if err != nil {
+		return err
+	}
+	var mappings []wireMockMapping
+	for _, path := range paths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var file wireMockFile
+		if err := json.Unmarshal(b, &file); err != nil {
+			return fmt.Errorf("wiremock mapping %s: %w", path, err)
+		}
+		if file.Request != nil {
+			file.Mappings = append(file.Mappings, file.wireMockMapping)
+		}
+		mappings = append(mappings, file.Mappings...)
+	}
+
+	// WireMock treats 1 as the highest priority and mappings without one last
+	sort.SliceStable(mappings, func(i, j int) bool {
+		return priority(mappings[i]) < priority(mappings[j])
+	})
+	// nothing is registered unless every mapping can be
+	mocks := make([]*mockResponse, 0, len(mappings))
+	for _, mapping := range mappings {
+		mr, err := m.wireMock(mapping, filesDir)
+		if err != nil {
+			return fmt.Errorf("wiremock mapping %q: %w", mapping.Name, err)
+		}
+		mocks = append(mocks, mr)
+	}
+	for _, mr := range mocks {
+		m.add(mr)
+	}
+	return nil
+}
+
+func priority(mapping wireMockMapping) int {
+	if mapping.Priority == 0 {
+		return 5
+	}
+	return mapping.Priority
+}
+
+// wireMock returns the mock for mapping without registering it.
+func (m *Mock) wireMock(mapping wireMockMapping, filesDir string) (*mockResponse, error) {
+	req := mapping.Request
+	if req == nil {
+		return nil, fmt.Errorf("missing request")
+	}
+	body, err := mapping.Response.body(filesDir)
+	if err != nil {
+		return nil, err
+	}
+	status := mapping.Response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	delay := time.Duration(mapping.Response.FixedDelayMilliseconds) * time.Millisecond
+
+	mr := m.newMockResponse("", "")
+	mr.headers.Del("Content-Type")
+	for k, v := range mapping.Response.Headers {
+		switch v := v.(type) {
+		case string:
+			mr.headers.Set(k, v)
+		case []any:
+			for _, value := range v {
+				mr.headers.Add(k, fmt.Sprint(value))
+			}
+		}
+	}
+	// a plain body may be an indirect @file: or @env: reference
+	indirect := len(mapping.Response.JSONBody) == 0 && mapping.Response.Base64Body == "" && mapping.Response.BodyFileName == ""
+	mr.handler = func(w http.ResponseWriter, r *http.Request) {
+		body := body
+		if indirect {
+			var err error
+			if body, err = resolveBody(string(body)); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprintf(w, "gohtmock: resolving response for %s: %s", r.URL.Path, err)
+				return
+			}
+		}
+		if !sleep(r.Context(), delay) {
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+	}
+
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "ANY"
+	}
+	mr.method = method
+
+	var query string
+	switch {
+	case req.URL != "":
+		mr.path = req.URL
+		if i := strings.Index(req.URL, "?"); i >= 0 {
+			mr.path, query = req.URL[:i], req.URL[i+1:]
+		}
+	case req.URLPath != "":
+		mr.path = req.URLPath
+	case req.URLPathPattern != "":
+		re, err := regexp.Compile("^(?:" + req.URLPathPattern + ")$")
+		if err != nil {
+			return nil, err
+		}
+		mr.path = req.URLPathPattern
+		mr.pathPattern = re
+	default:
+		// urlPattern covers path and query so it is checked in the filter
+		mr.path = req.URLPattern
+		mr.pathPattern = regexp.MustCompile(".*")
+	}
+
+	var urlPattern *regexp.Regexp
+	if req.URLPattern != "" {
+		if urlPattern, err = regexp.Compile("^(?:" + req.URLPattern + ")$"); err != nil {
+			return nil, err
+		}
+	}
+	for key, matcher := range req.QueryParameters {
+		if err := matcher.compile(); err != nil {
+			return nil, fmt.Errorf("query parameter %s: %w", key, err)
+		}
+	}
+	for key, matcher := range req.Headers {
+		if err := matcher.compile(); err != nil {
+			return nil, fmt.Errorf("header %s: %w", key, err)
+		}
+	}
+	for _, matcher := range req.BodyPatterns {
+		if err := matcher.compile(); err != nil {
+			return nil, fmt.Errorf("body pattern: %w", err)
+		}
+	}
+
+	mr.filter = func(r *http.Request) bool {
+		if req.URL != "" && r.URL.RawQuery != query {
+			return false
+		}
+		if urlPattern != nil && !urlPattern.MatchString(r.URL.RequestURI()) {
+			return false
+		}
+		values := r.URL.Query()
+		for key, matcher := range req.QueryParameters {
+			_, present := values[key]
+			if !matcher.match(values.Get(key), present) {
+				return false
+			}
+		}
+		for key, matcher := range req.Headers {
+			_, present := r.Header[http.CanonicalHeaderKey(key)]
+			if !matcher.match(r.Header.Get(key), present) {
+				return false
+			}
+		}
+		if len(req.BodyPatterns) > 0 {
+			b, err := ioutil.ReadAll(r.Body)
+			r.Body = ioutil.NopCloser(bytes.NewReader(b))
+			if err != nil {
+				return false
+			}
+			for _, matcher := range req.BodyPatterns {
+				if !matcher.match(string(b), true) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return mr, nil
+}
+
+func (r wireMockResponse) body(filesDir string) ([]byte, error) {
+	switch {
+	case r.BodyFileName != "":
+		return ioutil.ReadFile(filepath.Join(filesDir, r.BodyFileName))
+	case r.Base64Body != "":
+		return base64.StdEncoding.DecodeString(r.Base64Body)
+	case len(r.JSONBody) > 0:
+		return r.JSONBody, nil
+	}
+	return []byte(r.Body), nil
+}
+
+// compile checks wm and compiles its regexp, if any.
+func (wm *wireMockMatcher) compile() error {
+	if wm == nil {
+		return fmt.Errorf("matcher without a pattern")
+	}
+	if len(wm.unsupported) > 0 {
+		return fmt.Errorf("unsupported matcher %s", strings.Join(wm.unsupported, ", "))
+	}
+	if wm.EqualTo == nil && wm.Contains == nil && wm.Matches == nil && wm.DoesNotMatch == nil && len(wm.EqualToJSON) == 0 && !wm.Absent {
+		return fmt.Errorf("matcher without a pattern")
+	}
+	pattern := wm.Matches
+	if pattern == nil {
+		pattern = wm.DoesNotMatch
+	}
+	if pattern != nil {
+		re, err := regexp.Compile("^(?:" + *pattern + ")$")
+		if err != nil {
+			return err
+		}
+		wm.pattern = re
+	}
+	return nil
+}
+
+func (wm *wireMockMatcher) match(value string, present bool) bool {
+	if wm.Absent {
+		return !present
+	}
+	if !present {
+		return false
+	}
+	equal := func(a, b string) bool {
+		if wm.CaseInsensitive {
+			return strings.EqualFold(a, b)
+		}
+		return a == b
+	}
+	switch {
+	case wm.EqualTo != nil:
+		return equal(value, *wm.EqualTo)
+	case wm.Contains != nil:
+		if wm.CaseInsensitive {
+			return strings.Contains(strings.ToLower(value), strings.ToLower(*wm.Contains))
+		}
+		return strings.Contains(value, *wm.Contains)
+	case wm.Matches != nil:
+		return wm.pattern.MatchString(value)
+	case wm.DoesNotMatch != nil:
+		return !wm.pattern.MatchString(value)
+	case len(wm.EqualToJSON) > 0:
+		var expected, actual any
+		if json.Unmarshal(wm.EqualToJSON, &expected) != nil || json.Unmarshal([]byte(value), &actual) != nil {
+			return false
+		}
+		if s, ok := expected.(string); ok {
+			// equalToJson may also be given as an escaped JSON string
+			if json.Unmarshal([]byte(s), &expected) != nil {
+				return false
+			}
+		}
+		return reflect.DeepEqual(expected, actual)
+	}
+	return false
+}
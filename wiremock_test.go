@@ -0,0 +1,96 @@
+package gohtmock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadWireMockStubs(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	assert.NoError(t, mock.LoadWireMockStubs("testdata/wiremock"))
+
+	req, _ := http.NewRequest("GET", mock.URL()+"/users/1", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "{\"id\":1,\"name\":\"foo\"}\n", string(body))
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	resp, err = http.Get(mock.URL() + "/users?q=foo")
+	assert.NoError(t, err)
+	body, _ = ioutil.ReadAll(resp.Body)
+	assert.JSONEq(t, `[{"id":1}]`, string(body))
+
+	start := time.Now()
+	resp, err = http.Post(mock.URL()+"/users", "application/json", strings.NewReader(`{"admin":false, "name":"foo"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+
+	resp, err = http.Get(mock.URL() + "/users/1")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+
+	resp, err = http.Post(mock.URL()+"/users", "application/json", strings.NewReader(`{"name":"bar"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+
+	mock.AssertMocksCalled(t)
+	mock.AssertNoMissingMocks(t)
+}
+
+func TestLoadWireMockStubsInvalid(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	dir := t.TempDir()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"request": {"urlPathPattern": "("}}`), 0o600))
+	assert.Error(t, mock.LoadWireMockStubs(dir))
+}
+
+func TestLoadWireMockStubsUnsupportedMatcher(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	dir := t.TempDir()
+	valid := `{"name": "valid", "request": {"urlPath": "/valid"}, "response": {"body": "ok"}}`
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.json"), []byte(valid), 0o600))
+	mapping := `{"name": "jsonpath", "request": {"urlPath": "/users", "bodyPatterns": [{"matchesJsonPath": "$.name"}]}}`
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "jsonpath.json"), []byte(mapping), 0o600))
+	assert.EqualError(t, mock.LoadWireMockStubs(dir), `wiremock mapping "jsonpath": body pattern: unsupported matcher matchesJsonPath`)
+	// the valid mapping is not registered either
+	assert.Empty(t, mock.Mocks())
+}
+
+func TestLoadWireMockStubsCaseInsensitive(t *testing.T) {
+	mock := New()
+	defer mock.Close()
+	dir := t.TempDir()
+	mapping := `{"request": {"urlPath": "/users", "headers": {
+		"Accept": {"contains": "JSON", "caseInsensitive": true},
+		"X-Id": {"matches": "[0-9]+"}
+	}}, "response": {"body": "ok"}}`
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "users.json"), []byte(mapping), 0o600))
+	assert.NoError(t, mock.LoadWireMockStubs(dir))
+
+	get := func(accept, id string) int {
+		req, _ := http.NewRequest("GET", mock.URL()+"/users", nil)
+		req.Header.Set("Accept", accept)
+		req.Header.Set("X-Id", id)
+		resp, err := http.DefaultClient.Do(req)
+		if !assert.NoError(t, err) {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusOK, get("application/json", "12"))
+	assert.Equal(t, http.StatusNotFound, get("text/html", "12"))
+	assert.Equal(t, http.StatusNotFound, get("application/json", "12a"))
+}